@@ -0,0 +1,213 @@
+package s3_event_store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+const (
+	// createdAtKey is the metadata key of the creation time of an object in nanoseconds,
+	// since S3 only keeps the last modified time in seconds.
+	createdAtKey = "event-driver-created-at"
+	// expiresAtKey is the metadata key of the expiry time of an object in nanoseconds.
+	expiresAtKey = "event-driver-expires-at"
+)
+
+// lastCreatedAt is the creation time of the last object written by this process, so that the objects it writes have
+// strictly increasing creation times even if the clock doesn't move between two writes.
+var lastCreatedAt atomic.Int64
+
+// bucketObjectStore implements ObjectStore on an S3 bucket. S3 has no conditional writes in this SDK, nor custom
+// attributes on the listed objects, so the creation & expiry times of an object are kept in its metadata,
+// which costs a HEAD request per listed object. The metadata keys are lowercased by S3, and the objects written
+// without the creation time, e.g. before this layout, fall back to their last modified time.
+// Deleting an object that doesn't exist succeeds, since S3 doesn't report it.
+type bucketObjectStore struct {
+	bucket string
+	client *s3.Client
+}
+
+func BucketObjectStore(client *s3.Client, bucket string) ObjectStore {
+	return &bucketObjectStore{
+		bucket: bucket,
+		client: client,
+	}
+}
+
+// List pages through the objects with ListObjectsV2, where query.StartOffset is sent as StartAfter, which is exclusive.
+// ObjectEventStore never starts from the name of an object it reads, so nothing is missed.
+func (b *bucketObjectStore) List(ctx context.Context, query *Query) ObjectIterator {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+	}
+	if query != nil {
+		input.Prefix = aws.String(query.Prefix)
+		if query.Delimiter != "" {
+			input.Delimiter = aws.String(query.Delimiter)
+		}
+		if query.StartOffset != "" {
+			input.StartAfter = aws.String(query.StartOffset)
+		}
+	}
+
+	return &bucketObjects{
+		ctx:       ctx,
+		objects:   b,
+		paginator: s3.NewListObjectsV2Paginator(b.client, input),
+	}
+}
+
+func (b *bucketObjectStore) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	output, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return output.Body, nil
+}
+
+func (b *bucketObjectStore) Write(ctx context.Context, attrs *ObjectAttrs, content []byte) error {
+	metadata := make(map[string]string, len(attrs.Metadata)+2)
+	for key, value := range attrs.Metadata {
+		metadata[key] = value
+	}
+	metadata[createdAtKey] = strconv.FormatInt(nextCreatedAt(), 10)
+	if !attrs.ExpiresAt.IsZero() {
+		metadata[expiresAtKey] = strconv.FormatInt(attrs.ExpiresAt.UnixNano(), 10)
+	}
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:   aws.String(b.bucket),
+		Key:      aws.String(attrs.Name),
+		Body:     bytes.NewReader(content),
+		Metadata: metadata,
+	})
+
+	return err
+}
+
+func (b *bucketObjectStore) Delete(ctx context.Context, name string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+	})
+
+	return translateError(err)
+}
+
+func (b *bucketObjectStore) Attrs(ctx context.Context, name string) (*ObjectAttrs, error) {
+	output, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	attrs := &ObjectAttrs{
+		Name:     name,
+		Size:     aws.ToInt64(output.ContentLength),
+		Created:  aws.ToTime(output.LastModified),
+		Metadata: make(map[string]string, len(output.Metadata)),
+	}
+	for key, value := range output.Metadata {
+		switch key {
+		case createdAtKey:
+			if createdAt, err := strconv.ParseInt(value, 10, 64); err == nil {
+				attrs.Created = time.Unix(0, createdAt)
+			}
+		case expiresAtKey:
+			if expiresAt, err := strconv.ParseInt(value, 10, 64); err == nil {
+				attrs.ExpiresAt = time.Unix(0, expiresAt)
+			}
+		default:
+			attrs.Metadata[key] = value
+		}
+	}
+
+	return attrs, nil
+}
+
+// bucketObjects implements ObjectIterator on the pages of ListObjectsV2, getting the attributes of each object
+// with a HEAD request. The objects deleted after being listed are skipped.
+type bucketObjects struct {
+	ctx       context.Context
+	objects   *bucketObjectStore
+	paginator *s3.ListObjectsV2Paginator
+	page      []listedObject
+}
+
+// listedObject is an object or a common prefix of a page.
+type listedObject struct {
+	name     string
+	isPrefix bool
+}
+
+func (b *bucketObjects) Next() (*ObjectAttrs, error) {
+	for {
+		for len(b.page) > 0 {
+			listed := b.page[0]
+			b.page = b.page[1:]
+			if listed.isPrefix {
+				return &ObjectAttrs{Prefix: listed.name}, nil
+			}
+			attrs, err := b.objects.Attrs(b.ctx, listed.name)
+			if errors.Is(err, object_event_store.ErrObjectNotExist) {
+				continue
+			}
+
+			return attrs, err
+		}
+		if !b.paginator.HasMorePages() {
+			return nil, object_event_store.ErrNoMoreObjects
+		}
+		page, err := b.paginator.NextPage(b.ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, commonPrefix := range page.CommonPrefixes {
+			b.page = append(b.page, listedObject{name: aws.ToString(commonPrefix.Prefix), isPrefix: true})
+		}
+		for _, object := range page.Contents {
+			b.page = append(b.page, listedObject{name: aws.ToString(object.Key)})
+		}
+		sort.Slice(b.page, func(i, j int) bool {
+			return b.page[i].name < b.page[j].name
+		})
+	}
+}
+
+// nextCreatedAt returns the current time in nanoseconds, or the nanosecond after the last one returned.
+func nextCreatedAt() int64 {
+	for {
+		last := lastCreatedAt.Load()
+		createdAt := max(time.Now().UnixNano(), last+1)
+		if lastCreatedAt.CompareAndSwap(last, createdAt) {
+			return createdAt
+		}
+	}
+}
+
+// translateError maps the errors of S3 to the errors of ObjectStore.
+func translateError(err error) error {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound {
+		return object_event_store.ErrObjectNotExist
+	}
+
+	return err
+}
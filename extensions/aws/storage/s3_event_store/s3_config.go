@@ -0,0 +1,89 @@
+package s3_event_store
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/honestbank/event-driver/storage/object_event_store"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+// S3Config is the configuration of an S3EventStore, i.e. the bucket and the configuration of object_event_store.
+type S3Config struct {
+	Bucket string
+	object_event_store.ObjectConfig
+}
+
+// Config creates a default configuration, that
+// - doesn't do compression/decompression when write & read to S3
+// - takes the earliest created object if there are multiple under the same key/source/ path
+// - enforces universal 30s timeout in S3 requests
+// - reads up to 8 sources concurrently in LookUpByKey
+func Config(bucket string) *S3Config {
+	return &S3Config{
+		Bucket:       bucket,
+		ObjectConfig: *object_event_store.Config(),
+	}
+}
+
+func (c *S3Config) WithCompressor(compressor compression.Compressor) *S3Config {
+	c.ObjectConfig.WithCompressor(compressor)
+
+	return c
+}
+
+// WithConcurrency sets the maximum number of sources read concurrently by LookUpByKey, where 1 reads them sequentially.
+func (c *S3Config) WithConcurrency(concurrency int) *S3Config {
+	c.ObjectConfig.WithConcurrency(concurrency)
+
+	return c
+}
+
+func (c *S3Config) WithFolder(folder string) *S3Config {
+	c.ObjectConfig.WithFolder(folder)
+
+	return c
+}
+
+// WithKeyLayout sets how the keys & sources are mapped to the paths of the objects, see KeyLayout.
+func (c *S3Config) WithKeyLayout(keyLayout KeyLayout) *S3Config {
+	c.ObjectConfig.WithKeyLayout(keyLayout)
+
+	return c
+}
+
+// WithLogger sets the logger of the failures that don't fail the operations, e.g. of CollectGarbage.
+func (c *S3Config) WithLogger(logger *slog.Logger) *S3Config {
+	c.ObjectConfig.WithLogger(logger)
+
+	return c
+}
+
+// WithMetadata sets the metadata of each written object from its key, source and content,
+// e.g. the sequence number of the event for TakeHighestSequence. S3 lowercases the metadata keys.
+func (c *S3Config) WithMetadata(metadata func(key, source, content string) map[string]string) *S3Config {
+	c.ObjectConfig.WithMetadata(metadata)
+
+	return c
+}
+
+func (c *S3Config) WithReadPolicy(readPolicy ReadPolicy) *S3Config {
+	c.ObjectConfig.WithReadPolicy(readPolicy)
+
+	return c
+}
+
+// WithTTL makes the contents expire after the given TTL, by keeping the expiry time in the metadata of each object.
+// Expired objects are skipped on read, but S3 only deletes them with a lifecycle rule on the bucket,
+// which expires the objects by age, so its expiration must be longer than the longest TTL.
+func (c *S3Config) WithTTL(ttl time.Duration) *S3Config {
+	c.ObjectConfig.WithTTL(ttl)
+
+	return c
+}
+
+func (c *S3Config) WithTimeout(timeout Timeout) *S3Config {
+	c.ObjectConfig.WithTimeout(timeout)
+
+	return c
+}
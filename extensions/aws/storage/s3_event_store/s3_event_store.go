@@ -0,0 +1,67 @@
+package s3_event_store
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+// S3EventStore persists the contents in an S3-compatible object storage (AWS S3, MinIO, etc.).
+// The `folder/key/source/sha256` layout (see KeyLayout) and the ReadPolicy are those of object_event_store,
+// shared with the GCS event store of the Google Cloud extension, applied on top of an S3 bucket.
+type S3EventStore = object_event_store.ObjectEventStore
+
+// The types of object_event_store that S3Config and S3EventStore are made of.
+type (
+	KeyEncoding        = object_event_store.KeyEncoding
+	KeyLayout          = object_event_store.KeyLayout
+	MergingReadPolicy  = object_event_store.MergingReadPolicy
+	ObjectAttrs        = object_event_store.ObjectAttrs
+	ObjectIterator     = object_event_store.ObjectIterator
+	ObjectStore        = object_event_store.ObjectStore
+	Operation          = object_event_store.Operation
+	PartialLookUpError = object_event_store.PartialLookUpError
+	Query              = object_event_store.Query
+	ReadPolicy         = object_event_store.ReadPolicy
+	Timeout            = object_event_store.Timeout
+)
+
+const (
+	ListContents  = object_event_store.ListContents
+	ReadContent   = object_event_store.ReadContent
+	WriteContent  = object_event_store.WriteContent
+	DeleteContent = object_event_store.DeleteContent
+)
+
+var (
+	ErrGarbageCollectingMerge = object_event_store.ErrGarbageCollectingMerge
+	ErrMultipleObjects        = object_event_store.ErrMultipleObjects
+)
+
+// The key encodings and read policies of object_event_store.
+var (
+	RawKeyEncoding      = object_event_store.RawKeyEncoding
+	EscapedKeyEncoding  = object_event_store.EscapedKeyEncoding
+	TakeFirstCreated    = object_event_store.TakeFirstCreated
+	TakeLastCreated     = object_event_store.TakeLastCreated
+	FailOnMultiple      = object_event_store.FailOnMultiple
+	TakeByMetadata      = object_event_store.TakeByMetadata
+	TakeHighestSequence = object_event_store.TakeHighestSequence
+	MergeConcatenated   = object_event_store.MergeConcatenated
+	MergeAsJSONArray    = object_event_store.MergeAsJSONArray
+	CollectGarbage      = object_event_store.CollectGarbage
+)
+
+func New(cfg *S3Config, client *s3.Client) (storage.EventStore, error) {
+	if cfg == nil {
+		return nil, errors.New("s3 config cannot be null")
+	}
+	if client == nil {
+		return nil, errors.New("s3 client cannot be null")
+	}
+
+	return object_event_store.New(&cfg.ObjectConfig, BucketObjectStore(client, cfg.Bucket))
+}
@@ -0,0 +1,283 @@
+package s3_event_store_test
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/extensions/aws/storage/s3_event_store"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+const (
+	key     = "key"
+	source1 = "source1"
+	source2 = "source2"
+	content = "content"
+)
+
+func TestS3EventStore(t *testing.T) {
+	folderName := "folder-name"
+
+	t.Run("query empty bucket", func(t *testing.T) {
+		client := setup(t, "empty-bucket")
+		config := s3_event_store.Config("empty-bucket").WithFolder(folderName)
+		eventStore, err := s3_event_store.New(config, client)
+		assert.NoError(t, err)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, messageArray)
+	})
+
+	t.Run("with folder prefix", func(t *testing.T) {
+		client := setup(t, "with-prefix")
+		config := s3_event_store.Config("with-prefix").WithFolder(folderName)
+		eventStore, err := s3_event_store.New(config, client)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		objects, err := client.ListObjectsV2(context.TODO(), &s3.ListObjectsV2Input{
+			Bucket: aws.String("with-prefix"),
+			Prefix: aws.String(folderName + "/" + key + "/" + source1 + "/"),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, objects.Contents, 2)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+	})
+
+	t.Run("take last created", func(t *testing.T) {
+		client := setup(t, "take-last-created")
+		config := s3_event_store.Config("take-last-created").WithReadPolicy(s3_event_store.TakeLastCreated())
+		eventStore, err := s3_event_store.New(config, client)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "something else"), message)
+	})
+
+	t.Run("order by the creation time in nanoseconds", func(t *testing.T) {
+		client := setup(t, "order")
+		firstEventStore, err := s3_event_store.New(s3_event_store.Config("order"), client)
+		assert.NoError(t, err)
+		lastEventStore, err := s3_event_store.New(
+			s3_event_store.Config("order").WithReadPolicy(s3_event_store.TakeLastCreated()), client)
+		assert.NoError(t, err)
+
+		for index := 0; index < 10; index++ {
+			assert.NoError(t, firstEventStore.Persist(context.TODO(), key, source1, strconv.Itoa(index)))
+		}
+
+		message, err := firstEventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "0"), message)
+		message, err = lastEventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "9"), message)
+		revisions, err := firstEventStore.(storage.HistoryEventStore).ListRevisions(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 10)
+		message, err = firstEventStore.(storage.HistoryEventStore).LookUpRevision(context.TODO(), key, source1,
+			revisions[9].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "9"), message)
+	})
+
+	t.Run("read the objects written without metadata", func(t *testing.T) {
+		client := setup(t, "without-metadata")
+		eventStore, err := s3_event_store.New(s3_event_store.Config("without-metadata"), client)
+		assert.NoError(t, err)
+		_, err = client.PutObject(context.TODO(), &s3.PutObjectInput{
+			Bucket: aws.String("without-metadata"),
+			Key:    aws.String(key + "/" + source1 + "/sha"),
+			Body:   strings.NewReader(content),
+		})
+		assert.NoError(t, err)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+	})
+
+	t.Run("share the key layout & read policies of object stores", func(t *testing.T) {
+		client := setup(t, "shared")
+		config := s3_event_store.Config("shared").WithFolder(folderName).
+			WithKeyLayout(s3_event_store.KeyLayout{Encoding: s3_event_store.EscapedKeyEncoding(), ShardPrefixSize: 2}).
+			WithMetadata(func(key, source, content string) map[string]string {
+				return map[string]string{"sequence": content}
+			}).
+			WithReadPolicy(s3_event_store.TakeHighestSequence("sequence"))
+		eventStore, err := s3_event_store.New(config, client)
+		assert.NoError(t, err)
+
+		for _, sequence := range []string{"2", "3", "1"} {
+			assert.NoError(t, eventStore.Persist(context.TODO(), "orders/1", source1, sequence))
+		}
+		assert.NoError(t, eventStore.(storage.ExpiringEventStore).
+			PersistWithTTL(context.TODO(), "orders/1", source2, content, time.Millisecond))
+		time.Sleep(time.Millisecond)
+
+		message, err := eventStore.LookUp(context.TODO(), "orders/1", source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("orders/1", source1, "3"), message)
+		message, err = eventStore.LookUp(context.TODO(), "orders/1", source2)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+		keys, cursor, err := eventStore.(storage.KeyListingEventStore).ListKeys(context.TODO(), "orders/", "", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"orders/1"}, keys)
+		assert.Empty(t, cursor)
+		_, isConditional := eventStore.(storage.ConditionalEventStore)
+		assert.False(t, isConditional)
+	})
+
+	t.Run("with compression", func(t *testing.T) {
+		client := setup(t, "with-compression")
+		config := s3_event_store.Config("with-compression").WithCompressor(compression.Gzip(gzip.BestSpeed))
+		eventStore, err := s3_event_store.New(config, client)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		client := setup(t, "delete")
+		config := s3_event_store.Config("delete").WithFolder(folderName)
+		eventStore, err := s3_event_store.New(config, client)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		sources, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		// deleting what doesn't exist is not an error
+		assert.NoError(t, eventStore.Delete(context.TODO(), key, source1))
+		assert.NoError(t, eventStore.DeleteByKey(context.TODO(), key))
+	})
+
+	t.Run("s3 error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		millisecond := time.Millisecond
+		config := s3_event_store.Config("bucket").WithTimeout(s3_event_store.Timeout{Default: &millisecond})
+		eventStore, err := s3_event_store.New(config, newClient(server.URL))
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.Error(t, err)
+
+		_, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.Error(t, err)
+
+		_, err = eventStore.LookUp(context.TODO(), key, source1)
+		assert.Error(t, err)
+
+		_, err = eventStore.LookUpByKey(context.TODO(), key)
+		assert.Error(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.Error(t, err)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := s3_event_store.New(nil, newClient("http://localhost"))
+		assert.Error(t, err)
+		_, err = s3_event_store.New(s3_event_store.Config("bucket"), nil)
+		assert.Error(t, err)
+	})
+}
+
+// setup starts an in-process fake S3 server with the bucket created.
+func setup(t *testing.T, bucket string) *s3.Client {
+	server := httptest.NewServer(gofakes3.New(s3mem.New()).Server())
+	t.Cleanup(server.Close)
+
+	client := newClient(server.URL)
+	_, err := client.CreateBucket(context.TODO(), &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+	assert.NoError(t, err)
+
+	return client
+}
+
+func newClient(endpoint string) *s3.Client {
+	return s3.New(s3.Options{
+		BaseEndpoint: aws.String(endpoint),
+		Credentials:  credentials.NewStaticCredentialsProvider("access-key", "secret-key", ""),
+		Region:       "us-east-1",
+		UsePathStyle: true,
+	})
+}
@@ -0,0 +1,54 @@
+package bolt_event_store
+
+import (
+	"time"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type BoltConfig struct {
+	Path        string
+	Bucket      string
+	Compressor  compression.Compressor
+	OpenTimeout time.Duration  // how long to wait for the file lock when opening the database, 0 waits forever
+	TTL         *time.Duration // contents expire after TTL if configured
+}
+
+// Config creates a default configuration, that
+// - stores the contents in the database file under the given path, within the bucket "events"
+// - doesn't do compression/decompression when write & read the contents
+// - waits at most 1s for the file lock when opening the database
+// - never expires the contents
+func Config(path string) *BoltConfig {
+	return &BoltConfig{
+		Path:        path,
+		Bucket:      "events",
+		Compressor:  compression.Noop(),
+		OpenTimeout: time.Second,
+	}
+}
+
+// WithBucket sets the top-level bucket, which plays the role of the folder in GCSEventStore.
+func (c *BoltConfig) WithBucket(bucket string) *BoltConfig {
+	c.Bucket = bucket
+
+	return c
+}
+
+func (c *BoltConfig) WithCompressor(compressor compression.Compressor) *BoltConfig {
+	c.Compressor = compressor
+
+	return c
+}
+
+func (c *BoltConfig) WithOpenTimeout(openTimeout time.Duration) *BoltConfig {
+	c.OpenTimeout = openTimeout
+
+	return c
+}
+
+func (c *BoltConfig) WithTTL(ttl time.Duration) *BoltConfig {
+	c.TTL = &ttl
+
+	return c
+}
@@ -0,0 +1,390 @@
+package bolt_event_store
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/honestbank/event-driver/event"
+)
+
+const expiryHeaderSize = 8 // the expiry time in unix nanoseconds, 0 if the content never expires
+
+// BoltEventStore persists the contents in an embedded bbolt database, which survives restarts without any remote calls.
+// Each key is a nested bucket under the configured top-level bucket, holding the contents by source.
+type BoltEventStore struct {
+	cfg  *BoltConfig
+	lock sync.RWMutex // guards db, which is swapped during compaction
+	db   *bolt.DB
+}
+
+func New(cfg *BoltConfig) (*BoltEventStore, error) {
+	if cfg == nil {
+		return nil, errors.New("bolt config cannot be null")
+	}
+	db, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltEventStore{
+		cfg: cfg,
+		db:  db,
+	}, nil
+}
+
+func (b *BoltEventStore) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.db.Close()
+}
+
+// Delete removes the content of the key-source pair.
+func (b *BoltEventStore) Delete(_ context.Context, key, source string) error {
+	return b.update(func(bucket *bolt.Bucket) error {
+		keyBucket := bucket.Bucket([]byte(key))
+		if keyBucket == nil {
+			return nil
+		}
+		if err := keyBucket.Delete([]byte(source)); err != nil {
+			return err
+		}
+		if isEmpty(keyBucket) {
+			return bucket.DeleteBucket([]byte(key))
+		}
+
+		return nil
+	})
+}
+
+// DeleteByKey removes the contents of all sources under the key.
+func (b *BoltEventStore) DeleteByKey(_ context.Context, key string) error {
+	return b.update(func(bucket *bolt.Bucket) error {
+		err := bucket.DeleteBucket([]byte(key))
+		if errors.Is(err, bolt.ErrBucketNotFound) {
+			return nil
+		}
+
+		return err
+	})
+}
+
+func (b *BoltEventStore) ListSourcesByKey(_ context.Context, key string) ([]string, error) {
+	sources := make([]string, 0)
+	now := time.Now()
+	err := b.view(func(bucket *bolt.Bucket) error {
+		keyBucket := bucket.Bucket([]byte(key))
+		if keyBucket == nil {
+			return nil
+		}
+
+		return keyBucket.ForEach(func(source, record []byte) error {
+			if !isExpired(record, now) {
+				sources = append(sources, string(source))
+			}
+
+			return nil
+		})
+	})
+
+	return sources, err
+}
+
+func (b *BoltEventStore) LookUp(_ context.Context, key, source string) (*event.Message, error) {
+	var record []byte
+	err := b.view(func(bucket *bolt.Bucket) error {
+		keyBucket := bucket.Bucket([]byte(key))
+		if keyBucket == nil {
+			return nil
+		}
+		// the value is only valid within the transaction
+		if value := keyBucket.Get([]byte(source)); value != nil {
+			record = append([]byte{}, value...)
+		}
+
+		return nil
+	})
+	if err != nil || record == nil || isExpired(record, time.Now()) {
+		return nil, err
+	}
+	content, err := b.decode(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewMessage(key, source, string(content)), nil
+}
+
+func (b *BoltEventStore) LookUpByKey(_ context.Context, key string) ([]*event.Message, error) {
+	messages := make([]*event.Message, 0)
+	now := time.Now()
+	err := b.view(func(bucket *bolt.Bucket) error {
+		keyBucket := bucket.Bucket([]byte(key))
+		if keyBucket == nil {
+			return nil
+		}
+		var err error
+		messages, err = b.toMessages(key, keyBucket, now)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (b *BoltEventStore) Persist(ctx context.Context, key, source, content string) error {
+	return b.persist(ctx, key, source, content, b.cfg.TTL)
+}
+
+// PersistWithTTL persists the content that expires after the given TTL, regardless of the TTL in BoltConfig.
+func (b *BoltEventStore) PersistWithTTL(ctx context.Context, key, source, content string, ttl time.Duration) error {
+	return b.persist(ctx, key, source, content, &ttl)
+}
+
+func (b *BoltEventStore) persist(_ context.Context, key, source, content string, ttl *time.Duration) error {
+	record, err := b.encode([]byte(content), ttl)
+	if err != nil {
+		return err
+	}
+
+	return b.update(func(bucket *bolt.Bucket) error {
+		keyBucket, err := bucket.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+
+		return keyBucket.Put([]byte(source), record)
+	})
+}
+
+// PersistAndLookUpByKey persists the content and looks up the messages of the key in the same transaction.
+func (b *BoltEventStore) PersistAndLookUpByKey(_ context.Context, key, source, content string) ([]*event.Message, error) {
+	record, err := b.encode([]byte(content), b.cfg.TTL)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*event.Message, 0)
+	now := time.Now()
+	err = b.update(func(bucket *bolt.Bucket) error {
+		keyBucket, err := bucket.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		if err = keyBucket.Put([]byte(source), record); err != nil {
+			return err
+		}
+		messages, err = b.toMessages(key, keyBucket, now)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// PurgeExpired removes the expired contents, and the keys that have no content left.
+// It returns the number of contents removed.
+func (b *BoltEventStore) PurgeExpired() (int, error) {
+	purged := 0
+	now := time.Now()
+	err := b.update(func(bucket *bolt.Bucket) error {
+		emptyKeys := make([][]byte, 0)
+		err := bucket.ForEachBucket(func(key []byte) error {
+			keyBucket := bucket.Bucket(key)
+			// collect before deleting, since deleting while iterating with a cursor may skip items
+			expiredSources := make([][]byte, 0)
+			err := keyBucket.ForEach(func(source, record []byte) error {
+				if isExpired(record, now) {
+					expiredSources = append(expiredSources, source)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, source := range expiredSources {
+				if err = keyBucket.Delete(source); err != nil {
+					return err
+				}
+				purged++
+			}
+			if isEmpty(keyBucket) {
+				emptyKeys = append(emptyKeys, key)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range emptyKeys {
+			if err = bucket.DeleteBucket(key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return purged, err
+}
+
+// Compact purges the expired contents, then rewrites the database into a new file to release the freed pages,
+// since a bbolt file never shrinks by itself. All operations are blocked while the file is being rewritten.
+func (b *BoltEventStore) Compact() error {
+	if _, err := b.PurgeExpired(); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	compactedPath := b.cfg.Path + ".compacting"
+	compacted, err := bolt.Open(compactedPath, 0o600, &bolt.Options{Timeout: b.cfg.OpenTimeout})
+	if err != nil {
+		return err
+	}
+	if err = bolt.Compact(compacted, b.db, 0); err != nil {
+		_ = compacted.Close()
+		_ = os.Remove(compactedPath)
+
+		return err
+	}
+	if err = compacted.Close(); err != nil {
+		_ = os.Remove(compactedPath)
+
+		return err
+	}
+	if err = b.db.Close(); err != nil {
+		_ = os.Remove(compactedPath)
+
+		return err
+	}
+	if err = os.Rename(compactedPath, b.cfg.Path); err != nil {
+		_ = os.Remove(compactedPath)
+	}
+	// reopen the database even if renaming failed, so the store keeps working on the original file
+	db, openErr := open(b.cfg)
+	if openErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to reopen database after compaction: %w", openErr))
+	}
+	b.db = db
+
+	return err
+}
+
+// RunCompaction compacts the database every interval until the context is done or compaction fails.
+// It blocks, so usually it's run in its own goroutine.
+func (b *BoltEventStore) RunCompaction(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := b.Compact(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (b *BoltEventStore) view(fn func(bucket *bolt.Bucket) error) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(b.cfg.Bucket))
+		if bucket == nil {
+			return nil
+		}
+
+		return fn(bucket)
+	})
+}
+
+func (b *BoltEventStore) update(fn func(bucket *bolt.Bucket) error) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(b.cfg.Bucket))
+		if err != nil {
+			return err
+		}
+
+		return fn(bucket)
+	})
+}
+
+// toMessages decodes the unexpired records of the key bucket, which is only valid within the transaction.
+func (b *BoltEventStore) toMessages(key string, keyBucket *bolt.Bucket, now time.Time) ([]*event.Message, error) {
+	messages := make([]*event.Message, 0)
+	err := keyBucket.ForEach(func(source, record []byte) error {
+		if isExpired(record, now) {
+			return nil
+		}
+		content, err := b.decode(record)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, event.NewMessage(key, string(source), string(content)))
+
+		return nil
+	})
+
+	return messages, err
+}
+
+func (b *BoltEventStore) encode(content []byte, ttl *time.Duration) ([]byte, error) {
+	compressedContent, err := b.cfg.Compressor.Compress(content)
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, expiryHeaderSize, expiryHeaderSize+len(compressedContent))
+	if ttl != nil {
+		binary.BigEndian.PutUint64(record, uint64(time.Now().Add(*ttl).UnixNano()))
+	}
+
+	return append(record, compressedContent...), nil
+}
+
+func (b *BoltEventStore) decode(record []byte) ([]byte, error) {
+	if len(record) < expiryHeaderSize {
+		return nil, fmt.Errorf("record of size %d is too short", len(record))
+	}
+
+	return b.cfg.Compressor.Decompress(record[expiryHeaderSize:])
+}
+
+func isExpired(record []byte, now time.Time) bool {
+	if len(record) < expiryHeaderSize {
+		return false
+	}
+	expiresAt := binary.BigEndian.Uint64(record)
+
+	return expiresAt != 0 && now.UnixNano() >= int64(expiresAt)
+}
+
+func isEmpty(bucket *bolt.Bucket) bool {
+	key, _ := bucket.Cursor().First()
+
+	return key == nil
+}
+
+func open(cfg *BoltConfig) (*bolt.DB, error) {
+	return bolt.Open(cfg.Path, 0o600, &bolt.Options{Timeout: cfg.OpenTimeout})
+}
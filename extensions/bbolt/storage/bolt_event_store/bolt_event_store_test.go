@@ -0,0 +1,217 @@
+package bolt_event_store_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/extensions/bbolt/storage/bolt_event_store"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+const (
+	key     = "key"
+	source1 = "source1"
+	source2 = "source2"
+	content = "content"
+)
+
+func TestBoltEventStore(t *testing.T) {
+	t.Run("query empty database", func(t *testing.T) {
+		eventStore := newEventStore(t, bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db")))
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, messageArray)
+	})
+
+	t.Run("persist and look up", func(t *testing.T) {
+		eventStore := newEventStore(t, bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db")).
+			WithBucket("my-bucket").
+			WithCompressor(compression.Gzip(gzip.BestSpeed)))
+
+		err := eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+	})
+
+	t.Run("persist and look up by key", func(t *testing.T) {
+		eventStore := newEventStore(t, bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db")))
+		var atomicEventStore storage.AtomicEventStore = eventStore
+
+		messageArray, err := atomicEventStore.PersistAndLookUpByKey(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage(key, source1, content)}, messageArray)
+		err = eventStore.PersistWithTTL(context.TODO(), key, "expired", content, -time.Second)
+		assert.NoError(t, err)
+		messageArray, err = atomicEventStore.PersistAndLookUpByKey(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+	})
+
+	t.Run("survive restart", func(t *testing.T) {
+		config := bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db"))
+		eventStore, err := bolt_event_store.New(config)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		assert.NoError(t, eventStore.Close())
+
+		eventStore = newEventStore(t, config)
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		eventStore := newEventStore(t, bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db")))
+
+		err := eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		sources, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		// deleting what doesn't exist is not an error
+		assert.NoError(t, eventStore.Delete(context.TODO(), key, source1))
+		assert.NoError(t, eventStore.DeleteByKey(context.TODO(), key))
+	})
+
+	t.Run("with TTL", func(t *testing.T) {
+		eventStore := newEventStore(t, bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db")).
+			WithTTL(10*time.Millisecond))
+		var expiringEventStore storage.ExpiringEventStore = eventStore
+
+		err := expiringEventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = expiringEventStore.PersistWithTTL(context.TODO(), key, source2, content, time.Hour)
+		assert.NoError(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{event.NewMessage(key, source2, content)}, messageArray)
+
+		purged, err := eventStore.PurgeExpired()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		purged, err = eventStore.PurgeExpired()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, purged)
+	})
+
+	t.Run("compact", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events.db")
+		eventStore := newEventStore(t, bolt_event_store.Config(path).WithTTL(10*time.Millisecond))
+
+		largeContent := string(make([]byte, 64*1024))
+		for i := 0; i < 100; i++ {
+			err := eventStore.Persist(context.TODO(), fmt.Sprintf("key%d", i), source1, largeContent)
+			assert.NoError(t, err)
+		}
+		err := eventStore.PersistWithTTL(context.TODO(), key, source1, content, time.Hour)
+		assert.NoError(t, err)
+		sizeBefore := fileSize(t, path)
+
+		time.Sleep(20 * time.Millisecond)
+		err = eventStore.Compact()
+		assert.NoError(t, err)
+		assert.Less(t, fileSize(t, path), sizeBefore)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+	})
+
+	t.Run("run compaction until cancelled", func(t *testing.T) {
+		eventStore := newEventStore(t, bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db")))
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+		defer cancel()
+		err := eventStore.RunCompaction(ctx, 10*time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		_, err := bolt_event_store.New(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("database locked by another store", func(t *testing.T) {
+		config := bolt_event_store.Config(filepath.Join(t.TempDir(), "events.db")).WithOpenTimeout(10 * time.Millisecond)
+		newEventStore(t, config)
+		_, err := bolt_event_store.New(config)
+		assert.Error(t, err)
+	})
+}
+
+func newEventStore(t *testing.T, config *bolt_event_store.BoltConfig) *bolt_event_store.BoltEventStore {
+	eventStore, err := bolt_event_store.New(config)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = eventStore.Close()
+	})
+
+	return eventStore
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	return info.Size()
+}
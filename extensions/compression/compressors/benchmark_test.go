@@ -0,0 +1,145 @@
+package compressors_test
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/honestbank/event-driver/extensions/compression/compressors"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type benchmarkedCompressor struct {
+	name       string
+	compressor compression.Compressor
+}
+
+// benchmarkedCompressors is a function rather than a variable, so that the tests don't train the dictionary.
+func benchmarkedCompressors() []benchmarkedCompressor {
+	return []benchmarkedCompressor{
+		{"gzip-speed", compression.Gzip(gzip.BestSpeed)},
+		{"gzip-default", compression.Gzip(gzip.DefaultCompression)},
+		{"deflate-default", compression.Deflate(flate.DefaultCompression)},
+		{"zstd-1", compressors.Zstd(1)},
+		{"zstd-3", compressors.Zstd(3)},
+		{"zstd-9", compressors.Zstd(9)},
+		{"zstd-3-dictionary", zstdWithTrainedDictionary(3)},
+		{"snappy", compressors.Snappy()},
+		{"lz4-fast", compressors.LZ4(lz4.Fast)},
+		{"lz4-9", compressors.LZ4(lz4.Level9)},
+	}
+}
+
+// BenchmarkCompress reports the throughput and the compression ratio (original size / compressed size)
+// of each compressor on JSON events of typical sizes, e.g.
+//
+//	go test -run=^$ -bench=. ./compressors/
+func BenchmarkCompress(b *testing.B) {
+	for _, payload := range payloads(b) {
+		for _, benchmarked := range benchmarkedCompressors() {
+			b.Run(fmt.Sprintf("%s/%s", payload.name, benchmarked.name), func(b *testing.B) {
+				b.SetBytes(int64(len(payload.content)))
+				b.ReportAllocs()
+				var compressed []byte
+				var err error
+				for i := 0; i < b.N; i++ {
+					compressed, err = benchmarked.compressor.Compress(payload.content)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(len(payload.content))/float64(len(compressed)), "ratio")
+			})
+		}
+	}
+}
+
+func BenchmarkDecompress(b *testing.B) {
+	for _, payload := range payloads(b) {
+		for _, benchmarked := range benchmarkedCompressors() {
+			compressed, err := benchmarked.compressor.Compress(payload.content)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Run(fmt.Sprintf("%s/%s", payload.name, benchmarked.name), func(b *testing.B) {
+				b.SetBytes(int64(len(payload.content)))
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := benchmarked.compressor.Decompress(compressed); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func zstdWithTrainedDictionary(level int) compression.Compressor {
+	dictionary, err := compressors.TrainZstdDictionary(samples(50), 1, compressors.DefaultDictionarySize)
+	if err != nil {
+		panic(err)
+	}
+	compressor, err := compressors.ZstdWithDictionary(level, dictionary)
+	if err != nil {
+		panic(err)
+	}
+
+	return compressor
+}
+
+type payload struct {
+	name    string
+	content []byte
+}
+
+// payloads returns a single event (~0.3KB), and a batch of 100 events (~30KB) as JSON.
+func payloads(b *testing.B) []payload {
+	events := jsonEvents(0, 100)
+	single, err := json.Marshal(events[0])
+	if err != nil {
+		b.Fatal(err)
+	}
+	batch, err := json.Marshal(events)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return []payload{{"single-event", single}, {"batch-of-100", batch}}
+}
+
+// samples returns JSON events other than the ones of the payloads, to train dictionaries with.
+func samples(count int) [][]byte {
+	samples := make([][]byte, 0, count)
+	for _, event := range jsonEvents(1000, count) {
+		sample, _ := json.Marshal(event)
+		samples = append(samples, sample)
+	}
+
+	return samples
+}
+
+// jsonEvents returns count distinct events from the offset, that look like the events of a real topic.
+func jsonEvents(offset, count int) []map[string]any {
+	events := make([]map[string]any, 0, count)
+	for i := offset; i < offset+count; i++ {
+		events = append(events, map[string]any{
+			"id":          fmt.Sprintf("9f1c%04d-2a7e-4c1b-9d3e-8b6f0a5c%04d", i, i*7),
+			"type":        "transaction.settled",
+			"occurred_at": fmt.Sprintf("2024-05-%02dT%02d:%02d:%02dZ", i%28+1, i%24, i%60, (i*13)%60),
+			"account_id":  fmt.Sprintf("ACC-%08d", 10000000+i*7919),
+			"amount":      map[string]any{"value": fmt.Sprintf("%d.%02d", i*137%10000, i%100), "currency": "IDR"},
+			"status":      []string{"PENDING", "SETTLED", "REVERSED"}[i%3],
+			"metadata": map[string]any{
+				"channel":     []string{"mobile", "web", "atm"}[i%3],
+				"merchant":    fmt.Sprintf("merchant-%d", i%17),
+				"description": "Card payment at merchant",
+			},
+		})
+	}
+
+	return events
+}
@@ -0,0 +1,29 @@
+package compressors
+
+import (
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+// ZstdCodec returns the Codec of Zstd, which is recognized by the magic number of zstd frames.
+func ZstdCodec(level int) compression.Codec {
+	return compression.Codec{
+		Magic:      []byte{0x28, 0xb5, 0x2f, 0xfd},
+		Compressor: Zstd(level),
+	}
+}
+
+// LZ4Codec returns the Codec of LZ4, which is recognized by the magic number of LZ4 frames.
+func LZ4Codec(level lz4.CompressionLevel) compression.Codec {
+	return compression.Codec{
+		Magic:      []byte{0x04, 0x22, 0x4d, 0x18},
+		Compressor: LZ4(level),
+	}
+}
+
+// SnappyCodec returns the Codec of Snappy. Snappy blocks have no magic number, so the contents are framed
+// with the ID 's'.
+func SnappyCodec() compression.Codec {
+	return compression.Framed('s', Snappy())
+}
@@ -0,0 +1,200 @@
+package compressors_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/extensions/compression/compressors"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+func TestCompressors(t *testing.T) {
+	input := []byte(`{"key":"order-1","source":"payment","content":"test string test string test string"}`)
+
+	for name, compressor := range map[string]compression.Compressor{
+		"zstd fastest": compressors.Zstd(1),
+		"zstd default": compressors.Zstd(3),
+		"zstd best":    compressors.Zstd(22),
+		"snappy":       compressors.Snappy(),
+		"lz4 fast":     compressors.LZ4(lz4.Fast),
+		"lz4 level 9":  compressors.LZ4(lz4.Level9),
+	} {
+		t.Run(name, func(t *testing.T) {
+			compressed, err := compressor.Compress(input)
+			assert.NoError(t, err)
+			assert.NotEqual(t, input, compressed)
+
+			roundTripped, err := compressor.Decompress(compressed)
+			assert.NoError(t, err)
+			assert.Equal(t, input, roundTripped)
+
+			compressed, err = compressor.Compress([]byte{})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{}, compressed)
+			decompressed, err := compressor.Decompress([]byte{})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte{}, decompressed)
+
+			_, err = compressor.Decompress([]byte("corrupted input"))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("invalid lz4 level", func(t *testing.T) {
+		_, err := compressors.LZ4(lz4.CompressionLevel(3)).Compress(input)
+		assert.Error(t, err)
+	})
+}
+
+func TestCodecs(t *testing.T) {
+	input := []byte(`{"key":"order-1","source":"payment","content":"test string test string test string"}`)
+	codecs := map[string]compression.Codec{
+		"zstd":   compressors.ZstdCodec(3),
+		"snappy": compressors.SnappyCodec(),
+		"lz4":    compressors.LZ4Codec(lz4.Fast),
+	}
+	compressed := make(map[string][]byte)
+	for name, codec := range codecs {
+		var err error
+		compressed[name], err = codec.Compressor.Compress(input)
+		assert.NoError(t, err)
+		assert.Equal(t, codec.Magic, compressed[name][:len(codec.Magic)])
+	}
+
+	compressor := compression.AutoDetect(codecs["zstd"], codecs["snappy"], codecs["lz4"])
+	for name, content := range compressed {
+		t.Run("detect "+name, func(t *testing.T) {
+			decompressed, err := compressor.Decompress(content)
+			assert.NoError(t, err)
+			assert.Equal(t, input, decompressed)
+		})
+	}
+	decompressed, err := compressor.Decompress(input)
+	assert.NoError(t, err)
+	assert.Equal(t, input, decompressed)
+	_, err = compression.AutoDetect(compression.NoopCodec()).Decompress(compressed["zstd"])
+	assert.ErrorIs(t, err, compression.ErrUnsupportedCodec)
+}
+
+func TestStreams(t *testing.T) {
+	input := bytes.Repeat([]byte(`{"key":"order-1","source":"payment","content":"test string"}`), 1000)
+
+	for name, compressor := range map[string]compression.Compressor{
+		"zstd":   compressors.Zstd(3),
+		"lz4":    compressors.LZ4(lz4.Fast),
+		"snappy": compressors.SnappyCodec().Compressor,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			writer := compression.NewWriter(compressor, &buffer)
+			_, err := writer.Write(input)
+			assert.NoError(t, err)
+			assert.NoError(t, writer.Close())
+			decompressed, err := compressor.Decompress(buffer.Bytes())
+			assert.NoError(t, err)
+			assert.Equal(t, input, decompressed)
+
+			compressed, err := compressor.Compress(input)
+			assert.NoError(t, err)
+			reader, err := compression.NewReader(compressor, bytes.NewReader(compressed))
+			assert.NoError(t, err)
+			decompressed, err = io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.NoError(t, reader.Close())
+			assert.Equal(t, input, decompressed)
+		})
+	}
+}
+
+func TestSample(t *testing.T) {
+	ctx := context.TODO()
+	eventStore := storage.NewInMemoryStore()
+	assert.NoError(t, eventStore.Persist(ctx, "key1", "source1", "content1"))
+	assert.NoError(t, eventStore.Persist(ctx, "key1", "source2", "content2"))
+	assert.NoError(t, eventStore.Persist(ctx, "key2", "source1", "content1"))
+	assert.NoError(t, eventStore.Persist(ctx, "key2", "source2", ""))
+	assert.NoError(t, eventStore.Persist(ctx, "key3", "source1", "content3"))
+
+	samples, err := compressors.Sample(ctx, eventStore, []string{"key1", "key2", "key3", "unknown"}, 10)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, [][]byte{[]byte("content1"), []byte("content2"), []byte("content3")}, samples)
+
+	samples, err = compressors.Sample(ctx, eventStore, []string{"key2", "key3"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("content1")}, samples)
+}
+
+func TestZstdWithDictionary(t *testing.T) {
+	input := samples(1)[0]
+	dictionary1, err := compressors.TrainZstdDictionary(samples(50), 1, compressors.DefaultDictionarySize)
+	assert.NoError(t, err)
+	dictionary2, err := compressors.TrainZstdDictionary(samples(40), 2, compressors.DefaultDictionarySize)
+	assert.NoError(t, err)
+	id, err := compressors.DictionaryID(dictionary2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), id)
+
+	compressor, err := compressors.ZstdWithDictionary(3, dictionary1)
+	assert.NoError(t, err)
+	compressed, err := compressor.Compress(input)
+	assert.NoError(t, err)
+	withoutDictionary, err := compressors.Zstd(3).Compress(input)
+	assert.NoError(t, err)
+	assert.Less(t, len(compressed)*2, len(withoutDictionary))
+	decompressed, err := compressor.Decompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, input, decompressed)
+	_, err = compressors.Zstd(3).Decompress(compressed)
+	assert.Error(t, err)
+
+	t.Run("rotate dictionaries", func(t *testing.T) {
+		rotatedCompressor, err := compressors.ZstdWithDictionary(3, dictionary2, dictionary1)
+		assert.NoError(t, err)
+		for _, content := range [][]byte{compressed, withoutDictionary} {
+			decompressed, err := rotatedCompressor.Decompress(content)
+			assert.NoError(t, err)
+			assert.Equal(t, input, decompressed)
+		}
+
+		onlyDictionary2, err := compressors.ZstdWithDictionary(3, dictionary2)
+		assert.NoError(t, err)
+		_, err = onlyDictionary2.Decompress(compressed)
+		assert.Error(t, err)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var buffer bytes.Buffer
+		writer := compression.NewWriter(compressor, &buffer)
+		_, err := writer.Write(input)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		reader, err := compression.NewReader(compressor, &buffer)
+		assert.NoError(t, err)
+		decompressed, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, input, decompressed)
+	})
+
+	t.Run("auto detect", func(t *testing.T) {
+		codec, err := compressors.ZstdDictionaryCodec(3, dictionary1)
+		assert.NoError(t, err)
+		decompressed, err := compression.AutoDetect(codec).Decompress(withoutDictionary)
+		assert.NoError(t, err)
+		assert.Equal(t, input, decompressed)
+	})
+
+	t.Run("invalid dictionaries", func(t *testing.T) {
+		_, err := compressors.TrainZstdDictionary(nil, 1, compressors.DefaultDictionarySize)
+		assert.Error(t, err)
+		_, err = compressors.ZstdWithDictionary(3, []byte("not a dictionary"))
+		assert.Error(t, err)
+		_, err = compressors.ZstdWithDictionary(3, dictionary1, []byte("not a dictionary"))
+		assert.Error(t, err)
+	})
+}
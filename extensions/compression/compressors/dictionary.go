@@ -0,0 +1,107 @@
+package compressors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+// DefaultDictionarySize is the size of the dictionaries trained by TrainZstdDictionary by default,
+// which is plenty for events of a few KB.
+const DefaultDictionarySize = 16 << 10
+
+// Sample returns up to maxSamples distinct contents stored under the keys, in the order of the keys, as samples
+// to train a compression dictionary with. The samples should be representative of the contents to compress,
+// e.g. the keys of the last few days, covering every source.
+func Sample(ctx context.Context, eventStore storage.EventStore, keys []string, maxSamples int) ([][]byte, error) {
+	samples := make([][]byte, 0)
+	isSampled := make(map[string]bool)
+	for _, key := range keys {
+		messages, err := eventStore.LookUpByKey(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			content := message.GetContent()
+			if content == "" || isSampled[content] {
+				continue
+			}
+			if len(samples) >= maxSamples {
+				return samples, nil
+			}
+			isSampled[content] = true
+			samples = append(samples, []byte(content))
+		}
+	}
+
+	return samples, nil
+}
+
+// TrainZstdDictionary trains a zstd dictionary of at most maxSize bytes from the samples,
+// e.g. the contents collected by Sample. A few hundred distinct samples are usually enough,
+// and only their first 64KB matter. The ID is written in the header of every frame compressed with the dictionary,
+// so it must be unique among the dictionaries ever used by a store; zero picks a random ID.
+func TrainZstdDictionary(samples [][]byte, id uint32, maxSize int) ([]byte, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no samples to train the dictionary from")
+	}
+
+	return dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: maxSize,
+		HashBytes:   6,
+		ZstdDictID:  id,
+	})
+}
+
+// DictionaryID returns the ID of a zstd dictionary.
+func DictionaryID(dictionary []byte) (uint32, error) {
+	inspected, err := zstd.InspectDictionary(dictionary)
+	if err != nil {
+		return 0, err
+	}
+
+	return inspected.ID(), nil
+}
+
+// ZstdWithDictionary returns a Compressor of zstd frames compressed with the dictionary, which compresses small
+// contents like single JSON events much better than without. The frames record the ID of their dictionary,
+// so the contents compressed with the previous dictionaries, or without any, are decompressed with the right one.
+// Dictionaries can then be retrained and rotated without rewriting the contents, as long as the previous
+// dictionaries are kept.
+func ZstdWithDictionary(level int, dictionary []byte, previousDictionaries ...[]byte) (compression.Compressor, error) {
+	dictionaries := append([][]byte{dictionary}, previousDictionaries...)
+	for _, dictionary := range dictionaries {
+		if _, err := DictionaryID(dictionary); err != nil {
+			return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+		}
+	}
+
+	compressor, err := newZstd(level,
+		[]zstd.EOption{zstd.WithEncoderDict(dictionary)},
+		[]zstd.DOption{zstd.WithDecoderDicts(dictionaries...)})
+	if err != nil {
+		return nil, err
+	}
+
+	return compressor, nil
+}
+
+// ZstdDictionaryCodec returns the Codec of ZstdWithDictionary, which is recognized by the magic number of zstd frames
+// like ZstdCodec, so it also decompresses the contents of ZstdCodec.
+func ZstdDictionaryCodec(level int, dictionary []byte, previousDictionaries ...[]byte) (compression.Codec, error) {
+	compressor, err := ZstdWithDictionary(level, dictionary, previousDictionaries...)
+	if err != nil {
+		return compression.Codec{}, err
+	}
+
+	return compression.Codec{
+		Magic:      ZstdCodec(level).Magic,
+		Compressor: compressor,
+	}, nil
+}
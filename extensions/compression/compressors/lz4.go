@@ -0,0 +1,66 @@
+package compressors
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type lz4Compressor struct {
+	level lz4.CompressionLevel
+}
+
+// LZ4 returns a Compressor of LZ4 frames, which decompresses the fastest among the compressors,
+// e.g. LZ4(lz4.Fast) for the lowest CPU cost, or LZ4(lz4.Level9) for the best ratio.
+func LZ4(level lz4.CompressionLevel) compression.Compressor {
+	return lz4Compressor{
+		level: level,
+	}
+}
+
+func (l lz4Compressor) Compress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+	var buffer bytes.Buffer
+	lz4Writer, err := l.NewWriter(&buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = lz4Writer.Write(input)
+	if err != nil {
+		return nil, err
+	}
+	err = lz4Writer.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (l lz4Compressor) Decompress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+
+	return io.ReadAll(lz4.NewReader(bytes.NewReader(input)))
+}
+
+func (l lz4Compressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	lz4Writer := lz4.NewWriter(w)
+	err := lz4Writer.Apply(lz4.CompressionLevelOption(l.level), lz4.ConcurrencyOption(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return lz4Writer, nil
+}
+
+func (l lz4Compressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(lz4.NewReader(r)), nil
+}
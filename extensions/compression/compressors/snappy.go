@@ -0,0 +1,30 @@
+package compressors
+
+import (
+	"github.com/klauspost/compress/snappy"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type snappyCompressor struct{}
+
+// Snappy returns a Compressor of snappy blocks, which trades compression ratio for very low CPU cost.
+func Snappy() compression.Compressor {
+	return snappyCompressor{}
+}
+
+func (s snappyCompressor) Compress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+
+	return snappy.Encode(nil, input), nil
+}
+
+func (s snappyCompressor) Decompress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+
+	return snappy.Decode(nil, input)
+}
@@ -0,0 +1,77 @@
+package compressors
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type zstdCompressor struct {
+	encoderOptions []zstd.EOption
+	decoderOptions []zstd.DOption
+	encoder        *zstd.Encoder
+	decoder        *zstd.Decoder
+}
+
+// Zstd returns a Compressor of zstd frames, which compresses better than gzip at a similar or lower CPU cost.
+// The level is one of the standard zstd levels from 1 (fastest) to 22 (best compression), where 3 is the default
+// of zstd, and levels are mapped to the closest level implemented by github.com/klauspost/compress/zstd.
+func Zstd(level int) compression.Compressor {
+	// the options are always valid, so creating the encoder & decoder can't fail
+	compressor, _ := newZstd(level, nil, nil)
+
+	return compressor
+}
+
+func newZstd(level int, encoderOptions []zstd.EOption, decoderOptions []zstd.DOption) (zstdCompressor, error) {
+	encoderOptions = append([]zstd.EOption{
+		zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+		zstd.WithEncoderConcurrency(1),
+	}, encoderOptions...)
+	encoder, err := zstd.NewWriter(nil, encoderOptions...)
+	if err != nil {
+		return zstdCompressor{}, err
+	}
+	decoder, err := zstd.NewReader(nil, append([]zstd.DOption{zstd.WithDecoderConcurrency(0)}, decoderOptions...)...)
+	if err != nil {
+		return zstdCompressor{}, err
+	}
+
+	return zstdCompressor{
+		encoderOptions: encoderOptions,
+		decoderOptions: decoderOptions,
+		encoder:        encoder,
+		decoder:        decoder,
+	}, nil
+}
+
+func (z zstdCompressor) Compress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+
+	return z.encoder.EncodeAll(input, nil), nil
+}
+
+func (z zstdCompressor) Decompress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+
+	return z.decoder.DecodeAll(input, nil)
+}
+
+func (z zstdCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, z.encoderOptions...)
+}
+
+func (z zstdCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r, append([]zstd.DOption{zstd.WithDecoderConcurrency(1)}, z.decoderOptions...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.IOReadCloser(), nil
+}
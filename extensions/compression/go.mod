@@ -0,0 +1,19 @@
+module github.com/honestbank/event-driver/extensions/compression
+
+go 1.21
+
+replace github.com/honestbank/event-driver => ../../../event-driver
+
+require (
+	github.com/honestbank/event-driver v1.0.0
+	github.com/klauspost/compress v1.17.9
+	github.com/pierrec/lz4/v4 v4.1.21
+	github.com/stretchr/testify v1.9.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
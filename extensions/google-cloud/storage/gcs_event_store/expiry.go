@@ -0,0 +1,19 @@
+package gcs_event_store
+
+import (
+	gcs "cloud.google.com/go/storage"
+)
+
+// ExpiryLifecycleRule returns the bucket lifecycle rule that deletes the objects whose TTL has passed.
+// GCS evaluates lifecycle rules asynchronously, usually once a day,
+// so the expired objects may stay in the bucket for a while before they're actually deleted.
+//
+// Apply it to the bucket once, e.g.
+//
+//	bucket.Update(ctx, gcs.BucketAttrsToUpdate{Lifecycle: &gcs.Lifecycle{Rules: []gcs.LifecycleRule{ExpiryLifecycleRule()}}})
+func ExpiryLifecycleRule() gcs.LifecycleRule {
+	return gcs.LifecycleRule{
+		Action:    gcs.LifecycleAction{Type: gcs.DeleteAction},
+		Condition: gcs.LifecycleCondition{DaysSinceCustomTime: 1},
+	}
+}
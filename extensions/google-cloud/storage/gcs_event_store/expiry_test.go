@@ -0,0 +1,16 @@
+package gcs_event_store_test
+
+import (
+	"testing"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/extensions/google-cloud/storage/gcs_event_store"
+)
+
+func TestExpiryLifecycleRule(t *testing.T) {
+	rule := gcs_event_store.ExpiryLifecycleRule()
+	assert.Equal(t, gcs.DeleteAction, rule.Action.Type)
+	assert.Equal(t, int64(1), rule.Condition.DaysSinceCustomTime)
+}
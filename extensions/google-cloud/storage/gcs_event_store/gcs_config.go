@@ -2,43 +2,41 @@ package gcs_event_store
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
+	"github.com/honestbank/event-driver/storage/object_event_store"
 	"github.com/honestbank/event-driver/utils/compression"
 )
 
-type Operation string
-
-const (
-	ListContents Operation = "ListContents" // operation that lists all content associated with a given key
-	ReadContent  Operation = "ReadContent"  // operation that reads content associated with a key-source pair
-	WriteContent Operation = "WriteContent" //operation to writes content associated with a key-source pair
-)
-
+// GCSConfig is the configuration of a GCSEventStore, which is turned into the object_event_store.ObjectConfig
+// of the GCSEventStore when it's created.
 type GCSConfig struct {
-	Bucket     string
-	Compressor compression.Compressor
-	Folder     *string
-	ReadPolicy ReadPolicy
-	Timeout    Timeout
-}
-
-type Timeout struct {
-	Default   *time.Duration              // the default timeout for all operations
-	Operation map[Operation]time.Duration // the timeout of each operation - this overrides the default timeout
+	Bucket      string
+	Compressor  compression.Compressor
+	Concurrency int // the maximum number of sources read concurrently by LookUpByKey
+	Folder      *string
+	KeyLayout   KeyLayout                                           // the paths of the objects, see KeyLayout
+	Logger      *slog.Logger                                        // logs the failures that don't fail the operations
+	Metadata    func(key, source, content string) map[string]string // sets the metadata of the written objects
+	ReadPolicy  ReadPolicy
+	Timeout     Timeout
+	TTL         *time.Duration // contents expire after TTL if configured, see ExpiryLifecycleRule
 }
 
 // Config creates a default configuration, that
 // - doesn't do compression/decompression when write & read to GCS
 // - takes the earliest created object if there are multiple under the same key/source/ path
 // - enforces universal 30s timeout in GCS requests
+// - reads up to 8 sources concurrently in LookUpByKey
 func Config(bucket string) *GCSConfig {
 	halfMinute := 30 * time.Second
 
 	return &GCSConfig{
-		Bucket:     bucket,
-		Compressor: compression.Noop(),
-		ReadPolicy: TakeFirstCreated(),
+		Bucket:      bucket,
+		Compressor:  compression.Noop(),
+		Concurrency: 8,
+		ReadPolicy:  TakeFirstCreated(),
 		Timeout: Timeout{
 			Default:   &halfMinute,
 			Operation: make(map[Operation]time.Duration),
@@ -52,18 +50,59 @@ func (c *GCSConfig) WithCompressor(compressor compression.Compressor) *GCSConfig
 	return c
 }
 
+// WithConcurrency sets the maximum number of sources read concurrently by LookUpByKey, where 1 reads them sequentially.
+func (c *GCSConfig) WithConcurrency(concurrency int) *GCSConfig {
+	c.Concurrency = concurrency
+
+	return c
+}
+
 func (c *GCSConfig) WithFolder(folder string) *GCSConfig {
 	c.Folder = &folder
 
 	return c
 }
 
+// WithKeyLayout sets how the keys & sources are mapped to the paths of the objects, e.g.
+//
+//	WithKeyLayout(KeyLayout{Encoding: EscapedKeyEncoding(), ShardPrefixSize: 2, ReadLegacyLayout: true})
+//
+// escapes the keys & sources, shards the keys over 256 prefixes, and keeps reading the objects written before.
+func (c *GCSConfig) WithKeyLayout(keyLayout KeyLayout) *GCSConfig {
+	c.KeyLayout = keyLayout
+
+	return c
+}
+
+// WithLogger sets the logger of the failures that don't fail the operations, e.g. of CollectGarbage.
+func (c *GCSConfig) WithLogger(logger *slog.Logger) *GCSConfig {
+	c.Logger = logger
+
+	return c
+}
+
+// WithMetadata sets the metadata of each written object from its key, source and content,
+// e.g. the sequence number of the event for TakeHighestSequence.
+func (c *GCSConfig) WithMetadata(metadata func(key, source, content string) map[string]string) *GCSConfig {
+	c.Metadata = metadata
+
+	return c
+}
+
 func (c *GCSConfig) WithReadPolicy(readPolicy ReadPolicy) *GCSConfig {
 	c.ReadPolicy = readPolicy
 
 	return c
 }
 
+// WithTTL makes the contents expire after the given TTL, by setting the custom time of each object to its expiry time.
+// Expired objects are skipped on read, but GCS only deletes them if the bucket has ExpiryLifecycleRule configured.
+func (c *GCSConfig) WithTTL(ttl time.Duration) *GCSConfig {
+	c.TTL = &ttl
+
+	return c
+}
+
 func (c *GCSConfig) WithTimeout(timeout Timeout) *GCSConfig {
 	c.Timeout = timeout
 
@@ -75,15 +114,20 @@ func (c *GCSConfig) WithTimeout(timeout Timeout) *GCSConfig {
 func (c *GCSConfig) NewContextWithTimeout(
 	parent context.Context,
 	operation Operation) (context.Context, context.CancelFunc) {
-	if listContentsTimeout, isConfigured := c.Timeout.Operation[operation]; isConfigured {
-		return context.WithTimeout(parent, listContentsTimeout)
-	}
-	if c.Timeout.Default != nil {
-		return context.WithTimeout(parent, *c.Timeout.Default)
-	}
-
-	return parent, noop
+	return c.objectConfig().NewContextWithTimeout(parent, operation)
 }
 
-func noop() {
+// objectConfig returns the configuration of object_event_store that the GCSConfig stands for.
+func (c *GCSConfig) objectConfig() *object_event_store.ObjectConfig {
+	return &object_event_store.ObjectConfig{
+		Compressor:  c.Compressor,
+		Concurrency: c.Concurrency,
+		Folder:      c.Folder,
+		KeyLayout:   c.KeyLayout,
+		Logger:      c.Logger,
+		Metadata:    c.Metadata,
+		ReadPolicy:  toObjectReadPolicy(c.ReadPolicy),
+		Timeout:     c.Timeout,
+		TTL:         c.TTL,
+	}
 }
@@ -16,11 +16,13 @@ func TestGCSConfig(t *testing.T) {
 	listTimeout := time.Second * 10
 	readTimeout := time.Second * 20
 	writeTimeout := time.Second * 30
+	deleteTimeout := time.Second * 40
 
 	operationToTimeout := map[gcs_event_store.Operation]time.Duration{
-		gcs_event_store.ListContents: listTimeout,
-		gcs_event_store.ReadContent:  readTimeout,
-		gcs_event_store.WriteContent: writeTimeout,
+		gcs_event_store.ListContents:  listTimeout,
+		gcs_event_store.ReadContent:   readTimeout,
+		gcs_event_store.WriteContent:  writeTimeout,
+		gcs_event_store.DeleteContent: deleteTimeout,
 	}
 	gcsConfig := gcs_event_store.Config("bucket").
 		WithTimeout(gcs_event_store.Timeout{
@@ -20,6 +20,7 @@ import (
 
 	"github.com/honestbank/event-driver/event"
 	"github.com/honestbank/event-driver/extensions/google-cloud/storage/gcs_event_store"
+	"github.com/honestbank/event-driver/storage"
 	"github.com/honestbank/event-driver/utils/compression"
 )
 
@@ -176,6 +177,183 @@ func TestGCSEventStore(t *testing.T) {
 			messageArray)
 	})
 
+	t.Run("with TTL", func(t *testing.T) {
+		bucket := "with-ttl"
+		setup(t, bucket)
+		// long enough for the streamed writes & the first look-ups, which take a few round trips to the emulator
+		config := gcs_event_store.Config(bucket).WithFolder(folderName).WithTTL(3 * time.Second)
+		eventStore, err := gcs_event_store.New(context.TODO(), config, option.WithoutAuthentication())
+		assert.NoError(t, err)
+		expiringEventStore, isExpiring := eventStore.(storage.ExpiringEventStore)
+		assert.True(t, isExpiring)
+
+		err = expiringEventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = expiringEventStore.PersistWithTTL(context.TODO(), key, source2, content, time.Hour)
+		assert.NoError(t, err)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+
+		time.Sleep(4 * time.Second)
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		messageArray, err = eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{event.NewMessage(key, source2, content)}, messageArray)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		bucket := "delete"
+		setup(t, bucket)
+		config := gcs_event_store.Config(bucket).WithFolder(folderName)
+		eventStore, err := gcs_event_store.New(context.TODO(), config, option.WithoutAuthentication())
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		sources, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		// deleting what doesn't exist is not an error
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.NoError(t, err)
+	})
+
+	t.Run("conditional writes", func(t *testing.T) {
+		bucket := "conditional-writes"
+		setup(t, bucket)
+		config := gcs_event_store.Config(bucket).WithFolder(folderName)
+		eventStore, err := gcs_event_store.New(context.TODO(), config, option.WithoutAuthentication())
+		assert.NoError(t, err)
+		conditionalEventStore, isConditional := eventStore.(storage.ConditionalEventStore)
+		assert.True(t, isConditional)
+		assert.NoError(t, eventStore.DeleteByKey(context.TODO(), key)) // the emulator keeps the objects between runs
+
+		isPersisted, err := conditionalEventStore.PersistIfAbsent(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		isPersisted, err = conditionalEventStore.PersistIfAbsent(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		message, version, err := conditionalEventStore.LookUpVersion(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(context.TODO(), key, source1, "something else", version)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(context.TODO(), key, source1, "stale", version)
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		message, err = eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "something else"), message)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		bucket := "history"
+		setup(t, bucket)
+		config := gcs_event_store.Config(bucket).WithFolder(folderName)
+		eventStore, err := gcs_event_store.New(context.TODO(), config, option.WithoutAuthentication())
+		assert.NoError(t, err)
+		historyEventStore, isHistory := eventStore.(storage.HistoryEventStore)
+		assert.True(t, isHistory)
+		assert.NoError(t, eventStore.DeleteByKey(context.TODO(), key)) // the emulator keeps the objects between runs
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+
+		revisions, err := historyEventStore.ListRevisions(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		messageArray := make([]*event.Message, 0)
+		for _, revision := range revisions {
+			message, err := historyEventStore.LookUpRevision(context.TODO(), key, source1, revision.ID)
+			assert.NoError(t, err)
+			messageArray = append(messageArray, message)
+		}
+		assert.Equal(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source1, "something else")},
+			messageArray)
+	})
+
+	t.Run("list keys", func(t *testing.T) {
+		bucket := "list-keys"
+		setup(t, bucket)
+		config := gcs_event_store.Config(bucket).WithFolder(folderName).
+			WithKeyLayout(gcs_event_store.KeyLayout{ShardPrefixSize: 1})
+		eventStore, err := gcs_event_store.New(context.TODO(), config, option.WithoutAuthentication())
+		assert.NoError(t, err)
+		keyListingEventStore, isKeyListing := eventStore.(storage.KeyListingEventStore)
+		assert.True(t, isKeyListing)
+		for _, key := range []string{"key1", "key2", "key3", "other"} {
+			assert.NoError(t, eventStore.Persist(context.TODO(), key, source1, content))
+			assert.NoError(t, eventStore.Persist(context.TODO(), key, source2, content))
+		}
+
+		keys := make([]string, 0)
+		err = storage.ScanKeys(context.TODO(), keyListingEventStore, "key", 1, func(key string) error {
+			keys = append(keys, key)
+
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"key1", "key2", "key3"}, keys)
+	})
+
+	t.Run("take highest sequence and collect garbage", func(t *testing.T) {
+		bucket := "read-policies"
+		setup(t, bucket)
+		config := gcs_event_store.Config(bucket).
+			WithFolder(folderName).
+			WithMetadata(func(_, _, content string) map[string]string {
+				return map[string]string{"sequence": content}
+			}).
+			WithReadPolicy(gcs_event_store.CollectGarbage(gcs_event_store.TakeHighestSequence("sequence")))
+		eventStore, err := gcs_event_store.New(context.TODO(), config, option.WithoutAuthentication())
+		assert.NoError(t, err)
+		assert.NoError(t, eventStore.DeleteByKey(context.TODO(), key)) // the emulator keeps the objects between runs
+
+		for _, sequence := range []string{"2", "10", "3"} {
+			err = eventStore.Persist(context.TODO(), key, source1, sequence)
+			assert.NoError(t, err)
+		}
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "10"), message)
+		revisions, err := eventStore.(storage.HistoryEventStore).ListRevisions(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 1)
+	})
+
 	t.Run("gcs error", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -206,6 +384,12 @@ func TestGCSEventStore(t *testing.T) {
 
 		_, err = eventStore.LookUpByKey(context.TODO(), key)
 		assert.Error(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.Error(t, err)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.Error(t, err)
 	})
 }
 
@@ -0,0 +1,185 @@
+package gcs_event_store
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+// bucketObjectStore implements ConditionalObjectStore and StreamingObjectStore on a GCS bucket, where the expiry time
+// of an object is its custom time, so that ExpiryLifecycleRule deletes the expired objects.
+// Renaming an object copies it within the bucket, without downloading it.
+type bucketObjectStore struct {
+	bucket *gcs.BucketHandle
+}
+
+func BucketObjectStore(bucket *gcs.BucketHandle) ConditionalObjectStore {
+	return &bucketObjectStore{
+		bucket: bucket,
+	}
+}
+
+func (b *bucketObjectStore) List(ctx context.Context, query *Query) object_event_store.ObjectIterator {
+	gcsQuery := &gcs.Query{}
+	if query != nil {
+		gcsQuery = &gcs.Query{
+			Prefix:      query.Prefix,
+			Delimiter:   query.Delimiter,
+			StartOffset: query.StartOffset,
+		}
+	}
+
+	return &bucketObjects{objects: b.bucket.Objects(ctx, gcsQuery)}
+}
+
+func (b *bucketObjectStore) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	reader, err := b.bucket.Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return reader, nil
+}
+
+func (b *bucketObjectStore) Write(ctx context.Context, attrs *ObjectAttrs, content []byte) error {
+	return write(ctx, b.bucket.Object(attrs.Name), attrs, content)
+}
+
+func (b *bucketObjectStore) WriteIf(
+	ctx context.Context,
+	attrs *ObjectAttrs,
+	content []byte,
+	conditions Conditions) error {
+	gcsConditions := gcs.Conditions{
+		DoesNotExist:    conditions.DoesNotExist,
+		GenerationMatch: conditions.GenerationMatch,
+	}
+
+	return translateError(write(ctx, b.bucket.Object(attrs.Name).If(gcsConditions), attrs, content))
+}
+
+func (b *bucketObjectStore) NewWriter(ctx context.Context, attrs *ObjectAttrs) (io.WriteCloser, error) {
+	return newWriter(ctx, b.bucket.Object(attrs.Name), attrs), nil
+}
+
+func (b *bucketObjectStore) Rename(ctx context.Context, name string, attrs *ObjectAttrs) error {
+	object := b.bucket.Object(name)
+	copier := b.bucket.Object(attrs.Name).CopierFrom(object)
+	copier.ObjectAttrs = writableAttrs(attrs)
+	copiedAttrs, err := copier.Run(ctx)
+	if err != nil {
+		return translateError(err)
+	}
+	if !copiedAttrs.CustomTime.Equal(attrs.ExpiresAt) { // not kept by all the emulators of GCS
+		update := gcs.ObjectAttrsToUpdate{CustomTime: attrs.ExpiresAt}
+		if _, err = b.bucket.Object(attrs.Name).Update(ctx, update); err != nil {
+			return translateError(err)
+		}
+	}
+	if err = object.Delete(ctx); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (b *bucketObjectStore) Delete(ctx context.Context, name string) error {
+	return translateError(b.bucket.Object(name).Delete(ctx))
+}
+
+func (b *bucketObjectStore) Attrs(ctx context.Context, name string) (*ObjectAttrs, error) {
+	attrs, err := b.bucket.Object(name).Attrs(ctx)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return toObjectAttrs(attrs), nil
+}
+
+func write(ctx context.Context, object *gcs.ObjectHandle, attrs *ObjectAttrs, content []byte) error {
+	writer := newWriter(ctx, object, attrs)
+	if _, err := writer.Write(content); err != nil {
+		_ = writer.Close()
+
+		return err
+	}
+
+	return writer.Close()
+}
+
+func newWriter(ctx context.Context, object *gcs.ObjectHandle, attrs *ObjectAttrs) *gcs.Writer {
+	writer := object.NewWriter(ctx)
+	writer.ObjectAttrs = writableAttrs(attrs)
+
+	return writer
+}
+
+// writableAttrs returns the attributes of the object written by the ObjectStore.
+func writableAttrs(attrs *ObjectAttrs) gcs.ObjectAttrs {
+	return gcs.ObjectAttrs{
+		Name:       attrs.Name,
+		CustomTime: attrs.ExpiresAt,
+		Metadata:   attrs.Metadata,
+	}
+}
+
+// bucketObjects implements object_event_store.ObjectIterator on the objects listed from a GCS bucket.
+type bucketObjects struct {
+	objects *gcs.ObjectIterator
+}
+
+func (b *bucketObjects) Next() (*ObjectAttrs, error) {
+	attrs, err := b.objects.Next()
+	if errors.Is(err, iterator.Done) {
+		return nil, object_event_store.ErrNoMoreObjects
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toObjectAttrs(attrs), nil
+}
+
+func toObjectAttrs(attrs *gcs.ObjectAttrs) *ObjectAttrs {
+	return &ObjectAttrs{
+		Name:       attrs.Name,
+		Prefix:     attrs.Prefix,
+		Size:       attrs.Size,
+		Created:    attrs.Created,
+		ExpiresAt:  attrs.CustomTime,
+		Generation: attrs.Generation,
+		Metadata:   attrs.Metadata,
+	}
+}
+
+func toGCSObjectAttrs(attrs *ObjectAttrs) *gcs.ObjectAttrs {
+	return &gcs.ObjectAttrs{
+		Name:       attrs.Name,
+		Prefix:     attrs.Prefix,
+		Size:       attrs.Size,
+		Created:    attrs.Created,
+		CustomTime: attrs.ExpiresAt,
+		Generation: attrs.Generation,
+		Metadata:   attrs.Metadata,
+	}
+}
+
+// translateError maps the errors of GCS to the errors of ObjectStore.
+func translateError(err error) error {
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return object_event_store.ErrObjectNotExist
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return ErrPreconditionFailed
+	}
+
+	return err
+}
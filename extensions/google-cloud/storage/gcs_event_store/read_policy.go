@@ -5,6 +5,8 @@ import (
 
 	gcs "cloud.google.com/go/storage"
 	"google.golang.org/api/iterator"
+
+	"github.com/honestbank/event-driver/storage/object_event_store"
 )
 
 type ObjectIterator interface {
@@ -12,64 +14,190 @@ type ObjectIterator interface {
 }
 
 // ReadPolicy defines the actions that the GCSEventStore takes when it got the object iterator via list query.
+// The read policies below are those of object_event_store, adapted to the GCS types.
 type ReadPolicy interface {
 	Apply(ObjectIterator) (*gcs.ObjectAttrs, error)
 }
 
-// takeFirstCreated take the earliest created object under the same key/source/ path
-type takeFirstCreated struct{}
+// MergingReadPolicy is a ReadPolicy that makes GCSEventStore read all the unexpired objects under the same
+// key/source/ path, and merge their contents from the earliest to the latest created into one content.
+// Apply still chooses the object that stands for the merged content, e.g. whose generation is the version
+// for conditional writes.
+type MergingReadPolicy interface {
+	ReadPolicy
+	Merge(contents [][]byte) ([]byte, error)
+}
+
+// ErrMultipleObjects is returned by FailOnMultiple if there are multiple objects under the same key/source/ path.
+var ErrMultipleObjects = object_event_store.ErrMultipleObjects
 
+// ErrGarbageCollectingMerge is returned when creating a GCSEventStore with CollectGarbage of a MergingReadPolicy.
+var ErrGarbageCollectingMerge = object_event_store.ErrGarbageCollectingMerge
+
+// TakeFirstCreated takes the earliest created object under the same key/source/ path.
 func TakeFirstCreated() ReadPolicy {
-	return takeFirstCreated{}
+	return readPolicy{objectReadPolicy: object_event_store.TakeFirstCreated()}
+}
+
+// TakeLastCreated takes the latest created object under the same key/source/ path.
+func TakeLastCreated() ReadPolicy {
+	return readPolicy{objectReadPolicy: object_event_store.TakeLastCreated()}
+}
+
+// FailOnMultiple returns a ReadPolicy for contents that are never supposed to be persisted twice,
+// which fails the look-ups with ErrMultipleObjects rather than silently choosing one of the objects.
+func FailOnMultiple() ReadPolicy {
+	return readPolicy{objectReadPolicy: object_event_store.FailOnMultiple()}
+}
+
+// TakeByMetadata returns a ReadPolicy that takes the object whose value of the metadata field is preferred
+// by isPreferred over the others. The metadata are set on write by GCSConfig.WithMetadata.
+// Objects without the field are only taken if none has it, and ties are broken by taking the last created.
+func TakeByMetadata(field string, isPreferred func(candidate, current string) bool) ReadPolicy {
+	return readPolicy{objectReadPolicy: object_event_store.TakeByMetadata(field, isPreferred)}
+}
+
+// TakeHighestSequence returns a ReadPolicy that takes the object with the highest integer value of the metadata field,
+// e.g. the sequence number of the event. Values that aren't integers rank below all integers.
+func TakeHighestSequence(field string) ReadPolicy {
+	return readPolicy{objectReadPolicy: object_event_store.TakeHighestSequence(field)}
+}
+
+// MergeConcatenated returns a MergingReadPolicy that concatenates all the contents with the separator in between.
+func MergeConcatenated(separator string) MergingReadPolicy {
+	return mergingReadPolicy{readPolicy{objectReadPolicy: object_event_store.MergeConcatenated(separator)}}
+}
+
+// MergeAsJSONArray returns a MergingReadPolicy that puts all the contents in a JSON array,
+// where a content is put as is if it's valid JSON, or as a string otherwise.
+func MergeAsJSONArray() MergingReadPolicy {
+	return mergingReadPolicy{readPolicy{objectReadPolicy: object_event_store.MergeAsJSONArray()}}
+}
+
+// CollectGarbage wraps the ReadPolicy, so that GCSEventStore deletes all the other objects under the same
+// key/source/ path after reading the chosen object, including the expired ones. The deletion is best effort,
+// failures don't fail the read, and the objects left are deleted by a later read.
+// It's not meant for MergingReadPolicy, since a merged content is made of all the objects.
+func CollectGarbage(policy ReadPolicy) ReadPolicy {
+	return readPolicy{objectReadPolicy: object_event_store.CollectGarbage(toObjectReadPolicy(policy))}
+}
+
+// readPolicy adapts a ReadPolicy of object_event_store to the GCS types.
+type readPolicy struct {
+	objectReadPolicy object_event_store.ReadPolicy
 }
 
-func (t takeFirstCreated) Apply(objectIterator ObjectIterator) (*gcs.ObjectAttrs, error) {
+func (r readPolicy) Apply(objectIterator ObjectIterator) (*gcs.ObjectAttrs, error) {
 	if objectIterator == nil {
 		return nil, errors.New("objectIterator is nil")
 	}
-
-	var result *gcs.ObjectAttrs
-	for {
-		object, err := objectIterator.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		if result == nil || object.Created.Before(result.Created) {
-			result = object
-		}
+	objects := &gcsObjects{
+		objects:       objectIterator,
+		gcsAttrsByRef: make(map[*object_event_store.ObjectAttrs]*gcs.ObjectAttrs),
+	}
+	object, err := r.objectReadPolicy.Apply(objects)
+	if err != nil || object == nil {
+		return nil, err
 	}
 
-	return result, nil
+	return objects.gcsAttrsByRef[object], nil
+}
+
+// mergingReadPolicy adapts a MergingReadPolicy of object_event_store to the GCS types.
+type mergingReadPolicy struct {
+	readPolicy
 }
 
-// takeLastCreated take the latest created object under the same key/source/ path
-type takeLastCreated struct{}
+func (m mergingReadPolicy) Merge(contents [][]byte) ([]byte, error) {
+	return m.objectReadPolicy.(object_event_store.MergingReadPolicy).Merge(contents)
+}
 
-func TakeLastCreated() ReadPolicy {
-	return takeLastCreated{}
+// customReadPolicy adapts a ReadPolicy implemented on the GCS types to object_event_store.
+type customReadPolicy struct {
+	policy ReadPolicy
 }
 
-func (t takeLastCreated) Apply(objectIterator ObjectIterator) (*gcs.ObjectAttrs, error) {
+func (c customReadPolicy) Apply(
+	objectIterator object_event_store.ObjectIterator) (*object_event_store.ObjectAttrs, error) {
 	if objectIterator == nil {
 		return nil, errors.New("objectIterator is nil")
 	}
+	objects := &listedObjects{
+		objects:       objectIterator,
+		attrsByGCSRef: make(map[*gcs.ObjectAttrs]*object_event_store.ObjectAttrs),
+	}
+	object, err := c.policy.Apply(objects)
+	if err != nil || object == nil {
+		return nil, err
+	}
+
+	return objects.attrsByGCSRef[object], nil
+}
+
+// customMergingReadPolicy adapts a MergingReadPolicy implemented on the GCS types to object_event_store.
+type customMergingReadPolicy struct {
+	customReadPolicy
+}
+
+func (c customMergingReadPolicy) Merge(contents [][]byte) ([]byte, error) {
+	return c.policy.(MergingReadPolicy).Merge(contents)
+}
+
+// toObjectReadPolicy returns the ReadPolicy of object_event_store that the ReadPolicy stands for,
+// so that the read policies of this package are applied without converting the objects back and forth.
+func toObjectReadPolicy(policy ReadPolicy) object_event_store.ReadPolicy {
+	switch policy := policy.(type) {
+	case nil:
+		return nil
+	case readPolicy:
+		return policy.objectReadPolicy
+	case mergingReadPolicy:
+		return policy.objectReadPolicy
+	case MergingReadPolicy:
+		return customMergingReadPolicy{customReadPolicy{policy: policy}}
+	default:
+		return customReadPolicy{policy: policy}
+	}
+}
+
+// gcsObjects iterates the objects of an ObjectIterator as the objects of object_event_store,
+// remembering the GCS attributes of each.
+type gcsObjects struct {
+	objects       ObjectIterator
+	gcsAttrsByRef map[*object_event_store.ObjectAttrs]*gcs.ObjectAttrs
+}
+
+func (g *gcsObjects) Next() (*object_event_store.ObjectAttrs, error) {
+	gcsAttrs, err := g.objects.Next()
+	if errors.Is(err, iterator.Done) {
+		return nil, object_event_store.ErrNoMoreObjects
+	}
+	if err != nil {
+		return nil, err
+	}
+	attrs := toObjectAttrs(gcsAttrs)
+	g.gcsAttrsByRef[attrs] = gcsAttrs
+
+	return attrs, nil
+}
 
-	var result *gcs.ObjectAttrs
-	for {
-		object, err := objectIterator.Next()
-		if errors.Is(err, iterator.Done) {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		if result == nil || object.Created.After(result.Created) {
-			result = object
-		}
+// listedObjects iterates the objects of object_event_store as an ObjectIterator,
+// remembering the object of each GCS attributes.
+type listedObjects struct {
+	objects       object_event_store.ObjectIterator
+	attrsByGCSRef map[*gcs.ObjectAttrs]*object_event_store.ObjectAttrs
+}
+
+func (l *listedObjects) Next() (*gcs.ObjectAttrs, error) {
+	attrs, err := l.objects.Next()
+	if errors.Is(err, object_event_store.ErrNoMoreObjects) {
+		return nil, iterator.Done
+	}
+	if err != nil {
+		return nil, err
 	}
+	gcsAttrs := toGCSObjectAttrs(attrs)
+	l.attrsByGCSRef[gcsAttrs] = attrs
 
-	return result, nil
+	return gcsAttrs, nil
 }
@@ -1,6 +1,7 @@
 package gcs_event_store_test
 
 import (
+	"context"
 	"errors"
 	"strconv"
 	"testing"
@@ -12,7 +13,9 @@ import (
 	"go.uber.org/mock/gomock"
 	"google.golang.org/api/iterator"
 
+	"github.com/honestbank/event-driver/event"
 	"github.com/honestbank/event-driver/extensions/google-cloud/storage/gcs_event_store"
+	"github.com/honestbank/event-driver/storage"
 )
 
 func TestTakeFirstCreatedPolicy(t *testing.T) {
@@ -95,6 +98,172 @@ func TestTakeLastCreatedPolicy(t *testing.T) {
 	})
 }
 
+func TestFailOnMultiplePolicy(t *testing.T) {
+	t.Run("take the only object", func(t *testing.T) {
+		policy := gcs_event_store.FailOnMultiple()
+		result, err := policy.Apply(newMockIterator(t, makeItems(1)))
+		assert.NoError(t, err)
+		assert.Equal(t, "0", result.Name)
+
+		result, err = policy.Apply(newMockIterator(t, makeItems(0)))
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("fail on multiple objects", func(t *testing.T) {
+		policy := gcs_event_store.FailOnMultiple()
+		_, err := policy.Apply(newMockIterator(t, makeItems(2)))
+		assert.ErrorIs(t, err, gcs_event_store.ErrMultipleObjects)
+	})
+
+	t.Run("fail if iterator is  nil", func(t *testing.T) {
+		policy := gcs_event_store.FailOnMultiple()
+		_, err := policy.Apply(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestTakeHighestSequencePolicy(t *testing.T) {
+	t.Run("take the object with the highest sequence", func(t *testing.T) {
+		items := makeItems(5)
+		for i, sequence := range []string{"3", "10", "not-a-number", "2", ""} {
+			items[i].Metadata = map[string]string{"sequence": sequence}
+		}
+
+		policy := gcs_event_store.TakeHighestSequence("sequence")
+		result, err := policy.Apply(newMockIterator(t, items))
+		assert.NoError(t, err)
+		assert.Equal(t, "1", result.Name)
+	})
+
+	t.Run("prefer objects with the field, then the last created", func(t *testing.T) {
+		items := makeItems(4)
+		items[1].Metadata = map[string]string{"sequence": "1"}
+		items[2].Metadata = map[string]string{"sequence": "1"}
+
+		policy := gcs_event_store.TakeHighestSequence("sequence")
+		result, err := policy.Apply(newMockIterator(t, items))
+		assert.NoError(t, err)
+		assert.Equal(t, "2", result.Name)
+
+		result, err = policy.Apply(newMockIterator(t, makeItems(3)))
+		assert.NoError(t, err)
+		assert.Equal(t, "2", result.Name)
+	})
+
+	t.Run("fail in iteration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockIterator := mocks.NewMockObjectIterator(ctrl)
+		mockIterator.EXPECT().Next().DoAndReturn(func() (*gcs.ObjectAttrs, error) {
+			return nil, errors.New("test")
+		})
+
+		policy := gcs_event_store.TakeByMetadata("field", func(candidate, current string) bool {
+			return candidate > current
+		})
+		_, err := policy.Apply(mockIterator)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail if iterator is  nil", func(t *testing.T) {
+		policy := gcs_event_store.TakeHighestSequence("sequence")
+		_, err := policy.Apply(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestMergingPolicies(t *testing.T) {
+	contents := [][]byte{[]byte(`{"a":1}`), []byte("plain text"), []byte("2")}
+
+	t.Run("merge concatenated", func(t *testing.T) {
+		policy := gcs_event_store.MergeConcatenated("\n")
+		merged, err := policy.Merge(contents)
+		assert.NoError(t, err)
+		assert.Equal(t, "{\"a\":1}\nplain text\n2", string(merged))
+
+		result, err := policy.Apply(newMockIterator(t, makeItems(3)))
+		assert.NoError(t, err)
+		assert.Equal(t, "2", result.Name)
+	})
+
+	t.Run("merge as JSON array", func(t *testing.T) {
+		policy := gcs_event_store.MergeAsJSONArray()
+		merged, err := policy.Merge(contents)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `[{"a":1},"plain text",2]`, string(merged))
+
+		merged, err = policy.Merge(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "[]", string(merged))
+	})
+}
+
+func TestCollectGarbagePolicy(t *testing.T) {
+	policy := gcs_event_store.CollectGarbage(gcs_event_store.TakeLastCreated())
+	result, err := policy.Apply(newMockIterator(t, makeItems(3)))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", result.Name)
+}
+
+// takeLongestName is a ReadPolicy implemented on the GCS types, like the read policies of the applications.
+type takeLongestName struct{}
+
+func (t takeLongestName) Apply(objectIterator gcs_event_store.ObjectIterator) (*gcs.ObjectAttrs, error) {
+	var result *gcs.ObjectAttrs
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, iterator.Done) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || len(object.Metadata["name"]) > len(result.Metadata["name"]) {
+			result = object
+		}
+	}
+}
+
+func TestCustomReadPolicy(t *testing.T) {
+	eventStore, err := gcs_event_store.NewWithObjectStore(gcs_event_store.Config("bucket").
+		WithMetadata(func(_, _, content string) map[string]string {
+			return map[string]string{"name": content}
+		}).
+		WithReadPolicy(gcs_event_store.CollectGarbage(takeLongestName{})),
+		gcs_event_store.NewInMemoryObjectStore())
+	assert.NoError(t, err)
+	var conditionalEventStore storage.ConditionalEventStore = eventStore
+
+	// neither the first nor the last created
+	assert.NoError(t, conditionalEventStore.Persist(context.TODO(), "key", "source", "medium"))
+	assert.NoError(t, conditionalEventStore.Persist(context.TODO(), "key", "source", "longest"))
+	assert.NoError(t, conditionalEventStore.Persist(context.TODO(), "key", "source", "short"))
+	message, version, err := conditionalEventStore.LookUpVersion(context.TODO(), "key", "source")
+	assert.NoError(t, err)
+	assert.Equal(t, event.NewMessage("key", "source", "longest"), message)
+	assert.NotEqual(t, storage.NoVersion, version)
+
+	revisions, err := eventStore.ListRevisions(context.TODO(), "key", "source")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 1)
+}
+
+func newMockIterator(t *testing.T, items []*gcs.ObjectAttrs) gcs_event_store.ObjectIterator {
+	ctrl := gomock.NewController(t)
+	mockIterator := mocks.NewMockObjectIterator(ctrl)
+	mockIterator.EXPECT().Next().DoAndReturn(func() (*gcs.ObjectAttrs, error) {
+		if len(items) == 0 {
+			return nil, iterator.Done
+		}
+		item := items[0]
+		items = items[1:]
+
+		return item, nil
+	}).MaxTimes(len(items) + 1) // policies may stop iterating early
+
+	return mockIterator
+}
+
 func makeItems(number int) []*gcs.ObjectAttrs {
 	timeBase := time.Now()
 	objects := make([]*gcs.ObjectAttrs, 0, number)
@@ -0,0 +1,118 @@
+// Command migrate-event-store copies every key & source from one event store to another, e.g.
+//
+//	migrate-event-store -from 'gcs://old-bucket/events?compressor=gzip' -to 'gcs://new-bucket/events?compressor=zstd' \
+//	    -checkpoint migration.checkpoint
+//
+// Run it with -dry-run first to check the access to the source store and the size of the migration.
+// Run it again with the same -checkpoint to resume an interrupted migration.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/honestbank/event-driver/storage/migration"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		stop()
+		log.Fatal(err)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("migrate-event-store", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	from := flags.String("from", "", "the URL of the source store, e.g. gcs://bucket/folder?compressor=gzip")
+	to := flags.String("to", "", "the URL of the destination store, e.g. fs:///var/events?compressor=zstd&level=3")
+	keysPath := flags.String("keys", "", "the file listing the keys to copy, one per line, or - for stdin, "+
+		"instead of all the keys of the source store")
+	prefix := flags.String("prefix", "", "only copy the keys starting with the prefix")
+	pageSize := flags.Int("page-size", 100, "the number of keys copied between checkpoints")
+	concurrency := flags.Int("concurrency", 1, "the number of keys copied concurrently")
+	checkpointPath := flags.String("checkpoint", "", "the file to save the progress in, to resume from")
+	isDryRun := flags.Bool("dry-run", false, "read the source store without writing to the destination store")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("both -from and -to are required")
+	}
+
+	source, err := newEventStore(ctx, *from)
+	if err != nil {
+		return fmt.Errorf("failed to create the source store: %w", err)
+	}
+	destination, err := newEventStore(ctx, *to)
+	if err != nil {
+		return fmt.Errorf("failed to create the destination store: %w", err)
+	}
+	storeMigration := migration.New(source, destination).
+		WithPrefix(*prefix).
+		WithPageSize(*pageSize).
+		WithConcurrency(*concurrency).
+		WithProgress(func(report migration.Report) { printReport(stdout, report) })
+	if *keysPath != "" {
+		keys, err := readKeys(*keysPath, stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read the keys: %w", err)
+		}
+		storeMigration = storeMigration.WithKeys(keys)
+	}
+	if *checkpointPath != "" {
+		storeMigration = storeMigration.WithCheckpoint(migration.FileCheckpoint(*checkpointPath))
+	}
+	if *isDryRun {
+		storeMigration = storeMigration.WithDryRun()
+		fmt.Fprintln(stdout, "dry run, nothing is written")
+	}
+
+	report, err := storeMigration.Run(ctx)
+	if err != nil {
+		return fmt.Errorf("migration stopped at cursor '%s': %w", report.Cursor, err)
+	}
+	if report.IsAlreadyCompleted {
+		fmt.Fprintln(stdout, "the checkpoint says the migration is already completed, remove it to start over")
+	}
+	fmt.Fprintln(stdout, "done")
+
+	return nil
+}
+
+// readKeys reads the non-empty lines of the file at the path, or of stdin if the path is "-".
+func readKeys(path string, stdin io.Reader) ([]string, error) {
+	reader := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+	keys := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if key := strings.TrimSpace(scanner.Text()); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, scanner.Err()
+}
+
+func printReport(stdout io.Writer, report migration.Report) {
+	fmt.Fprintf(stdout, "copied %d keys, %d contents, %d bytes, cursor '%s'\n",
+		report.Keys, report.Contents, report.Bytes, report.Cursor)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/extensions/compression/compressors"
+	"github.com/honestbank/event-driver/extensions/google-cloud/storage/gcs_event_store"
+	"github.com/honestbank/event-driver/storage/fs_event_store"
+	"github.com/honestbank/event-driver/storage/migration"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.TODO()
+	sourceRoot := t.TempDir()
+	source, err := fs_event_store.New(fs_event_store.Config(sourceRoot).WithCompressor(compression.Gzip(1)))
+	assert.NoError(t, err)
+	assert.NoError(t, source.Persist(ctx, "key1", "source1", "content1-1"))
+	assert.NoError(t, source.Persist(ctx, "key1", "source2", "content1-2"))
+	assert.NoError(t, source.Persist(ctx, "key2", "source1", "content2-1"))
+	from := "fs://" + sourceRoot + "?compressor=gzip"
+
+	t.Run("dry run", func(t *testing.T) {
+		destinationRoot := t.TempDir()
+		stdout := &bytes.Buffer{}
+		err := run(ctx, []string{"-from", from, "-to", "fs://" + destinationRoot, "-keys", "-", "-dry-run"},
+			strings.NewReader("key1\nkey2\n"), stdout)
+		assert.NoError(t, err)
+		assert.Contains(t, stdout.String(), "copied 2 keys, 3 contents, 30 bytes")
+		entries, err := os.ReadDir(destinationRoot)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("list the keys of the source store", func(t *testing.T) {
+		destinationRoot := t.TempDir()
+		stdout := &bytes.Buffer{}
+		err := run(ctx, []string{"-from", from, "-to", "fs://" + destinationRoot}, nil, stdout)
+		assert.NoError(t, err)
+		assert.Contains(t, stdout.String(), "copied 2 keys, 3 contents, 30 bytes")
+
+		destination, err := fs_event_store.New(fs_event_store.Config(destinationRoot))
+		assert.NoError(t, err)
+		messages, err := destination.LookUpByKey(ctx, "key2")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage("key2", "source1", "content2-1")}, messages)
+	})
+
+	t.Run("re-encode with another compressor", func(t *testing.T) {
+		destinationRoot := t.TempDir()
+		keysPath := filepath.Join(t.TempDir(), "keys.txt")
+		assert.NoError(t, os.WriteFile(keysPath, []byte("key1\n\nkey2\nunknown\n"), 0o600))
+		checkpointPath := filepath.Join(t.TempDir(), "checkpoint")
+		stdout := &bytes.Buffer{}
+		err := run(ctx, []string{
+			"-from", from,
+			"-to", "fs://" + destinationRoot + "?compressor=zstd&level=1",
+			"-keys", keysPath,
+			"-page-size", "2",
+			"-concurrency", "2",
+			"-checkpoint", checkpointPath,
+		}, nil, stdout)
+		assert.NoError(t, err)
+		assert.Equal(t, "copied 2 keys, 3 contents, 30 bytes, cursor '2'\n"+
+			"copied 2 keys, 3 contents, 30 bytes, cursor ''\n"+
+			"done\n", stdout.String())
+		progress, err := migration.FileCheckpoint(checkpointPath).Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Progress{IsCompleted: true}, progress)
+		stdout.Reset()
+		err = run(ctx, []string{"-from", from, "-to", "fs://" + destinationRoot, "-keys", keysPath,
+			"-checkpoint", checkpointPath}, nil, stdout)
+		assert.NoError(t, err)
+		assert.Contains(t, stdout.String(), "already completed")
+
+		destination, err := fs_event_store.New(fs_event_store.Config(destinationRoot).WithCompressor(compressors.Zstd(1)))
+		assert.NoError(t, err)
+		messages, err := destination.LookUpByKey(ctx, "key1")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage("key1", "source1", "content1-1"),
+			event.NewMessage("key1", "source2", "content1-2"),
+		}, messages)
+
+		// the auto compressor reads the zstd contents too
+		stdout.Reset()
+		err = run(ctx, []string{"-from", "fs://" + destinationRoot + "?compressor=auto", "-to", "fs://" + t.TempDir(),
+			"-keys", "-", "-prefix", "key2"}, strings.NewReader("key1\nkey2"), stdout)
+		assert.NoError(t, err)
+		assert.Contains(t, stdout.String(), "copied 1 keys, 1 contents, 10 bytes")
+	})
+
+	t.Run("auto reads the framed compressors and fails on unknown contents", func(t *testing.T) {
+		root := t.TempDir()
+		for _, name := range []string{"deflate", "snappy", "raw-snappy"} {
+			compressor, err := newCompressor(name, "")
+			assert.NoError(t, err)
+			store, err := fs_event_store.New(fs_event_store.Config(root).WithCompressor(compressor))
+			assert.NoError(t, err)
+			assert.NoError(t, store.Persist(ctx, name, "source1", strings.Repeat(name, 10)))
+		}
+
+		stdout := &bytes.Buffer{}
+		err := run(ctx, []string{"-from", "fs://" + root + "?compressor=auto", "-to", "fs://" + t.TempDir(),
+			"-keys", "-"}, strings.NewReader("deflate\nsnappy"), stdout)
+		assert.NoError(t, err)
+		assert.Contains(t, stdout.String(), "copied 2 keys, 2 contents, 130 bytes")
+		err = run(ctx, []string{"-from", "fs://" + root + "?compressor=auto", "-to", "fs://" + t.TempDir(),
+			"-keys", "-"}, strings.NewReader("raw-snappy"), stdout)
+		assert.ErrorContains(t, err, "nor UTF-8 text")
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		assert.ErrorContains(t, run(ctx, []string{"-from", from}, nil, stdout), "both -from and -to are required")
+		assert.ErrorContains(t, run(ctx, []string{"-from", from, "-to", "s3://bucket"}, nil, stdout),
+			"unsupported event store 's3://bucket'")
+		assert.ErrorContains(t, run(ctx, []string{"-from", from, "-to", "fs:///tmp?compressor=brotli"}, nil, stdout),
+			"unsupported compressor 'brotli'")
+	})
+}
+
+func TestNewGCSConfig(t *testing.T) {
+	storeURL, err := url.Parse("gcs://bucket/events/v2/?shards=2&escape=true&legacy=1")
+	assert.NoError(t, err)
+	config, err := newGCSConfig(storeURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "bucket", config.Bucket)
+	assert.Equal(t, "events/v2", *config.Folder)
+	assert.Equal(t, gcs_event_store.KeyLayout{
+		Encoding:         gcs_event_store.EscapedKeyEncoding(),
+		ShardPrefixSize:  2,
+		ReadLegacyLayout: true,
+	}, config.KeyLayout)
+
+	storeURL, err = url.Parse("gcs://bucket")
+	assert.NoError(t, err)
+	config, err = newGCSConfig(storeURL)
+	assert.NoError(t, err)
+	assert.Nil(t, config.Folder)
+	assert.Equal(t, gcs_event_store.KeyLayout{}, config.KeyLayout)
+
+	for _, rawURL := range []string{"gcs:///folder", "gcs://bucket?shards=two", "gcs://bucket?escape=maybe"} {
+		storeURL, err = url.Parse(rawURL)
+		assert.NoError(t, err)
+		_, err = newGCSConfig(storeURL)
+		assert.Error(t, err, rawURL)
+	}
+}
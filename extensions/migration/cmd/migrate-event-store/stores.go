@@ -0,0 +1,158 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/honestbank/event-driver/extensions/compression/compressors"
+	"github.com/honestbank/event-driver/extensions/google-cloud/storage/gcs_event_store"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/fs_event_store"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+// newEventStore creates the event store of a URL, which is either
+// - gcs://bucket/folder?compressor=&level=&shards=&escape=&legacy= for a GCSEventStore, or
+// - fs:///root/folder?compressor=&level= for an FSEventStore.
+func newEventStore(ctx context.Context, rawURL string) (storage.EventStore, error) {
+	storeURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event store URL '%s': %w", rawURL, err)
+	}
+	query := storeURL.Query()
+	compressor, err := newCompressor(query.Get("compressor"), query.Get("level"))
+	if err != nil {
+		return nil, err
+	}
+	switch storeURL.Scheme {
+	case "gcs":
+		config, err := newGCSConfig(storeURL)
+		if err != nil {
+			return nil, err
+		}
+
+		return gcs_event_store.New(ctx, config.WithCompressor(compressor))
+	case "fs":
+		if storeURL.Path == "" {
+			return nil, fmt.Errorf("missing the root directory of '%s'", rawURL)
+		}
+
+		return fs_event_store.New(fs_event_store.Config(storeURL.Path).WithCompressor(compressor))
+	default:
+		return nil, fmt.Errorf("unsupported event store '%s', expected gcs://bucket/folder or fs:///directory", rawURL)
+	}
+}
+
+// newGCSConfig creates the configuration of gcs://bucket/folder?shards=&escape=&legacy=, without the compressor.
+func newGCSConfig(storeURL *url.URL) (*gcs_event_store.GCSConfig, error) {
+	if storeURL.Host == "" {
+		return nil, fmt.Errorf("missing the bucket of '%s'", storeURL)
+	}
+	config := gcs_event_store.Config(storeURL.Host)
+	if folder := strings.Trim(storeURL.Path, "/"); folder != "" {
+		config = config.WithFolder(folder)
+	}
+	query := storeURL.Query()
+	keyLayout := gcs_event_store.KeyLayout{}
+	if shards := query.Get("shards"); shards != "" {
+		shardPrefixSize, err := strconv.Atoi(shards)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shards '%s': %w", shards, err)
+		}
+		keyLayout.ShardPrefixSize = shardPrefixSize
+	}
+	isEscaped, err := parseBool(query.Get("escape"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid escape: %w", err)
+	}
+	if isEscaped {
+		keyLayout.Encoding = gcs_event_store.EscapedKeyEncoding()
+	}
+	keyLayout.ReadLegacyLayout, err = parseBool(query.Get("legacy"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid legacy: %w", err)
+	}
+
+	return config.WithKeyLayout(keyLayout), nil
+}
+
+// newCompressor creates the compressor of the name, where "auto" writes zstd and reads any of the others
+// but the raw ones. deflate & snappy are framed like their codecs, so that "auto" recognizes them, while raw-deflate
+// & raw-snappy read the stores written by compression.Deflate & compressors.Snappy.
+func newCompressor(name, level string) (compression.Compressor, error) {
+	compressionLevel := 0
+	if level != "" {
+		var err error
+		if compressionLevel, err = strconv.Atoi(level); err != nil {
+			return nil, fmt.Errorf("invalid compression level '%s': %w", level, err)
+		}
+	}
+	levelOr := func(defaultLevel int) int {
+		if level == "" {
+			return defaultLevel
+		}
+
+		return compressionLevel
+	}
+	switch name {
+	case "", "none":
+		return compression.Noop(), nil
+	case "gzip":
+		return compression.Gzip(levelOr(gzip.DefaultCompression)), nil
+	case "deflate":
+		return compression.DeflateCodec(levelOr(gzip.DefaultCompression)).Compressor, nil
+	case "raw-deflate":
+		return compression.Deflate(levelOr(gzip.DefaultCompression)), nil
+	case "zstd":
+		return compressors.Zstd(levelOr(3)), nil
+	case "snappy":
+		return compressors.SnappyCodec().Compressor, nil
+	case "raw-snappy":
+		return compressors.Snappy(), nil
+	case "lz4":
+		return compressors.LZ4(lz4.CompressionLevel(levelOr(int(lz4.Fast)))), nil
+	case "auto":
+		return compression.AutoDetect(
+			compressors.ZstdCodec(levelOr(3)),
+			compression.GzipCodec(gzip.DefaultCompression),
+			compression.DeflateCodec(gzip.DefaultCompression),
+			compressors.LZ4Codec(lz4.Fast),
+			compressors.SnappyCodec(),
+		).WithFallback(plainText{}), nil
+	default:
+		return nil, fmt.Errorf("unsupported compressor '%s', expected none, gzip, deflate, raw-deflate, zstd, "+
+			"snappy, raw-snappy, lz4 or auto", name)
+	}
+}
+
+// plainText is the fallback of "auto": a content without a known magic prefix is read as is if it's UTF-8 text,
+// and fails otherwise, e.g. if it's compressed by raw-deflate or raw-snappy, instead of being copied compressed.
+type plainText struct{}
+
+func (p plainText) Compress(input []byte) ([]byte, error) {
+	return input, nil
+}
+
+func (p plainText) Decompress(input []byte) ([]byte, error) {
+	if !utf8.Valid(input) {
+		return nil, errors.New("content isn't compressed by a known codec, nor UTF-8 text")
+	}
+
+	return input, nil
+}
+
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,43 @@
+package redis_event_store
+
+import (
+	"time"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type RedisConfig struct {
+	Prefix     string
+	Compressor compression.Compressor
+	TTL        *time.Duration // keys expire after TTL since their last write if configured
+}
+
+// Config creates a default configuration, that
+// - stores each key as a Redis hash named `event-driver:<key>`, with the sources as its fields
+// - doesn't do compression/decompression when write & read the contents
+// - never expires the keys
+func Config() *RedisConfig {
+	return &RedisConfig{
+		Prefix:     "event-driver",
+		Compressor: compression.Noop(),
+	}
+}
+
+// WithPrefix sets the prefix of the Redis keys, which plays the role of the folder in GCSEventStore.
+func (c *RedisConfig) WithPrefix(prefix string) *RedisConfig {
+	c.Prefix = prefix
+
+	return c
+}
+
+func (c *RedisConfig) WithCompressor(compressor compression.Compressor) *RedisConfig {
+	c.Compressor = compressor
+
+	return c
+}
+
+func (c *RedisConfig) WithTTL(ttl time.Duration) *RedisConfig {
+	c.TTL = &ttl
+
+	return c
+}
@@ -0,0 +1,132 @@
+package redis_event_store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/honestbank/event-driver/event"
+)
+
+// RedisEventStore persists the contents in Redis hashes, i.e. `prefix:key` -> source -> content,
+// so that multiple replicas of a service can share the same state with low latency.
+// Redis expires a hash as a whole, so the TTL applies to the key with all its sources,
+// and is refreshed whenever a source of the key is persisted. For the same reason, it isn't a
+// storage.ExpiringEventStore: a TTL per content would expire the other sources of the key along with it.
+type RedisEventStore struct {
+	cfg    *RedisConfig
+	client redis.UniversalClient
+}
+
+func New(cfg *RedisConfig, client redis.UniversalClient) (*RedisEventStore, error) {
+	if cfg == nil {
+		return nil, errors.New("redis config cannot be null")
+	}
+	if client == nil {
+		return nil, errors.New("redis client cannot be null")
+	}
+
+	return &RedisEventStore{
+		cfg:    cfg,
+		client: client,
+	}, nil
+}
+
+func (r *RedisEventStore) Delete(ctx context.Context, key, source string) error {
+	return r.client.HDel(ctx, r.redisKey(key), source).Err()
+}
+
+func (r *RedisEventStore) DeleteByKey(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.redisKey(key)).Err()
+}
+
+func (r *RedisEventStore) ListSourcesByKey(ctx context.Context, key string) ([]string, error) {
+	return r.client.HKeys(ctx, r.redisKey(key)).Result()
+}
+
+func (r *RedisEventStore) LookUp(ctx context.Context, key, source string) (*event.Message, error) {
+	compressedContent, err := r.client.HGet(ctx, r.redisKey(key), source).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := r.cfg.Compressor.Decompress(compressedContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewMessage(key, source, string(content)), nil
+}
+
+// LookUpByKey reads all sources of the key in a single round trip.
+func (r *RedisEventStore) LookUpByKey(ctx context.Context, key string) ([]*event.Message, error) {
+	compressedContentBySource, err := r.client.HGetAll(ctx, r.redisKey(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toMessages(key, compressedContentBySource)
+}
+
+func (r *RedisEventStore) toMessages(key string, compressedContentBySource map[string]string) ([]*event.Message, error) {
+	messages := make([]*event.Message, 0, len(compressedContentBySource))
+	for source, compressedContent := range compressedContentBySource {
+		content, err := r.cfg.Compressor.Decompress([]byte(compressedContent))
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, event.NewMessage(key, source, string(content)))
+	}
+
+	return messages, nil
+}
+
+// Persist writes the content and refreshes the TTL in a single MULTI/EXEC round trip.
+func (r *RedisEventStore) Persist(ctx context.Context, key, source, content string) error {
+	compressedContent, err := r.cfg.Compressor.Compress([]byte(content))
+	if err != nil {
+		return err
+	}
+	redisKey := r.redisKey(key)
+	_, err = r.client.TxPipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		pipeliner.HSet(ctx, redisKey, source, compressedContent)
+		if r.cfg.TTL != nil {
+			pipeliner.PExpire(ctx, redisKey, *r.cfg.TTL)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// PersistAndLookUpByKey persists the content and looks up the messages of the key in a single MULTI/EXEC transaction.
+func (r *RedisEventStore) PersistAndLookUpByKey(ctx context.Context, key, source, content string) ([]*event.Message, error) {
+	compressedContent, err := r.cfg.Compressor.Compress([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	redisKey := r.redisKey(key)
+	var hGetAll *redis.MapStringStringCmd
+	_, err = r.client.TxPipelined(ctx, func(pipeliner redis.Pipeliner) error {
+		pipeliner.HSet(ctx, redisKey, source, compressedContent)
+		if r.cfg.TTL != nil {
+			pipeliner.PExpire(ctx, redisKey, *r.cfg.TTL)
+		}
+		hGetAll = pipeliner.HGetAll(ctx, redisKey)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toMessages(key, hGetAll.Val())
+}
+
+func (r *RedisEventStore) redisKey(key string) string {
+	return r.cfg.Prefix + ":" + key
+}
@@ -0,0 +1,186 @@
+package redis_event_store_test
+
+import (
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/extensions/redis/storage/redis_event_store"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+const (
+	key     = "key"
+	source1 = "source1"
+	source2 = "source2"
+	content = "content"
+)
+
+func TestRedisEventStore(t *testing.T) {
+	t.Run("query empty database", func(t *testing.T) {
+		_, client := setup(t)
+		eventStore, err := redis_event_store.New(redis_event_store.Config(), client)
+		assert.NoError(t, err)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, messageArray)
+	})
+
+	t.Run("persist and look up", func(t *testing.T) {
+		server, client := setup(t)
+		config := redis_event_store.Config().
+			WithPrefix("my-service").
+			WithCompressor(compression.Gzip(gzip.BestSpeed))
+		eventStore, err := redis_event_store.New(config, client)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"my-service:key"}, server.Keys())
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, client := setup(t)
+		eventStore, err := redis_event_store.New(redis_event_store.Config(), client)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		sources, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+	})
+
+	t.Run("with TTL", func(t *testing.T) {
+		server, client := setup(t)
+		eventStore, err := redis_event_store.New(redis_event_store.Config().WithTTL(time.Minute), client)
+		assert.NoError(t, err)
+		// a TTL per content can't be honoured, since Redis expires the hash of the key as a whole
+		_, isExpiring := any(eventStore).(storage.ExpiringEventStore)
+		assert.False(t, isExpiring)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, server.TTL("event-driver:key"))
+
+		// persisting another source refreshes the TTL of the key
+		server.FastForward(30 * time.Second)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+		server.FastForward(45 * time.Second)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+
+		server.FastForward(time.Minute)
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, messageArray)
+	})
+
+	t.Run("persist and look up by key", func(t *testing.T) {
+		server, client := setup(t)
+		eventStore, err := redis_event_store.New(redis_event_store.Config().WithTTL(time.Hour), client)
+		assert.NoError(t, err)
+		var atomicEventStore storage.AtomicEventStore = eventStore
+
+		messageArray, err := atomicEventStore.PersistAndLookUpByKey(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage(key, source1, content)}, messageArray)
+		messageArray, err = atomicEventStore.PersistAndLookUpByKey(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+		assert.Equal(t, time.Hour, server.TTL("event-driver:key"))
+
+		server.SetError("test")
+		_, err = atomicEventStore.PersistAndLookUpByKey(context.TODO(), key, source1, content)
+		assert.Error(t, err)
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		server, client := setup(t)
+		eventStore, err := redis_event_store.New(redis_event_store.Config(), client)
+		assert.NoError(t, err)
+		server.SetError("test")
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.Error(t, err)
+		_, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.Error(t, err)
+		_, err = eventStore.LookUp(context.TODO(), key, source1)
+		assert.Error(t, err)
+		_, err = eventStore.LookUpByKey(context.TODO(), key)
+		assert.Error(t, err)
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.Error(t, err)
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, client := setup(t)
+		_, err := redis_event_store.New(nil, client)
+		assert.Error(t, err)
+		_, err = redis_event_store.New(redis_event_store.Config(), nil)
+		assert.Error(t, err)
+	})
+}
+
+func setup(t *testing.T) (*miniredis.Miniredis, redis.UniversalClient) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	return server, client
+}
@@ -0,0 +1,28 @@
+package sql_event_store
+
+// Dialect holds what differs between the supported databases.
+// Both PostgreSQL and SQLite understand `$n` placeholders and `INSERT ... ON CONFLICT ... DO UPDATE`,
+// so only the column types and the locking differ.
+type Dialect struct {
+	BlobType string
+	// KeyLock is the statement taking a lock on the key (given as $1) until the end of the transaction,
+	// so that PersistAndLookUpByKey of the same key are serialized.
+	// It's empty if a write transaction already locks the whole database.
+	KeyLock string
+	// MigrationLock is the statement taking a lock on the migrations of a table (given as $1) until the end of the
+	// transaction, so that the processes migrating the same table are serialized.
+	// It's empty if the migrations recorded concurrently are only rolled back, see migrate.
+	MigrationLock string
+}
+
+func Postgres() Dialect {
+	return Dialect{
+		BlobType:      "BYTEA",
+		KeyLock:       "SELECT pg_advisory_xact_lock(hashtext($1))",
+		MigrationLock: "SELECT pg_advisory_xact_lock(hashtext($1))",
+	}
+}
+
+func SQLite() Dialect {
+	return Dialect{BlobType: "BLOB"}
+}
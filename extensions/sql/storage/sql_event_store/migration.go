@@ -0,0 +1,114 @@
+package sql_event_store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// migration upgrades the schema by one version, with the table name and the dialect given.
+type migration func(table string, dialect Dialect) []string
+
+// migrations are applied in order, and the index+1 is the schema version after the migration is applied.
+// Never modify a released migration, append a new one instead.
+var migrations = []migration{
+	func(table string, dialect Dialect) []string {
+		return []string{
+			// the primary key (event_key, source) also serves as the index for looking up by key
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+				event_key  TEXT NOT NULL,
+				source     TEXT NOT NULL,
+				content    %s NOT NULL,
+				created_at BIGINT NOT NULL,
+				expires_at BIGINT,
+				PRIMARY KEY (event_key, source)
+			)`, table, dialect.BlobType),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_expires_at_idx ON %s (expires_at)`, table, table),
+		}
+	},
+}
+
+// errConcurrentMigration is returned when another process has recorded the same version in the meantime,
+// which rolls the migration back before migrating from the version recorded.
+var errConcurrentMigration = errors.New("the schema was migrated concurrently")
+
+// migrate applies the migrations that haven't been applied yet, each within its own transaction,
+// and records the applied versions in the table `<table>_migrations`.
+// Each transaction takes Dialect.MigrationLock and reads the current version again, so that the processes starting
+// together apply each migration once. The databases without the lock fall back to rolling back the migration
+// whose version has been recorded concurrently.
+func migrate(ctx context.Context, db *sql.DB, table string, dialect Dialect) error {
+	migrationTable := table + "_migrations"
+	for {
+		isMigrated := false
+		err := inTransaction(ctx, db, func(tx *sql.Tx) error {
+			if dialect.MigrationLock != "" {
+				if _, err := tx.ExecContext(ctx, dialect.MigrationLock, migrationTable); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx,
+				fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version INTEGER PRIMARY KEY)`, migrationTable))
+			if err != nil {
+				return err
+			}
+			var currentVersion int
+			err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT COALESCE(MAX(version), 0) FROM %s`, migrationTable)).
+				Scan(&currentVersion)
+			if err != nil {
+				return err
+			}
+			if currentVersion >= len(migrations) {
+				isMigrated = true
+
+				return nil
+			}
+
+			return migrateTo(ctx, tx, table, migrationTable, dialect, currentVersion+1)
+		})
+		if errors.Is(err, errConcurrentMigration) {
+			continue
+		}
+		if err != nil || isMigrated {
+			return err
+		}
+	}
+}
+
+// migrateTo applies the migration to the given version and records the version.
+func migrateTo(ctx context.Context, tx *sql.Tx, table, migrationTable string, dialect Dialect, version int) error {
+	for _, statement := range migrations[version-1](table, dialect) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to migrate to version %d: %w", version, err)
+		}
+	}
+	result, err := tx.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s (version) VALUES ($1) ON CONFLICT (version) DO NOTHING`, migrationTable), version)
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return errConcurrentMigration
+	}
+
+	return nil
+}
+
+func inTransaction(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+
+		return err
+	}
+
+	return tx.Commit()
+}
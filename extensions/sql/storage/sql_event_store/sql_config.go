@@ -0,0 +1,46 @@
+package sql_event_store
+
+import (
+	"time"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type SQLConfig struct {
+	Dialect    Dialect
+	Table      string
+	Compressor compression.Compressor
+	TTL        *time.Duration // contents expire after TTL if configured
+}
+
+// Config creates a default configuration, that
+// - stores the contents in the table "events"
+// - doesn't do compression/decompression when write & read the contents
+// - never expires the contents
+func Config(dialect Dialect) *SQLConfig {
+	return &SQLConfig{
+		Dialect:    dialect,
+		Table:      "events",
+		Compressor: compression.Noop(),
+	}
+}
+
+// WithTable sets the table of the contents, which plays the role of the folder in GCSEventStore.
+// The table name is put in the queries as is, so it must be a valid SQL identifier.
+func (c *SQLConfig) WithTable(table string) *SQLConfig {
+	c.Table = table
+
+	return c
+}
+
+func (c *SQLConfig) WithCompressor(compressor compression.Compressor) *SQLConfig {
+	c.Compressor = compressor
+
+	return c
+}
+
+func (c *SQLConfig) WithTTL(ttl time.Duration) *SQLConfig {
+	c.TTL = &ttl
+
+	return c
+}
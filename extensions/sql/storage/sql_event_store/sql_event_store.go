@@ -0,0 +1,200 @@
+package sql_event_store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/honestbank/event-driver/event"
+)
+
+// SQLEventStore persists the contents in a SQL table via database/sql, one row per key-source pair.
+// Persist upserts the row, so the latest content of a key-source pair always wins.
+type SQLEventStore struct {
+	cfg *SQLConfig
+	db  *sql.DB
+}
+
+// New creates a SQLEventStore on the given database, and migrates the schema to the latest version.
+func New(ctx context.Context, db *sql.DB, cfg *SQLConfig) (*SQLEventStore, error) {
+	if cfg == nil {
+		return nil, errors.New("sql config cannot be null")
+	}
+	if db == nil {
+		return nil, errors.New("db cannot be null")
+	}
+	if err := migrate(ctx, db, cfg.Table, cfg.Dialect); err != nil {
+		return nil, err
+	}
+
+	return &SQLEventStore{
+		cfg: cfg,
+		db:  db,
+	}, nil
+}
+
+func (s *SQLEventStore) Delete(ctx context.Context, key, source string) error {
+	_, err := s.db.ExecContext(ctx,
+		fmt.Sprintf(`DELETE FROM %s WHERE event_key = $1 AND source = $2`, s.cfg.Table), key, source)
+
+	return err
+}
+
+func (s *SQLEventStore) DeleteByKey(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE event_key = $1`, s.cfg.Table), key)
+
+	return err
+}
+
+func (s *SQLEventStore) ListSourcesByKey(ctx context.Context, key string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		fmt.Sprintf(`SELECT source FROM %s WHERE event_key = $1 AND (expires_at IS NULL OR expires_at > $2)`,
+			s.cfg.Table),
+		key, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make([]string, 0)
+	for rows.Next() {
+		var source string
+		if err = rows.Scan(&source); err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, rows.Err()
+}
+
+func (s *SQLEventStore) LookUp(ctx context.Context, key, source string) (*event.Message, error) {
+	var compressedContent []byte
+	err := s.db.QueryRowContext(ctx,
+		fmt.Sprintf(`SELECT content FROM %s
+			WHERE event_key = $1 AND source = $2 AND (expires_at IS NULL OR expires_at > $3)`, s.cfg.Table),
+		key, source, time.Now().UnixNano()).
+		Scan(&compressedContent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.cfg.Compressor.Decompress(compressedContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewMessage(key, source, string(content)), nil
+}
+
+func (s *SQLEventStore) LookUpByKey(ctx context.Context, key string) ([]*event.Message, error) {
+	return s.lookUpByKey(ctx, s.db, key)
+}
+
+func (s *SQLEventStore) lookUpByKey(ctx context.Context, querier querier, key string) ([]*event.Message, error) {
+	rows, err := querier.QueryContext(ctx,
+		fmt.Sprintf(`SELECT source, content FROM %s
+			WHERE event_key = $1 AND (expires_at IS NULL OR expires_at > $2)`, s.cfg.Table),
+		key, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]*event.Message, 0)
+	for rows.Next() {
+		var source string
+		var compressedContent []byte
+		if err = rows.Scan(&source, &compressedContent); err != nil {
+			return nil, err
+		}
+		content, err := s.cfg.Compressor.Decompress(compressedContent)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, event.NewMessage(key, source, string(content)))
+	}
+
+	return messages, rows.Err()
+}
+
+func (s *SQLEventStore) Persist(ctx context.Context, key, source, content string) error {
+	return s.persist(ctx, s.db, key, source, content, s.cfg.TTL)
+}
+
+// PersistWithTTL persists the content that expires after the given TTL, regardless of the TTL in SQLConfig.
+func (s *SQLEventStore) PersistWithTTL(ctx context.Context, key, source, content string, ttl time.Duration) error {
+	return s.persist(ctx, s.db, key, source, content, &ttl)
+}
+
+// PersistAndLookUpByKey persists the content and looks up the messages of the key in the same transaction,
+// which holds the key lock of the Dialect, so that concurrent calls on the same key are serialized.
+func (s *SQLEventStore) PersistAndLookUpByKey(ctx context.Context, key, source, content string) ([]*event.Message, error) {
+	var messages []*event.Message
+	err := inTransaction(ctx, s.db, func(tx *sql.Tx) error {
+		if s.cfg.Dialect.KeyLock != "" {
+			if _, err := tx.ExecContext(ctx, s.cfg.Dialect.KeyLock, key); err != nil {
+				return err
+			}
+		}
+		if err := s.persist(ctx, tx, key, source, content, s.cfg.TTL); err != nil {
+			return err
+		}
+		var err error
+		messages, err = s.lookUpByKey(ctx, tx, key)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (s *SQLEventStore) persist(
+	ctx context.Context,
+	querier querier,
+	key, source, content string,
+	ttl *time.Duration) error {
+	compressedContent, err := s.cfg.Compressor.Compress([]byte(content))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var expiresAt *int64
+	if ttl != nil {
+		expiresAtNano := now.Add(*ttl).UnixNano()
+		expiresAt = &expiresAtNano
+	}
+	_, err = querier.ExecContext(ctx,
+		fmt.Sprintf(`INSERT INTO %s (event_key, source, content, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (event_key, source) DO UPDATE
+			SET content = excluded.content, created_at = excluded.created_at, expires_at = excluded.expires_at`,
+			s.cfg.Table),
+		key, source, compressedContent, now.UnixNano(), expiresAt)
+
+	return err
+}
+
+// PurgeExpired deletes the expired rows, and returns the number of rows deleted.
+func (s *SQLEventStore) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		fmt.Sprintf(`DELETE FROM %s WHERE expires_at IS NOT NULL AND expires_at <= $1`, s.cfg.Table),
+		time.Now().UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
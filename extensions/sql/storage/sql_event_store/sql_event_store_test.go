@@ -0,0 +1,208 @@
+package sql_event_store_test
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/extensions/sql/storage/sql_event_store"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+const (
+	key     = "key"
+	source1 = "source1"
+	source2 = "source2"
+	content = "content"
+)
+
+func TestSQLiteEventStore(t *testing.T) {
+	// concurrent write transactions wait for each other rather than failing with SQLITE_BUSY
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "events.db")+"?_pragma=busy_timeout(5000)")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	testSQLEventStore(t, db, sql_event_store.SQLite())
+}
+
+// testSQLEventStore runs the same test cases against different databases.
+func testSQLEventStore(t *testing.T, db *sql.DB, dialect sql_event_store.Dialect) {
+	t.Run("query empty table", func(t *testing.T) {
+		eventStore, err := sql_event_store.New(context.TODO(), db, sql_event_store.Config(dialect).WithTable("empty"))
+		assert.NoError(t, err)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, messageArray)
+	})
+
+	t.Run("upsert and look up", func(t *testing.T) {
+		eventStore, err := sql_event_store.New(context.TODO(), db, sql_event_store.Config(dialect).
+			WithTable("upsert").
+			WithCompressor(compression.Gzip(gzip.BestSpeed)))
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+	})
+
+	t.Run("migrate only once", func(t *testing.T) {
+		config := sql_event_store.Config(dialect).WithTable("migrate_once")
+		eventStore, err := sql_event_store.New(context.TODO(), db, config)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+
+		eventStore, err = sql_event_store.New(context.TODO(), db, config)
+		assert.NoError(t, err)
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+		var versions int
+		err = db.QueryRow(`SELECT COUNT(*) FROM migrate_once_migrations`).Scan(&versions)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, versions)
+	})
+
+	t.Run("migrate concurrently", func(t *testing.T) {
+		config := sql_event_store.Config(dialect).WithTable("migrate_concurrently")
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := sql_event_store.New(context.TODO(), db, config)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		var versions int
+		err := db.QueryRow(`SELECT COUNT(*) FROM migrate_concurrently_migrations`).Scan(&versions)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, versions)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		eventStore, err := sql_event_store.New(context.TODO(), db, sql_event_store.Config(dialect).WithTable("deletion"))
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		sources, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+	})
+
+	t.Run("with TTL", func(t *testing.T) {
+		eventStore, err := sql_event_store.New(context.TODO(), db, sql_event_store.Config(dialect).
+			WithTable("ttl").
+			WithTTL(10*time.Millisecond))
+		assert.NoError(t, err)
+		var expiringEventStore storage.ExpiringEventStore = eventStore
+
+		err = expiringEventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = expiringEventStore.PersistWithTTL(context.TODO(), key, source2, content, time.Hour)
+		assert.NoError(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{event.NewMessage(key, source2, content)}, messageArray)
+
+		purged, err := eventStore.PurgeExpired(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+	})
+
+	t.Run("persist and look up by key", func(t *testing.T) {
+		eventStore, err := sql_event_store.New(context.TODO(), db, sql_event_store.Config(dialect).WithTable("atomic"))
+		assert.NoError(t, err)
+		var atomicEventStore storage.AtomicEventStore = eventStore
+		goroutines := 8
+
+		// exactly one of the concurrent writers sees the contents of all the others
+		var completeViews atomic.Int32
+		waitGroup := sync.WaitGroup{}
+		for routine := 0; routine < goroutines; routine++ {
+			waitGroup.Add(1)
+			go func(routine int) {
+				defer waitGroup.Done()
+				source := fmt.Sprintf("source%d", routine)
+				messageArray, err := atomicEventStore.PersistAndLookUpByKey(context.TODO(), key, source, content)
+				assert.NoError(t, err)
+				assert.Contains(t, messageArray, event.NewMessage(key, source, content))
+				if len(messageArray) == goroutines {
+					completeViews.Add(1)
+				}
+			}(routine)
+		}
+		waitGroup.Wait()
+
+		assert.Equal(t, int32(1), completeViews.Load())
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := sql_event_store.New(context.TODO(), db, nil)
+		assert.Error(t, err)
+		_, err = sql_event_store.New(context.TODO(), nil, sql_event_store.Config(dialect))
+		assert.Error(t, err)
+	})
+}
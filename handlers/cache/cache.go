@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 
 	"github.com/honestbank/event-driver/event"
@@ -69,13 +70,41 @@ func (c *cache) Process(ctx context.Context, in *event.Message, next handlers.Ca
 	}
 
 	// persist input message by key & source
-	err = c.storage.Persist(ctx, key, source, in.GetContent())
+	isPersisted, err := c.persist(ctx, key, source, in.GetContent())
 	if err != nil {
 		logger.Error("failed to persist message", slog.Any("error", err))
 
 		return err
 	}
+	// cache hit, the same key & source is persisted concurrently since the look-up
+	if !isPersisted {
+		message, err = c.storage.LookUp(ctx, key, source)
+		if err != nil {
+			logger.Error("failed to look up message", slog.Any("error", err))
+
+			return err
+		}
+		if message == nil {
+			logger.Error("concurrently persisted message is gone")
+
+			return fmt.Errorf("message of key %s and source %s is persisted concurrently but then gone", key, source)
+		}
+		logger.Info("cache hit on persist")
+
+		return c.conflictResolver.Resolve(ctx, message, next)
+	}
 	logger.Debug("cache not hit")
 
 	return next.Call(ctx, in)
 }
+
+// persist writes the content only if it's absent when the storage supports conditional writes,
+// so that only one of the concurrent calls with the same key & source passes to the next handlers.
+// Otherwise, it simply overwrites the content.
+func (c *cache) persist(ctx context.Context, key, source, content string) (bool, error) {
+	if conditionalStorage, isConditional := c.storage.(storage.ConditionalEventStore); isConditional {
+		return conditionalStorage.PersistIfAbsent(ctx, key, source, content)
+	}
+
+	return true, c.storage.Persist(ctx, key, source, content)
+}
@@ -3,8 +3,10 @@ package cache_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/honestbank/event-driver/handlers/options"
@@ -170,4 +172,61 @@ func TestCache(t *testing.T) {
 		err := handler.Process(ctx, input, callNext)
 		assert.Error(t, err)
 	})
+
+	t.Run("cache hit on persist", func(t *testing.T) {
+		ctx := context.TODO()
+		input := event.NewMessage("key", "source", "content1")
+		persisted := event.NewMessage("key", "source", "content2")
+		ctrl := gomock.NewController(t)
+		conflictResolver := mocks.NewMockConflictResolver(ctrl)
+		callNext := mocks.NewMockCallNext(ctrl)
+		eventStore := mocks.NewMockConditionalEventStore(ctrl)
+		gomock.InOrder(
+			eventStore.EXPECT().LookUp(gomock.Any(), "key", "source").Return(nil, nil),
+			// persisted by another replica in between
+			eventStore.EXPECT().PersistIfAbsent(gomock.Any(), "key", "source", "content1").Return(false, nil),
+			eventStore.EXPECT().LookUp(gomock.Any(), "key", "source").Return(persisted, nil),
+		)
+		conflictResolver.EXPECT().Resolve(ctx, persisted, callNext)
+		logs := &strings.Builder{}
+		handler := cache.New(eventStore, options.WithLogWriter(logs)).
+			WithConflictResolver(conflictResolver)
+
+		err := handler.Process(ctx, input, callNext)
+		assert.NoError(t, err)
+		assert.Contains(t, logs.String(), "cache hit on persist")
+	})
+
+	t.Run("concurrently persisted message is gone", func(t *testing.T) {
+		ctx := context.TODO()
+		input := event.NewMessage("key", "source", "content")
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		eventStore := mocks.NewMockConditionalEventStore(ctrl)
+		eventStore.EXPECT().LookUp(gomock.Any(), "key", "source").Return(nil, nil).Times(2)
+		eventStore.EXPECT().PersistIfAbsent(gomock.Any(), "key", "source", "content").Return(false, nil)
+		handler := cache.New(eventStore)
+
+		err := handler.Process(ctx, input, callNext)
+		assert.Error(t, err)
+	})
+
+	t.Run("only one of concurrent calls passes to next", func(t *testing.T) {
+		ctx := context.TODO()
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		callNext.EXPECT().Call(gomock.Any(), gomock.Any()).Times(1)
+		handler := cache.New(storage.NewInMemoryStore())
+
+		var wg sync.WaitGroup
+		for index := 0; index < 50; index++ {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				err := handler.Process(ctx, event.NewMessage("key", "source", fmt.Sprint(index)), callNext)
+				assert.NoError(t, err)
+			}(index)
+		}
+		wg.Wait()
+	})
 }
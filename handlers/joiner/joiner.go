@@ -12,13 +12,22 @@ import (
 	"github.com/honestbank/event-driver/storage"
 )
 
+// JoinMarkerSource is the source under which the joiner records that a key is joined, if the storage implements
+// storage.ConditionalEventStore. The events of this source are never part of the join.
+const JoinMarkerSource = "joiner-joined"
+
 // joiner implements handlers.Handler that joins the events with the same key
 // when the sources match the criteria given by Condition.
 // The output is of JSON format `{"source1":"content1","source2":"content2",...}`.
+// A key is joined once: if the storage implements storage.ConditionalEventStore, the join is recorded with
+// PersistIfAbsent of JoinMarkerSource, so that redelivered or concurrent messages don't join the key again.
+// Otherwise, the key is joined by the message whose source makes the condition met, which redelivered messages
+// of that source do again.
 type joiner struct {
-	condition Condition
-	storage   storage.EventStore
-	logger    *slog.Logger
+	condition   Condition
+	storage     storage.EventStore
+	logger      *slog.Logger
+	clearOnJoin bool
 }
 
 func New(condition Condition, storage storage.EventStore, opts ...options.Option) *joiner {
@@ -35,32 +44,64 @@ func New(condition Condition, storage storage.EventStore, opts ...options.Option
 	}
 }
 
+// WithClearOnJoin makes the joiner delete the persisted events of the key
+// once the joint event is successfully processed by the next handlers.
+// Failing to clear is logged but not returned as error, since the joint event has already been processed.
+func (j *joiner) WithClearOnJoin(clearOnJoin bool) *joiner {
+	j.clearOnJoin = clearOnJoin
+
+	return j
+}
+
 func (j *joiner) Process(ctx context.Context, in *event.Message, next handlers.CallNext) error {
 	logger := j.logger.With(slog.String("key", in.GetKey()), slog.String("source", in.GetSource()))
-	// persist input message by key & source
-	err := j.storage.Persist(ctx, in.GetKey(), in.GetSource(), in.GetContent())
+	// persist input message by key & source, and look up all the messages of the key
+	messages, err := j.persistAndLookUpByKey(ctx, in)
 	if err != nil {
-		logger.Error("failed to persist message", slog.Any("error", err))
+		logger.Error("failed to persist and look up message", slog.Any("error", err))
 
 		return err
 	}
 
 	// validate sources
-	messages, err := j.storage.LookUpByKey(ctx, in.GetKey())
-	if err != nil {
-		logger.Error("failed to look up by key", slog.Any("error", err))
-
-		return err
-	}
 	persistedSources := make([]string, 0, len(messages))
+	otherSources := make([]string, 0, len(messages))
 	for _, message := range messages {
+		if message.GetSource() == JoinMarkerSource {
+			logger.Debug("got message, but the key has already been joined")
+
+			return nil
+		}
 		persistedSources = append(persistedSources, message.GetSource())
+		if message.GetSource() != in.GetSource() {
+			otherSources = append(otherSources, message.GetSource())
+		}
 	}
 	if !j.condition.Evaluate(persistedSources) {
 		logger.Debug("got message, but condition isn't met yet")
 
 		return nil
 	}
+	conditionalStorage, isConditional := j.storage.(storage.ConditionalEventStore)
+	if isConditional {
+		isJoined, err := conditionalStorage.PersistIfAbsent(ctx, in.GetKey(), JoinMarkerSource, in.GetSource())
+		if err != nil {
+			logger.Error("failed to record the join", slog.Any("error", err))
+
+			return err
+		}
+		if !isJoined {
+			logger.Debug("got message, but the key has already been joined")
+
+			return nil
+		}
+	} else if len(otherSources) > 0 && j.condition.Evaluate(otherSources) {
+		// only the source that turns the condition from unmet to met makes the join,
+		// so a key is joined once rather than on every message that arrives after the condition is met
+		logger.Debug("got message, but condition has already been met without it")
+
+		return nil
+	}
 
 	// join sources
 	contentBySource := make(map[string]interface{})
@@ -86,5 +127,36 @@ func (j *joiner) Process(ctx context.Context, in *event.Message, next handlers.C
 	logger.Info("joined message")
 	logger.Debug("joint event", slog.String("content", string(jointContent)))
 
-	return next.Call(ctx, jointEvent)
+	err = next.Call(ctx, jointEvent)
+	if err != nil {
+		// the join is undone so that the redelivered message joins the key again
+		if isConditional {
+			if deleteErr := j.storage.Delete(ctx, in.GetKey(), JoinMarkerSource); deleteErr != nil {
+				logger.Error("failed to undo the join", slog.Any("error", deleteErr))
+			}
+		}
+
+		return err
+	}
+	if !j.clearOnJoin {
+		return nil
+	}
+	if err = j.storage.DeleteByKey(ctx, in.GetKey()); err != nil {
+		logger.Error("failed to clear persisted messages", slog.Any("error", err))
+	}
+
+	return nil
+}
+
+// persistAndLookUpByKey persists the input and looks up the messages of the key, atomically if the storage supports it.
+// Otherwise, concurrent messages of the same key may both miss or both make the join.
+func (j *joiner) persistAndLookUpByKey(ctx context.Context, in *event.Message) ([]*event.Message, error) {
+	if atomicStorage, isAtomic := j.storage.(storage.AtomicEventStore); isAtomic {
+		return atomicStorage.PersistAndLookUpByKey(ctx, in.GetKey(), in.GetSource(), in.GetContent())
+	}
+	if err := j.storage.Persist(ctx, in.GetKey(), in.GetSource(), in.GetContent()); err != nil {
+		return nil, err
+	}
+
+	return j.storage.LookUpByKey(ctx, in.GetKey())
 }
@@ -3,8 +3,10 @@ package joiner_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/honestbank/event-driver/handlers/options"
@@ -146,4 +148,220 @@ func TestJoiner(t *testing.T) {
 		err = handler.Process(ctx, input2, callNext)
 		assert.Error(t, err)
 	})
+
+	t.Run("clear on join", func(t *testing.T) {
+		ctx := context.TODO()
+		input1 := event.NewMessage("key", "source1", "content1")
+		input2 := event.NewMessage("key", "source2", "content2")
+
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		condition := joiner.MatchAll("source1", "source2")
+		eventStore := storage.NewInMemoryStore()
+
+		expectedMessage := event.NewMessage("key", "composed-event", `{"source1":"content1","source2":"content2"}`)
+		callNext.EXPECT().Call(gomock.Any(), expectedMessage).Times(1)
+
+		handler := joiner.New(condition, eventStore).WithClearOnJoin(true)
+		err := handler.Process(ctx, input1, callNext)
+		assert.NoError(t, err)
+		err = handler.Process(ctx, input2, callNext)
+		assert.NoError(t, err)
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Empty(t, messages)
+	})
+
+	t.Run("keep state if failed to pass to next", func(t *testing.T) {
+		ctx := context.TODO()
+		input1 := event.NewMessage("key", "source1", "content1")
+		input2 := event.NewMessage("key", "source2", "content2")
+
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		condition := joiner.MatchAll("source1", "source2")
+		eventStore := storage.NewInMemoryStore()
+
+		callNext.EXPECT().Call(gomock.Any(), gomock.Any()).Return(errors.New("test"))
+
+		handler := joiner.New(condition, eventStore).WithClearOnJoin(true)
+		err := handler.Process(ctx, input1, callNext)
+		assert.NoError(t, err)
+		err = handler.Process(ctx, input2, callNext)
+		assert.Error(t, err)
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Len(t, messages, 2)
+	})
+
+	t.Run("failed to clear on join", func(t *testing.T) {
+		ctx := context.TODO()
+		input := event.NewMessage("key", "source1", "content1")
+
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		condition := joiner.MatchAll("source1")
+		eventStore := mocks.NewMockEventStore(ctrl)
+
+		eventStore.EXPECT().Persist(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+		eventStore.EXPECT().LookUpByKey(gomock.Any(), gomock.Any()).Return([]*event.Message{input}, nil)
+		eventStore.EXPECT().DeleteByKey(gomock.Any(), "key").Return(errors.New("test"))
+		callNext.EXPECT().Call(gomock.Any(), gomock.Any())
+
+		logs := &strings.Builder{}
+		handler := joiner.New(condition, eventStore, options.WithLogWriter(logs)).WithClearOnJoin(true)
+		err := handler.Process(ctx, input, callNext)
+		assert.NoError(t, err)
+		assert.Contains(t, logs.String(), "failed to clear persisted messages")
+	})
+
+	t.Run("join once per key", func(t *testing.T) {
+		ctx := context.TODO()
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		condition := joiner.MatchAll("source1").
+			And(joiner.MatchAny("source2", "source3"))
+		eventStore := storage.NewBoundedStore(10) // isn't a storage.ConditionalEventStore
+
+		expectedMessage := event.NewMessage("key", "composed-event", `{"source1":"content1","source2":"content2"}`)
+		callNext.EXPECT().Call(gomock.Any(), expectedMessage).Times(1)
+
+		logs := &strings.Builder{}
+		handler := joiner.New(condition, eventStore, options.WithLogLevel(slog.LevelDebug), options.WithLogWriter(logs))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source1", "content1"), callNext))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source2", "content2"), callNext))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source3", "content3"), callNext))
+		assert.Contains(t, logs.String(), "got message, but condition has already been met without it")
+	})
+
+	t.Run("record the join in a conditional store", func(t *testing.T) {
+		ctx := context.TODO()
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		eventStore := storage.NewInMemoryStore()
+
+		expectedMessage := event.NewMessage("key", "composed-event", `{"source1":"content1","source2":"content2"}`)
+		callNext.EXPECT().Call(gomock.Any(), expectedMessage).Times(1)
+
+		logs := &strings.Builder{}
+		handler := joiner.New(joiner.MatchAll("source1", "source2"), eventStore,
+			options.WithLogLevel(slog.LevelDebug), options.WithLogWriter(logs))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source1", "content1"), callNext))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source2", "content2"), callNext))
+		// the message that made the join is redelivered
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source2", "content2"), callNext))
+		assert.Contains(t, logs.String(), "got message, but the key has already been joined")
+
+		marker, err := eventStore.LookUp(ctx, "key", joiner.JoinMarkerSource)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", joiner.JoinMarkerSource, "source2"), marker)
+	})
+
+	t.Run("join once without required sources", func(t *testing.T) {
+		ctx := context.TODO()
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		callNext.EXPECT().Call(gomock.Any(), event.NewMessage("key", "composed-event", `{"source1":"content1"}`)).
+			Times(1)
+
+		handler := joiner.New(joiner.MatchAll(), storage.NewInMemoryStore())
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source1", "content1"), callNext))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source2", "content2"), callNext))
+	})
+
+	t.Run("undo the join if failed to pass to next", func(t *testing.T) {
+		ctx := context.TODO()
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		gomock.InOrder(
+			callNext.EXPECT().Call(gomock.Any(), gomock.Any()).Return(errors.New("test")),
+			callNext.EXPECT().Call(gomock.Any(), gomock.Any()),
+		)
+
+		handler := joiner.New(joiner.MatchAll("source1"), storage.NewInMemoryStore())
+		assert.Error(t, handler.Process(ctx, event.NewMessage("key", "source1", "content1"), callNext))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source1", "content1"), callNext))
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source1", "content1"), callNext))
+	})
+
+	t.Run("failed to record the join", func(t *testing.T) {
+		ctx := context.TODO()
+		input := event.NewMessage("key", "source1", "content1")
+
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		eventStore := mocks.NewMockConditionalEventStore(ctrl)
+		eventStore.EXPECT().Persist(gomock.Any(), "key", "source1", "content1")
+		eventStore.EXPECT().LookUpByKey(gomock.Any(), "key").Return([]*event.Message{input}, nil)
+		eventStore.EXPECT().PersistIfAbsent(gomock.Any(), "key", joiner.JoinMarkerSource, "source1").
+			Return(false, errors.New("test"))
+
+		logs := &strings.Builder{}
+		handler := joiner.New(joiner.MatchAll("source1"), eventStore, options.WithLogWriter(logs))
+		assert.Error(t, handler.Process(ctx, input, callNext))
+		assert.Contains(t, logs.String(), "failed to record the join")
+	})
+
+	t.Run("join once under concurrency", func(t *testing.T) {
+		ctx := context.TODO()
+		sources := make([]string, 0)
+		for index := 0; index < 20; index++ {
+			sources = append(sources, fmt.Sprintf("source%d", index))
+		}
+
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		callNext.EXPECT().Call(gomock.Any(), gomock.Any()).Times(1)
+		handler := joiner.New(joiner.MatchAll(sources...), storage.NewInMemoryStore())
+
+		waitGroup := sync.WaitGroup{}
+		for _, source := range sources {
+			waitGroup.Add(1)
+			go func(source string) {
+				defer waitGroup.Done()
+				assert.NoError(t, handler.Process(ctx, event.NewMessage("key", source, "content"), callNext))
+			}(source)
+		}
+		waitGroup.Wait()
+	})
+
+	t.Run("join once under concurrent duplicates without atomicity", func(t *testing.T) {
+		ctx := context.TODO()
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		callNext.EXPECT().Call(gomock.Any(), gomock.Any()).Times(1)
+		// hide PersistAndLookUpByKey of the InMemoryStore, but keep its conditional writes
+		eventStore := struct{ storage.ConditionalEventStore }{storage.NewInMemoryStore()}
+		handler := joiner.New(joiner.MatchAll("source1", "source2"), eventStore)
+		assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source1", "content1"), callNext))
+
+		waitGroup := sync.WaitGroup{}
+		for index := 0; index < 10; index++ {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				assert.NoError(t, handler.Process(ctx, event.NewMessage("key", "source2", "content2"), callNext))
+			}()
+		}
+		waitGroup.Wait()
+	})
+
+	t.Run("failed to persist and look up atomically", func(t *testing.T) {
+		ctx := context.TODO()
+		input := event.NewMessage("key", "source1", "content1")
+
+		ctrl := gomock.NewController(t)
+		callNext := mocks.NewMockCallNext(ctrl)
+		eventStore := mocks.NewMockAtomicEventStore(ctrl)
+		eventStore.EXPECT().PersistAndLookUpByKey(gomock.Any(), "key", "source1", "content1").
+			Return(nil, errors.New("test"))
+
+		logs := &strings.Builder{}
+		handler := joiner.New(joiner.MatchAll("source1"), eventStore, options.WithLogWriter(logs))
+		err := handler.Process(ctx, input, callNext)
+		assert.Error(t, err)
+		assert.Contains(t, logs.String(), "failed to persist and look up message")
+	})
 }
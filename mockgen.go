@@ -2,7 +2,8 @@
 
 //go:generate go run go.uber.org/mock/mockgen -destination=./mocks/mock_handlers.go -package=mocks github.com/honestbank/event-driver/handlers CallNext
 //go:generate go run go.uber.org/mock/mockgen -destination=./mocks/mock_cache.go -package=mocks github.com/honestbank/event-driver/handlers/cache ConflictResolver,KeyExtractor
-//go:generate go run go.uber.org/mock/mockgen -destination=./mocks/mock_event_storage.go -package=mocks github.com/honestbank/event-driver/storage EventStore
+//go:generate go run go.uber.org/mock/mockgen -destination=./mocks/mock_event_storage.go -package=mocks github.com/honestbank/event-driver/storage EventStore,ConditionalEventStore,AtomicEventStore,KeyListingEventStore
+//go:generate go run go.uber.org/mock/mockgen -destination=./mocks/mock_object_store.go -package=mocks github.com/honestbank/event-driver/storage/object_event_store ObjectIterator,ObjectStore
 
 package main
 
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/honestbank/event-driver/storage (interfaces: EventStore)
+// Source: github.com/honestbank/event-driver/storage (interfaces: EventStore,ConditionalEventStore,AtomicEventStore,KeyListingEventStore)
 //
 // Generated by this command:
 //
-//	mockgen -destination=./mocks/mock_event_storage.go -package=mocks github.com/honestbank/event-driver/storage EventStore
+//	mockgen -destination=./mocks/mock_event_storage.go -package=mocks github.com/honestbank/event-driver/storage EventStore,ConditionalEventStore,AtomicEventStore,KeyListingEventStore
 //
 
 // Package mocks is a generated GoMock package.
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	event "github.com/honestbank/event-driver/event"
+	storage "github.com/honestbank/event-driver/storage"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -40,6 +41,34 @@ func (m *MockEventStore) EXPECT() *MockEventStoreMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockEventStore) Delete(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockEventStoreMockRecorder) Delete(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockEventStore)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteByKey mocks base method.
+func (m *MockEventStore) DeleteByKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByKey indicates an expected call of DeleteByKey.
+func (mr *MockEventStoreMockRecorder) DeleteByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByKey", reflect.TypeOf((*MockEventStore)(nil).DeleteByKey), arg0, arg1)
+}
+
 // ListSourcesByKey mocks base method.
 func (m *MockEventStore) ListSourcesByKey(arg0 context.Context, arg1 string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -98,3 +127,410 @@ func (mr *MockEventStoreMockRecorder) Persist(arg0, arg1, arg2, arg3 any) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockEventStore)(nil).Persist), arg0, arg1, arg2, arg3)
 }
+
+// MockConditionalEventStore is a mock of ConditionalEventStore interface.
+type MockConditionalEventStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockConditionalEventStoreMockRecorder
+}
+
+// MockConditionalEventStoreMockRecorder is the mock recorder for MockConditionalEventStore.
+type MockConditionalEventStoreMockRecorder struct {
+	mock *MockConditionalEventStore
+}
+
+// NewMockConditionalEventStore creates a new mock instance.
+func NewMockConditionalEventStore(ctrl *gomock.Controller) *MockConditionalEventStore {
+	mock := &MockConditionalEventStore{ctrl: ctrl}
+	mock.recorder = &MockConditionalEventStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConditionalEventStore) EXPECT() *MockConditionalEventStoreMockRecorder {
+	return m.recorder
+}
+
+// CompareAndPersist mocks base method.
+func (m *MockConditionalEventStore) CompareAndPersist(arg0 context.Context, arg1, arg2, arg3 string, arg4 storage.Version) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndPersist", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndPersist indicates an expected call of CompareAndPersist.
+func (mr *MockConditionalEventStoreMockRecorder) CompareAndPersist(arg0, arg1, arg2, arg3, arg4 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndPersist", reflect.TypeOf((*MockConditionalEventStore)(nil).CompareAndPersist), arg0, arg1, arg2, arg3, arg4)
+}
+
+// Delete mocks base method.
+func (m *MockConditionalEventStore) Delete(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockConditionalEventStoreMockRecorder) Delete(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockConditionalEventStore)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteByKey mocks base method.
+func (m *MockConditionalEventStore) DeleteByKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByKey indicates an expected call of DeleteByKey.
+func (mr *MockConditionalEventStoreMockRecorder) DeleteByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByKey", reflect.TypeOf((*MockConditionalEventStore)(nil).DeleteByKey), arg0, arg1)
+}
+
+// ListSourcesByKey mocks base method.
+func (m *MockConditionalEventStore) ListSourcesByKey(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSourcesByKey", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSourcesByKey indicates an expected call of ListSourcesByKey.
+func (mr *MockConditionalEventStoreMockRecorder) ListSourcesByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSourcesByKey", reflect.TypeOf((*MockConditionalEventStore)(nil).ListSourcesByKey), arg0, arg1)
+}
+
+// LookUp mocks base method.
+func (m *MockConditionalEventStore) LookUp(arg0 context.Context, arg1, arg2 string) (*event.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookUp", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*event.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookUp indicates an expected call of LookUp.
+func (mr *MockConditionalEventStoreMockRecorder) LookUp(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookUp", reflect.TypeOf((*MockConditionalEventStore)(nil).LookUp), arg0, arg1, arg2)
+}
+
+// LookUpByKey mocks base method.
+func (m *MockConditionalEventStore) LookUpByKey(arg0 context.Context, arg1 string) ([]*event.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookUpByKey", arg0, arg1)
+	ret0, _ := ret[0].([]*event.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookUpByKey indicates an expected call of LookUpByKey.
+func (mr *MockConditionalEventStoreMockRecorder) LookUpByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookUpByKey", reflect.TypeOf((*MockConditionalEventStore)(nil).LookUpByKey), arg0, arg1)
+}
+
+// LookUpVersion mocks base method.
+func (m *MockConditionalEventStore) LookUpVersion(arg0 context.Context, arg1, arg2 string) (*event.Message, storage.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookUpVersion", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*event.Message)
+	ret1, _ := ret[1].(storage.Version)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LookUpVersion indicates an expected call of LookUpVersion.
+func (mr *MockConditionalEventStoreMockRecorder) LookUpVersion(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookUpVersion", reflect.TypeOf((*MockConditionalEventStore)(nil).LookUpVersion), arg0, arg1, arg2)
+}
+
+// Persist mocks base method.
+func (m *MockConditionalEventStore) Persist(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Persist", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Persist indicates an expected call of Persist.
+func (mr *MockConditionalEventStoreMockRecorder) Persist(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockConditionalEventStore)(nil).Persist), arg0, arg1, arg2, arg3)
+}
+
+// PersistIfAbsent mocks base method.
+func (m *MockConditionalEventStore) PersistIfAbsent(arg0 context.Context, arg1, arg2, arg3 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PersistIfAbsent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PersistIfAbsent indicates an expected call of PersistIfAbsent.
+func (mr *MockConditionalEventStoreMockRecorder) PersistIfAbsent(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PersistIfAbsent", reflect.TypeOf((*MockConditionalEventStore)(nil).PersistIfAbsent), arg0, arg1, arg2, arg3)
+}
+
+// MockAtomicEventStore is a mock of AtomicEventStore interface.
+type MockAtomicEventStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAtomicEventStoreMockRecorder
+}
+
+// MockAtomicEventStoreMockRecorder is the mock recorder for MockAtomicEventStore.
+type MockAtomicEventStoreMockRecorder struct {
+	mock *MockAtomicEventStore
+}
+
+// NewMockAtomicEventStore creates a new mock instance.
+func NewMockAtomicEventStore(ctrl *gomock.Controller) *MockAtomicEventStore {
+	mock := &MockAtomicEventStore{ctrl: ctrl}
+	mock.recorder = &MockAtomicEventStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAtomicEventStore) EXPECT() *MockAtomicEventStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockAtomicEventStore) Delete(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockAtomicEventStoreMockRecorder) Delete(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockAtomicEventStore)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteByKey mocks base method.
+func (m *MockAtomicEventStore) DeleteByKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByKey indicates an expected call of DeleteByKey.
+func (mr *MockAtomicEventStoreMockRecorder) DeleteByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByKey", reflect.TypeOf((*MockAtomicEventStore)(nil).DeleteByKey), arg0, arg1)
+}
+
+// ListSourcesByKey mocks base method.
+func (m *MockAtomicEventStore) ListSourcesByKey(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSourcesByKey", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSourcesByKey indicates an expected call of ListSourcesByKey.
+func (mr *MockAtomicEventStoreMockRecorder) ListSourcesByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSourcesByKey", reflect.TypeOf((*MockAtomicEventStore)(nil).ListSourcesByKey), arg0, arg1)
+}
+
+// LookUp mocks base method.
+func (m *MockAtomicEventStore) LookUp(arg0 context.Context, arg1, arg2 string) (*event.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookUp", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*event.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookUp indicates an expected call of LookUp.
+func (mr *MockAtomicEventStoreMockRecorder) LookUp(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookUp", reflect.TypeOf((*MockAtomicEventStore)(nil).LookUp), arg0, arg1, arg2)
+}
+
+// LookUpByKey mocks base method.
+func (m *MockAtomicEventStore) LookUpByKey(arg0 context.Context, arg1 string) ([]*event.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookUpByKey", arg0, arg1)
+	ret0, _ := ret[0].([]*event.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookUpByKey indicates an expected call of LookUpByKey.
+func (mr *MockAtomicEventStoreMockRecorder) LookUpByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookUpByKey", reflect.TypeOf((*MockAtomicEventStore)(nil).LookUpByKey), arg0, arg1)
+}
+
+// Persist mocks base method.
+func (m *MockAtomicEventStore) Persist(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Persist", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Persist indicates an expected call of Persist.
+func (mr *MockAtomicEventStoreMockRecorder) Persist(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockAtomicEventStore)(nil).Persist), arg0, arg1, arg2, arg3)
+}
+
+// PersistAndLookUpByKey mocks base method.
+func (m *MockAtomicEventStore) PersistAndLookUpByKey(arg0 context.Context, arg1, arg2, arg3 string) ([]*event.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PersistAndLookUpByKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*event.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PersistAndLookUpByKey indicates an expected call of PersistAndLookUpByKey.
+func (mr *MockAtomicEventStoreMockRecorder) PersistAndLookUpByKey(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PersistAndLookUpByKey", reflect.TypeOf((*MockAtomicEventStore)(nil).PersistAndLookUpByKey), arg0, arg1, arg2, arg3)
+}
+
+// MockKeyListingEventStore is a mock of KeyListingEventStore interface.
+type MockKeyListingEventStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyListingEventStoreMockRecorder
+}
+
+// MockKeyListingEventStoreMockRecorder is the mock recorder for MockKeyListingEventStore.
+type MockKeyListingEventStoreMockRecorder struct {
+	mock *MockKeyListingEventStore
+}
+
+// NewMockKeyListingEventStore creates a new mock instance.
+func NewMockKeyListingEventStore(ctrl *gomock.Controller) *MockKeyListingEventStore {
+	mock := &MockKeyListingEventStore{ctrl: ctrl}
+	mock.recorder = &MockKeyListingEventStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyListingEventStore) EXPECT() *MockKeyListingEventStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockKeyListingEventStore) Delete(arg0 context.Context, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockKeyListingEventStoreMockRecorder) Delete(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockKeyListingEventStore)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteByKey mocks base method.
+func (m *MockKeyListingEventStore) DeleteByKey(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByKey indicates an expected call of DeleteByKey.
+func (mr *MockKeyListingEventStoreMockRecorder) DeleteByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByKey", reflect.TypeOf((*MockKeyListingEventStore)(nil).DeleteByKey), arg0, arg1)
+}
+
+// ListKeys mocks base method.
+func (m *MockKeyListingEventStore) ListKeys(arg0 context.Context, arg1, arg2 string, arg3 int) ([]string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeys", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListKeys indicates an expected call of ListKeys.
+func (mr *MockKeyListingEventStoreMockRecorder) ListKeys(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeys", reflect.TypeOf((*MockKeyListingEventStore)(nil).ListKeys), arg0, arg1, arg2, arg3)
+}
+
+// ListSourcesByKey mocks base method.
+func (m *MockKeyListingEventStore) ListSourcesByKey(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSourcesByKey", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSourcesByKey indicates an expected call of ListSourcesByKey.
+func (mr *MockKeyListingEventStoreMockRecorder) ListSourcesByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSourcesByKey", reflect.TypeOf((*MockKeyListingEventStore)(nil).ListSourcesByKey), arg0, arg1)
+}
+
+// LookUp mocks base method.
+func (m *MockKeyListingEventStore) LookUp(arg0 context.Context, arg1, arg2 string) (*event.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookUp", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*event.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookUp indicates an expected call of LookUp.
+func (mr *MockKeyListingEventStoreMockRecorder) LookUp(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookUp", reflect.TypeOf((*MockKeyListingEventStore)(nil).LookUp), arg0, arg1, arg2)
+}
+
+// LookUpByKey mocks base method.
+func (m *MockKeyListingEventStore) LookUpByKey(arg0 context.Context, arg1 string) ([]*event.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookUpByKey", arg0, arg1)
+	ret0, _ := ret[0].([]*event.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookUpByKey indicates an expected call of LookUpByKey.
+func (mr *MockKeyListingEventStoreMockRecorder) LookUpByKey(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookUpByKey", reflect.TypeOf((*MockKeyListingEventStore)(nil).LookUpByKey), arg0, arg1)
+}
+
+// Persist mocks base method.
+func (m *MockKeyListingEventStore) Persist(arg0 context.Context, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Persist", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Persist indicates an expected call of Persist.
+func (mr *MockKeyListingEventStoreMockRecorder) Persist(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockKeyListingEventStore)(nil).Persist), arg0, arg1, arg2, arg3)
+}
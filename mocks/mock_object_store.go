@@ -0,0 +1,152 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/honestbank/event-driver/storage/object_event_store (interfaces: ObjectIterator,ObjectStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/mock_object_store.go -package=mocks github.com/honestbank/event-driver/storage/object_event_store ObjectIterator,ObjectStore
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	object_event_store "github.com/honestbank/event-driver/storage/object_event_store"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockObjectIterator is a mock of ObjectIterator interface.
+type MockObjectIterator struct {
+	ctrl     *gomock.Controller
+	recorder *MockObjectIteratorMockRecorder
+}
+
+// MockObjectIteratorMockRecorder is the mock recorder for MockObjectIterator.
+type MockObjectIteratorMockRecorder struct {
+	mock *MockObjectIterator
+}
+
+// NewMockObjectIterator creates a new mock instance.
+func NewMockObjectIterator(ctrl *gomock.Controller) *MockObjectIterator {
+	mock := &MockObjectIterator{ctrl: ctrl}
+	mock.recorder = &MockObjectIteratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockObjectIterator) EXPECT() *MockObjectIteratorMockRecorder {
+	return m.recorder
+}
+
+// Next mocks base method.
+func (m *MockObjectIterator) Next() (*object_event_store.ObjectAttrs, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next")
+	ret0, _ := ret[0].(*object_event_store.ObjectAttrs)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Next indicates an expected call of Next.
+func (mr *MockObjectIteratorMockRecorder) Next() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockObjectIterator)(nil).Next))
+}
+
+// MockObjectStore is a mock of ObjectStore interface.
+type MockObjectStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockObjectStoreMockRecorder
+}
+
+// MockObjectStoreMockRecorder is the mock recorder for MockObjectStore.
+type MockObjectStoreMockRecorder struct {
+	mock *MockObjectStore
+}
+
+// NewMockObjectStore creates a new mock instance.
+func NewMockObjectStore(ctrl *gomock.Controller) *MockObjectStore {
+	mock := &MockObjectStore{ctrl: ctrl}
+	mock.recorder = &MockObjectStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockObjectStore) EXPECT() *MockObjectStoreMockRecorder {
+	return m.recorder
+}
+
+// Attrs mocks base method.
+func (m *MockObjectStore) Attrs(arg0 context.Context, arg1 string) (*object_event_store.ObjectAttrs, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attrs", arg0, arg1)
+	ret0, _ := ret[0].(*object_event_store.ObjectAttrs)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Attrs indicates an expected call of Attrs.
+func (mr *MockObjectStoreMockRecorder) Attrs(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attrs", reflect.TypeOf((*MockObjectStore)(nil).Attrs), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockObjectStore) Delete(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockObjectStoreMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockObjectStore)(nil).Delete), arg0, arg1)
+}
+
+// List mocks base method.
+func (m *MockObjectStore) List(arg0 context.Context, arg1 *object_event_store.Query) object_event_store.ObjectIterator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", arg0, arg1)
+	ret0, _ := ret[0].(object_event_store.ObjectIterator)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockObjectStoreMockRecorder) List(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockObjectStore)(nil).List), arg0, arg1)
+}
+
+// Read mocks base method.
+func (m *MockObjectStore) Read(arg0 context.Context, arg1 string) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Read", arg0, arg1)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Read indicates an expected call of Read.
+func (mr *MockObjectStoreMockRecorder) Read(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockObjectStore)(nil).Read), arg0, arg1)
+}
+
+// Write mocks base method.
+func (m *MockObjectStore) Write(arg0 context.Context, arg1 *object_event_store.ObjectAttrs, arg2 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockObjectStoreMockRecorder) Write(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockObjectStore)(nil).Write), arg0, arg1, arg2)
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/honestbank/event-driver/event"
+)
+
+// BoundedStore keeps the contents in memory like InMemoryStore, but holds at most `capacity` keys.
+// When a new key is persisted into a full store, the key chosen by the EvictionPolicy is evicted with all its sources.
+// It's meant for bounded windows such as cache deduplication, where losing old keys is acceptable.
+type BoundedStore struct {
+	lock           sync.Mutex
+	capacity       int
+	records        map[string]map[string]string // key -> source -> content
+	evictionPolicy EvictionPolicy
+	onEviction     func(key string, messages []*event.Message)
+	stats          BoundedStoreStats
+}
+
+// BoundedStoreStats counts the look-ups and evictions of a BoundedStore since it's created.
+type BoundedStoreStats struct {
+	Hits      uint64 // number of look-ups that found the key (and source)
+	Misses    uint64 // number of look-ups that didn't find the key (and source)
+	Evictions uint64 // number of keys evicted to make room for new keys
+	Keys      int    // number of keys currently in the store
+}
+
+// NewBoundedStore creates a BoundedStore that holds at most `capacity` keys, evicting the least recently used key.
+// A non-positive capacity is treated as 1.
+func NewBoundedStore(capacity int) *BoundedStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &BoundedStore{
+		capacity:       capacity,
+		records:        make(map[string]map[string]string),
+		evictionPolicy: LeastRecentlyUsed(),
+	}
+}
+
+// WithEvictionPolicy replaces the eviction policy, e.g. with LeastFrequentlyUsed.
+// The keys already in the store are touched once each in lexicographic order, so that the new policy can evict them,
+// but their past usage is lost.
+func (b *BoundedStore) WithEvictionPolicy(evictionPolicy EvictionPolicy) *BoundedStore {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	keys := make([]string, 0, len(b.records))
+	for key := range b.records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		evictionPolicy.Touch(key)
+	}
+	b.evictionPolicy = evictionPolicy
+
+	return b
+}
+
+// WithOnEviction registers a callback that receives the evicted key along with all its messages.
+// The callback is called after the store is unlocked, so it's safe to use the store inside the callback.
+func (b *BoundedStore) WithOnEviction(onEviction func(key string, messages []*event.Message)) *BoundedStore {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.onEviction = onEviction
+
+	return b
+}
+
+func (b *BoundedStore) Stats() BoundedStoreStats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	stats := b.stats
+	stats.Keys = len(b.records)
+
+	return stats
+}
+
+func (b *BoundedStore) Delete(_ context.Context, key, source string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	delete(b.records[key], source)
+	if len(b.records[key]) == 0 {
+		b.deleteKey(key)
+	}
+
+	return nil
+}
+
+func (b *BoundedStore) DeleteByKey(_ context.Context, key string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.deleteKey(key)
+
+	return nil
+}
+
+func (b *BoundedStore) ListSourcesByKey(_ context.Context, key string) ([]string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	results := b.lookUpKey(key)
+	sources := make([]string, 0, len(results))
+	for source := range results {
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
+
+// ListKeys returns the keys in lexicographic order, where the cursor is the last listed key.
+// Listing the keys doesn't count as a use of them for the EvictionPolicy.
+func (b *BoundedStore) ListKeys(_ context.Context, prefix, cursor string, limit int) ([]string, string, error) {
+	b.lock.Lock()
+	keys := make([]string, 0, len(b.records))
+	for key := range b.records {
+		keys = append(keys, key)
+	}
+	b.lock.Unlock()
+	keys, nextCursor := listKeys(keys, prefix, cursor, limit)
+
+	return keys, nextCursor, nil
+}
+
+func (b *BoundedStore) LookUp(_ context.Context, key, source string) (*event.Message, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	content, isHit := b.records[key][source]
+	if !isHit {
+		b.stats.Misses++
+
+		return nil, nil
+	}
+	b.stats.Hits++
+	b.evictionPolicy.Touch(key)
+
+	return event.NewMessage(key, source, content), nil
+}
+
+func (b *BoundedStore) LookUpByKey(_ context.Context, key string) ([]*event.Message, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return toMessages(key, b.lookUpKey(key)), nil
+}
+
+func (b *BoundedStore) Persist(_ context.Context, key, source, content string) error {
+	b.lock.Lock()
+	evictedKey, evictedMessages := b.persist(key, source, content)
+	onEviction := b.onEviction
+	b.lock.Unlock()
+
+	if evictedMessages != nil && onEviction != nil {
+		onEviction(evictedKey, evictedMessages)
+	}
+
+	return nil
+}
+
+// PersistAndLookUpByKey persists the content and looks up the messages of the key under the same lock.
+func (b *BoundedStore) PersistAndLookUpByKey(_ context.Context, key, source, content string) ([]*event.Message, error) {
+	b.lock.Lock()
+	evictedKey, evictedMessages := b.persist(key, source, content)
+	messages := toMessages(key, b.records[key])
+	onEviction := b.onEviction
+	b.lock.Unlock()
+
+	if evictedMessages != nil && onEviction != nil {
+		onEviction(evictedKey, evictedMessages)
+	}
+
+	return messages, nil
+}
+
+// persist writes the content, and returns the evicted key & messages if an eviction happened.
+func (b *BoundedStore) persist(key, source, content string) (string, []*event.Message) {
+	var evictedKey string
+	var evictedMessages []*event.Message
+	if _, isKeyExist := b.records[key]; !isKeyExist {
+		if len(b.records) >= b.capacity {
+			evictedKey, evictedMessages = b.evict()
+		}
+		b.records[key] = make(map[string]string)
+	}
+	b.records[key][source] = content
+	b.evictionPolicy.Touch(key)
+
+	return evictedKey, evictedMessages
+}
+
+func (b *BoundedStore) evict() (string, []*event.Message) {
+	victim, hasVictim := b.evictionPolicy.Victim()
+	if !hasVictim {
+		return "", nil
+	}
+	messages := toMessages(victim, b.records[victim])
+	b.deleteKey(victim)
+	b.stats.Evictions++
+
+	return victim, messages
+}
+
+func (b *BoundedStore) lookUpKey(key string) map[string]string {
+	results, isHit := b.records[key]
+	if !isHit {
+		b.stats.Misses++
+
+		return nil
+	}
+	b.stats.Hits++
+	b.evictionPolicy.Touch(key)
+
+	return results
+}
+
+func (b *BoundedStore) deleteKey(key string) {
+	delete(b.records, key)
+	b.evictionPolicy.Remove(key)
+}
+
+func toMessages(key string, contentBySource map[string]string) []*event.Message {
+	messages := make([]*event.Message, 0, len(contentBySource))
+	for source, content := range contentBySource {
+		messages = append(messages, event.NewMessage(key, source, content))
+	}
+
+	return messages
+}
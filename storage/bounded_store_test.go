@@ -0,0 +1,173 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+)
+
+func TestBoundedStore(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("behaves as an event store within capacity", func(t *testing.T) {
+		var boundedStore storage.EventStore = storage.NewBoundedStore(2)
+
+		assert.NoError(t, boundedStore.Persist(ctx, key1, source1, "content1-1"))
+		assert.NoError(t, boundedStore.Persist(ctx, key1, source2, "content1-2"))
+		assert.NoError(t, boundedStore.Persist(ctx, key2, source1, "content2-1"))
+
+		sources, err := boundedStore.ListSourcesByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+		contents, err := boundedStore.LookUpByKey(ctx, key2)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{event.NewMessage(key2, source1, "content2-1")}, contents)
+		content, err := boundedStore.LookUp(ctx, key1, source2)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key1, source2, "content1-2"), content)
+
+		assert.NoError(t, boundedStore.Delete(ctx, key1, source1))
+		sources, err = boundedStore.ListSourcesByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+		assert.NoError(t, boundedStore.DeleteByKey(ctx, key1))
+		content, err = boundedStore.LookUp(ctx, key1, source2)
+		assert.NoError(t, err)
+		assert.Nil(t, content)
+	})
+
+	t.Run("evicts the least recently used key", func(t *testing.T) {
+		evicted := make(map[string][]*event.Message)
+		boundedStore := storage.NewBoundedStore(2).
+			WithOnEviction(func(key string, messages []*event.Message) {
+				evicted[key] = messages
+			})
+
+		assert.NoError(t, boundedStore.Persist(ctx, "key1", source1, "content1"))
+		assert.NoError(t, boundedStore.Persist(ctx, "key2", source1, "content2"))
+		_, err := boundedStore.LookUp(ctx, "key1", source1) // key2 becomes the least recently used
+		assert.NoError(t, err)
+		assert.NoError(t, boundedStore.Persist(ctx, "key3", source1, "content3"))
+
+		assert.Equal(t, map[string][]*event.Message{
+			"key2": {event.NewMessage("key2", source1, "content2")},
+		}, evicted)
+		content, err := boundedStore.LookUp(ctx, "key2", source1)
+		assert.NoError(t, err)
+		assert.Nil(t, content)
+
+		stats := boundedStore.Stats()
+		assert.Equal(t, storage.BoundedStoreStats{Hits: 1, Misses: 1, Evictions: 1, Keys: 2}, stats)
+	})
+
+	t.Run("evicts the least frequently used key", func(t *testing.T) {
+		evictedKeys := make([]string, 0)
+		boundedStore := storage.NewBoundedStore(2).
+			WithEvictionPolicy(storage.LeastFrequentlyUsed()).
+			WithOnEviction(func(key string, _ []*event.Message) {
+				evictedKeys = append(evictedKeys, key)
+			})
+
+		assert.NoError(t, boundedStore.Persist(ctx, "key1", source1, "content1"))
+		assert.NoError(t, boundedStore.Persist(ctx, "key2", source1, "content2"))
+		for i := 0; i < 3; i++ {
+			_, err := boundedStore.LookUpByKey(ctx, "key1")
+			assert.NoError(t, err)
+		}
+		_, err := boundedStore.LookUpByKey(ctx, "key2")
+		assert.NoError(t, err)
+		assert.NoError(t, boundedStore.Persist(ctx, "key3", source1, "content3")) // evicts key2
+		assert.NoError(t, boundedStore.Persist(ctx, "key4", source1, "content4")) // evicts key3
+
+		assert.Equal(t, []string{"key2", "key3"}, evictedKeys)
+		sources, err := boundedStore.ListSourcesByKey(ctx, "key1")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1}, sources)
+	})
+
+	t.Run("replacing the eviction policy keeps the store bounded", func(t *testing.T) {
+		evictedKeys := make([]string, 0)
+		boundedStore := storage.NewBoundedStore(2).
+			WithOnEviction(func(key string, _ []*event.Message) {
+				evictedKeys = append(evictedKeys, key)
+			})
+		assert.NoError(t, boundedStore.Persist(ctx, "key2", source1, "content2"))
+		assert.NoError(t, boundedStore.Persist(ctx, "key1", source1, "content1"))
+
+		boundedStore.WithEvictionPolicy(storage.LeastFrequentlyUsed())
+		assert.NoError(t, boundedStore.Persist(ctx, "key3", source1, "content3")) // evicts key1, the first one touched
+		assert.NoError(t, boundedStore.Persist(ctx, "key4", source1, "content4")) // evicts key2
+
+		assert.Equal(t, []string{"key1", "key2"}, evictedKeys)
+		assert.Equal(t, 2, boundedStore.Stats().Keys)
+	})
+
+	t.Run("persisting to an existing key doesn't evict", func(t *testing.T) {
+		boundedStore := storage.NewBoundedStore(1).
+			WithOnEviction(func(key string, _ []*event.Message) {
+				assert.Fail(t, "unexpected eviction", key)
+			})
+
+		assert.NoError(t, boundedStore.Persist(ctx, key1, source1, "content1-1"))
+		assert.NoError(t, boundedStore.Persist(ctx, key1, source2, "content1-2"))
+		assert.Equal(t, 1, boundedStore.Stats().Keys)
+	})
+
+	t.Run("callback can access the store", func(t *testing.T) {
+		boundedStore := storage.NewBoundedStore(1)
+		boundedStore.WithOnEviction(func(key string, messages []*event.Message) {
+			_, err := boundedStore.LookUpByKey(ctx, key)
+			assert.NoError(t, err)
+		})
+
+		assert.NoError(t, boundedStore.Persist(ctx, key1, source1, "content1-1"))
+		assert.NoError(t, boundedStore.Persist(ctx, key2, source1, "content2-1"))
+		assert.Equal(t, uint64(1), boundedStore.Stats().Evictions)
+	})
+
+	t.Run("list the keys", func(t *testing.T) {
+		evictedKeys := make([]string, 0)
+		boundedStore := storage.NewBoundedStore(2).
+			WithOnEviction(func(key string, _ []*event.Message) {
+				evictedKeys = append(evictedKeys, key)
+			})
+		assert.NoError(t, boundedStore.Persist(ctx, "order-2", source1, "content2"))
+		assert.NoError(t, boundedStore.Persist(ctx, "order-1", source1, "content1"))
+		assert.NoError(t, boundedStore.Persist(ctx, "payment-1", source1, "content3")) // evicts order-2
+
+		keys, cursor, err := boundedStore.ListKeys(ctx, "", "", 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order-1"}, keys)
+		assert.Equal(t, "order-1", cursor)
+		keys, cursor, err = boundedStore.ListKeys(ctx, "", cursor, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"payment-1"}, keys)
+		assert.Empty(t, cursor)
+		keys, _, err = boundedStore.ListKeys(ctx, "order-", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order-1"}, keys)
+		assert.Equal(t, []string{"order-2"}, evictedKeys)
+	})
+
+	t.Run("persist and look up by key", func(t *testing.T) {
+		var atomicStore storage.AtomicEventStore = storage.NewBoundedStore(1)
+
+		messages, err := atomicStore.PersistAndLookUpByKey(ctx, key1, source1, "content1-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage(key1, source1, "content1-1")}, messages)
+		messages, err = atomicStore.PersistAndLookUpByKey(ctx, key1, source2, "content1-2")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key1, source1, "content1-1"),
+			event.NewMessage(key1, source2, "content1-2")},
+			messages)
+		// evicts key1
+		messages, err = atomicStore.PersistAndLookUpByKey(ctx, key2, source1, "content2-1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage(key2, source1, "content2-1")}, messages)
+	})
+}
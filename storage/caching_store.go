@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/honestbank/event-driver/event"
+)
+
+const defaultCachingStoreCapacity = 1024
+
+// CachingStore decorates an EventStore with an in-memory view of the contents of recently looked-up keys,
+// so that repeated look-ups of hot keys don't go to the wrapped store until the view expires after the TTL.
+// ListSourcesByKey and LookUpByKey read through the view, while LookUp only uses the view if the key is already cached.
+// Writes always go through to the wrapped store, and invalidate the view of the key afterwards,
+// or update it with WithWriteThrough. Hence it doesn't help the handlers that look up a key right after writing to it,
+// e.g. the joiner, unless write-through is correct for the wrapped store.
+// The view only knows about the writes made through the same CachingStore, so it's correct in single-replica
+// deployments, while the writes of other replicas are only seen once the view expires.
+// The optional capabilities of the wrapped store, e.g. AtomicEventStore, aren't exposed,
+// since the view isn't updated atomically with the wrapped store, except for ListKeys which doesn't use the view.
+type CachingStore struct {
+	eventStore     EventStore
+	ttl            time.Duration
+	lock           sync.Mutex
+	capacity       int
+	isWriteThrough bool
+	views          map[string]cachedView
+	loads          map[string]*cachedLoads
+	evictionPolicy EvictionPolicy
+	stats          CachingStoreStats
+}
+
+// CachingStoreStats counts the look-ups and invalidations of a CachingStore since it's created.
+type CachingStoreStats struct {
+	Hits          uint64 // number of look-ups served by the view
+	Misses        uint64 // number of look-ups that went to the wrapped store
+	Invalidations uint64 // number of times the view of a key was dropped by writes or Invalidate
+	Keys          int    // number of keys currently cached
+}
+
+type cachedView struct {
+	contentBySource map[string]string
+	expiresAt       time.Time
+}
+
+// cachedLoads tracks the look-ups of a key that are in flight, so that a look-up that started before the key was
+// invalidated doesn't cache its stale result.
+type cachedLoads struct {
+	count         int
+	invalidations uint64
+}
+
+// NewCachingStore wraps the EventStore with a view that holds the contents of up to 1024 keys for the TTL.
+func NewCachingStore(eventStore EventStore, ttl time.Duration) *CachingStore {
+	return &CachingStore{
+		eventStore:     eventStore,
+		ttl:            ttl,
+		capacity:       defaultCachingStoreCapacity,
+		views:          make(map[string]cachedView),
+		loads:          make(map[string]*cachedLoads),
+		evictionPolicy: LeastRecentlyUsed(),
+	}
+}
+
+// WithCapacity sets the maximum number of cached keys, beyond which the least recently used key is evicted.
+// A non-positive capacity is treated as 1.
+func (c *CachingStore) WithCapacity(capacity int) *CachingStore {
+	if capacity < 1 {
+		capacity = 1
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.capacity = capacity
+
+	return c
+}
+
+// WithWriteThrough makes Persist update the view of the key with the persisted content, rather than invalidating it.
+// It's only correct if LookUp of the wrapped store returns the last persisted content, e.g. GCSEventStore with
+// TakeLastCreated, but not with TakeFirstCreated.
+func (c *CachingStore) WithWriteThrough() *CachingStore {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.isWriteThrough = true
+
+	return c
+}
+
+func (c *CachingStore) Stats() CachingStoreStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stats := c.stats
+	stats.Keys = len(c.views)
+
+	return stats
+}
+
+// Invalidate drops the view of the key, e.g. when another replica is known to have written to it.
+func (c *CachingStore) Invalidate(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.invalidate(key)
+}
+
+func (c *CachingStore) Delete(ctx context.Context, key, source string) error {
+	defer c.Invalidate(key)
+
+	return c.eventStore.Delete(ctx, key, source)
+}
+
+func (c *CachingStore) DeleteByKey(ctx context.Context, key string) error {
+	defer c.Invalidate(key)
+
+	return c.eventStore.DeleteByKey(ctx, key)
+}
+
+func (c *CachingStore) ListSourcesByKey(ctx context.Context, key string) ([]string, error) {
+	messages, err := c.lookUpByKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]string, 0, len(messages))
+	for _, message := range messages {
+		sources = append(sources, message.GetSource())
+	}
+
+	return sources, nil
+}
+
+// ListKeys lists the keys of the wrapped store, or fails with ErrKeyListingUnsupported if it can't list them.
+func (c *CachingStore) ListKeys(ctx context.Context, prefix, cursor string, limit int) ([]string, string, error) {
+	keyListingStore, isKeyListing := c.eventStore.(KeyListingEventStore)
+	if !isKeyListing {
+		return nil, "", ErrKeyListingUnsupported
+	}
+
+	return keyListingStore.ListKeys(ctx, prefix, cursor, limit)
+}
+
+func (c *CachingStore) LookUp(ctx context.Context, key, source string) (*event.Message, error) {
+	c.lock.Lock()
+	if view, isHit := c.getView(key); isHit {
+		c.stats.Hits++
+		content, isFound := view.contentBySource[source]
+		c.lock.Unlock()
+		if !isFound {
+			return nil, nil
+		}
+
+		return event.NewMessage(key, source, content), nil
+	}
+	c.stats.Misses++
+	c.lock.Unlock()
+
+	return c.eventStore.LookUp(ctx, key, source)
+}
+
+func (c *CachingStore) LookUpByKey(ctx context.Context, key string) ([]*event.Message, error) {
+	return c.lookUpByKey(ctx, key)
+}
+
+func (c *CachingStore) Persist(ctx context.Context, key, source, content string) error {
+	err := c.eventStore.Persist(ctx, key, source, content)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	view, isHit := c.getView(key)
+	if err != nil || !c.isWriteThrough || !isHit {
+		c.invalidate(key)
+
+		return err
+	}
+	// look-ups in flight may have missed the content, so they must not cache their results
+	if loads, isLoading := c.loads[key]; isLoading {
+		loads.invalidations++
+	}
+	view.contentBySource[source] = content
+
+	return nil
+}
+
+// lookUpByKey returns the messages of the key from the view, or from the wrapped store if the key isn't cached,
+// in which case the result is cached unless the key is invalidated in the meantime.
+func (c *CachingStore) lookUpByKey(ctx context.Context, key string) ([]*event.Message, error) {
+	c.lock.Lock()
+	if view, isHit := c.getView(key); isHit {
+		c.stats.Hits++
+		messages := toMessages(key, view.contentBySource)
+		c.lock.Unlock()
+
+		return messages, nil
+	}
+	c.stats.Misses++
+	loads, isLoading := c.loads[key]
+	if !isLoading {
+		loads = &cachedLoads{}
+		c.loads[key] = loads
+	}
+	loads.count++
+	invalidations := loads.invalidations
+	c.lock.Unlock()
+
+	messages, err := c.eventStore.LookUpByKey(ctx, key)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	loads.count--
+	if loads.count == 0 {
+		delete(c.loads, key)
+	}
+	if err == nil && loads.invalidations == invalidations {
+		c.putView(key, messages)
+	}
+
+	return messages, err
+}
+
+// getView returns the unexpired view of the key, and removes the view if it has expired.
+func (c *CachingStore) getView(key string) (cachedView, bool) {
+	view, isHit := c.views[key]
+	if !isHit {
+		return cachedView{}, false
+	}
+	if !time.Now().Before(view.expiresAt) {
+		c.deleteView(key)
+
+		return cachedView{}, false
+	}
+	c.evictionPolicy.Touch(key)
+
+	return view, true
+}
+
+func (c *CachingStore) putView(key string, messages []*event.Message) {
+	if _, isCached := c.views[key]; !isCached && len(c.views) >= c.capacity {
+		if victim, hasVictim := c.evictionPolicy.Victim(); hasVictim {
+			c.deleteView(victim)
+		}
+	}
+	contentBySource := make(map[string]string, len(messages))
+	for _, message := range messages {
+		contentBySource[message.GetSource()] = message.GetContent()
+	}
+	c.views[key] = cachedView{
+		contentBySource: contentBySource,
+		expiresAt:       time.Now().Add(c.ttl),
+	}
+	c.evictionPolicy.Touch(key)
+}
+
+func (c *CachingStore) invalidate(key string) {
+	if loads, isLoading := c.loads[key]; isLoading {
+		loads.invalidations++
+	}
+	if _, isCached := c.views[key]; isCached {
+		c.deleteView(key)
+		c.stats.Invalidations++
+	}
+}
+
+func (c *CachingStore) deleteView(key string) {
+	delete(c.views, key)
+	c.evictionPolicy.Remove(key)
+}
@@ -0,0 +1,209 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+)
+
+func TestCachingStore(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("read through the view", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore()}
+		cachingStore := storage.NewCachingStore(eventStore, time.Minute)
+		assert.NoError(t, eventStore.Persist(ctx, key1, source1, "content1"))
+
+		for i := 0; i < 3; i++ {
+			messages, err := cachingStore.LookUpByKey(ctx, key1)
+			assert.NoError(t, err)
+			assert.Equal(t, []*event.Message{event.NewMessage(key1, source1, "content1")}, messages)
+		}
+		sources, err := cachingStore.ListSourcesByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{source1}, sources)
+		message, err := cachingStore.LookUp(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key1, source1, "content1"), message)
+		message, err = cachingStore.LookUp(ctx, key1, source2)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+		assert.Equal(t, int32(1), eventStore.lookUpByKeys.Load())
+
+		// an uncached key is looked up in the wrapped store without being cached
+		message, err = cachingStore.LookUp(ctx, key2, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+		assert.Equal(t, storage.CachingStoreStats{Hits: 5, Misses: 2, Keys: 1}, cachingStore.Stats())
+	})
+
+	t.Run("invalidate on write", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore()}
+		cachingStore := storage.NewCachingStore(eventStore, time.Minute)
+
+		assert.NoError(t, cachingStore.Persist(ctx, key1, source1, "content1"))
+		_, err := cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.NoError(t, cachingStore.Persist(ctx, key1, source2, "content2"))
+		messages, err := cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key1, source1, "content1"),
+			event.NewMessage(key1, source2, "content2"),
+		}, messages)
+
+		assert.NoError(t, cachingStore.Delete(ctx, key1, source1))
+		sources, err := cachingStore.ListSourcesByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{source2}, sources)
+
+		assert.NoError(t, cachingStore.DeleteByKey(ctx, key1))
+		messages, err = cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Empty(t, messages)
+
+		// writes of other replicas are only seen after Invalidate
+		assert.NoError(t, eventStore.Persist(ctx, key1, source1, "content1"))
+		messages, err = cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Empty(t, messages)
+		cachingStore.Invalidate(key1)
+		messages, err = cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage(key1, source1, "content1")}, messages)
+		assert.Equal(t, int32(5), eventStore.lookUpByKeys.Load())
+		assert.Equal(t, uint64(4), cachingStore.Stats().Invalidations)
+	})
+
+	t.Run("write through", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore()}
+		cachingStore := storage.NewCachingStore(eventStore, time.Minute).WithWriteThrough()
+
+		assert.NoError(t, cachingStore.Persist(ctx, key1, source1, "content1"))
+		_, err := cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.NoError(t, cachingStore.Persist(ctx, key1, source2, "content2"))
+		assert.NoError(t, cachingStore.Persist(ctx, key1, source1, "content1-updated"))
+		messages, err := cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key1, source1, "content1-updated"),
+			event.NewMessage(key1, source2, "content2"),
+		}, messages)
+		assert.Equal(t, int32(1), eventStore.lookUpByKeys.Load())
+
+		// a failed write leaves the wrapped store in an unknown state
+		eventStore.err = errors.New("test")
+		assert.Error(t, cachingStore.Persist(ctx, key1, source1, "content"))
+		assert.Equal(t, 0, cachingStore.Stats().Keys)
+	})
+
+	t.Run("expire after TTL", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore()}
+		cachingStore := storage.NewCachingStore(eventStore, 10*time.Millisecond)
+
+		_, err := cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		_, err = cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), eventStore.lookUpByKeys.Load())
+
+		time.Sleep(20 * time.Millisecond)
+		_, err = cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), eventStore.lookUpByKeys.Load())
+	})
+
+	t.Run("evict the least recently used key", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore()}
+		cachingStore := storage.NewCachingStore(eventStore, time.Minute).WithCapacity(2)
+
+		for _, key := range []string{key1, key2, key1, "key3", key1} {
+			_, err := cachingStore.LookUpByKey(ctx, key)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), eventStore.lookUpByKeys.Load())
+		_, err := cachingStore.LookUpByKey(ctx, key2)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(4), eventStore.lookUpByKeys.Load())
+		assert.Equal(t, 2, cachingStore.Stats().Keys)
+	})
+
+	t.Run("don't cache a look-up that raced with a write", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore()}
+		cachingStore := storage.NewCachingStore(eventStore, time.Minute)
+		eventStore.onLookUpByKey = func() {
+			eventStore.onLookUpByKey = nil
+			assert.NoError(t, cachingStore.Persist(ctx, key1, source1, "content1"))
+		}
+
+		messages, err := cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Empty(t, messages)
+		messages, err = cachingStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage(key1, source1, "content1")}, messages)
+	})
+
+	t.Run("don't cache failures", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore(), err: errors.New("test")}
+		cachingStore := storage.NewCachingStore(eventStore, time.Minute)
+
+		_, err := cachingStore.LookUpByKey(ctx, key1)
+		assert.Error(t, err)
+		_, err = cachingStore.ListSourcesByKey(ctx, key1)
+		assert.Error(t, err)
+		assert.Equal(t, 0, cachingStore.Stats().Keys)
+	})
+
+	t.Run("list the keys of the wrapped store", func(t *testing.T) {
+		eventStore := &countingStore{InMemoryStore: storage.NewInMemoryStore()}
+		cachingStore := storage.NewCachingStore(eventStore, time.Minute)
+		assert.NoError(t, cachingStore.Persist(ctx, key2, source1, "content2"))
+		assert.NoError(t, cachingStore.Persist(ctx, key1, source1, "content1"))
+
+		keys, cursor, err := cachingStore.ListKeys(ctx, "", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{key1, key2}, keys)
+		assert.Empty(t, cursor)
+
+		_, _, err = storage.NewCachingStore(struct{ storage.EventStore }{eventStore}, time.Minute).ListKeys(ctx, "", "", 0)
+		assert.ErrorIs(t, err, storage.ErrKeyListingUnsupported)
+	})
+}
+
+// countingStore counts the LookUpByKey calls, and fails Persist & LookUpByKey with err if it's set.
+type countingStore struct {
+	*storage.InMemoryStore
+	lookUpByKeys  atomic.Int32
+	onLookUpByKey func() // called after looking up in the wrapped store
+	err           error
+}
+
+func (c *countingStore) LookUpByKey(ctx context.Context, key string) ([]*event.Message, error) {
+	c.lookUpByKeys.Add(1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	messages, err := c.InMemoryStore.LookUpByKey(ctx, key)
+	if c.onLookUpByKey != nil {
+		c.onLookUpByKey()
+	}
+
+	return messages, err
+}
+
+func (c *countingStore) Persist(ctx context.Context, key, source, content string) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	return c.InMemoryStore.Persist(ctx, key, source, content)
+}
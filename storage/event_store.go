@@ -2,14 +2,79 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/honestbank/event-driver/event"
 )
 
-// EventStore persists an event by key & source, and looks up an event by key+source, or a collection of events by key.
+// EventStore persists an event by key & source, looks up an event by key+source, or a collection of events by key,
+// and deletes an event by key+source, or a collection of events by key.
 type EventStore interface {
+	Delete(ctx context.Context, key, source string) error
+	DeleteByKey(ctx context.Context, key string) error
 	ListSourcesByKey(ctx context.Context, key string) ([]string, error)
 	LookUp(ctx context.Context, key, source string) (*event.Message, error)
 	LookUpByKey(ctx context.Context, key string) ([]*event.Message, error)
 	Persist(ctx context.Context, key, source, content string) error
 }
+
+// ExpiringEventStore is an EventStore whose contents expire after a TTL.
+// The store-wide TTL is set when configuring the store, and PersistWithTTL overrides it for a single content.
+// Expired contents are never returned by look-ups, even if the store hasn't physically removed them yet.
+type ExpiringEventStore interface {
+	EventStore
+	PersistWithTTL(ctx context.Context, key, source, content string, ttl time.Duration) error
+}
+
+// Version identifies a revision of the content of a key-source pair, which changes on every write.
+// NoVersion stands for the absence of the content.
+type Version int64
+
+const NoVersion Version = 0
+
+// ConditionalEventStore is an EventStore that supports conditional writes, so that concurrent writers on the same
+// key-source pair can't overwrite each other, e.g. replicas of a pipeline that consume the same events.
+type ConditionalEventStore interface {
+	EventStore
+	// LookUpVersion returns the message with its current version, or nil and NoVersion if there is no content.
+	LookUpVersion(ctx context.Context, key, source string) (*event.Message, Version, error)
+	// PersistIfAbsent persists the content only if there is no content yet, and reports whether it's persisted.
+	PersistIfAbsent(ctx context.Context, key, source, content string) (bool, error)
+	// CompareAndPersist persists the content only if the current version is still the given one,
+	// and reports whether it's persisted. CompareAndPersist with NoVersion is equivalent to PersistIfAbsent.
+	CompareAndPersist(ctx context.Context, key, source, content string, version Version) (bool, error)
+}
+
+// AtomicEventStore is an EventStore that persists a content and looks up all the contents of the key as a single
+// atomic operation, so that concurrent writers on the same key see each other's content in a consistent order,
+// i.e. the last one of them always sees the contents of all the others.
+type AtomicEventStore interface {
+	EventStore
+	// PersistAndLookUpByKey persists the content, and returns the messages of the key including the persisted one.
+	PersistAndLookUpByKey(ctx context.Context, key, source, content string) ([]*event.Message, error)
+}
+
+// Revision describes one of the contents persisted for a key-source pair over time.
+type Revision struct {
+	ID        string // identifies the revision among the revisions of the same key-source pair
+	CreatedAt time.Time
+}
+
+// HistoryEventStore is an EventStore that keeps the history of the contents of each key-source pair,
+// rather than only the one returned by LookUp.
+type HistoryEventStore interface {
+	EventStore
+	// ListRevisions returns the unexpired revisions of the key-source pair, from the oldest to the newest.
+	ListRevisions(ctx context.Context, key, source string) ([]Revision, error)
+	// LookUpRevision returns the message of the given revision, or nil if the revision doesn't exist (anymore).
+	LookUpRevision(ctx context.Context, key, source, id string) (*event.Message, error)
+}
+
+// KeyListingEventStore is an EventStore that enumerates its keys page by page, e.g. to migrate or audit the store.
+type KeyListingEventStore interface {
+	EventStore
+	// ListKeys returns up to limit keys starting with the prefix, from the cursor returned with the previous page,
+	// or from the first key if the cursor is empty. The keys are returned in a stable order, and the returned cursor
+	// is empty after the last page. A limit <= 0 returns all the remaining keys at once.
+	ListKeys(ctx context.Context, prefix, cursor string, limit int) (keys []string, nextCursor string, err error)
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"container/heap"
+	"container/list"
+)
+
+// EvictionPolicy tracks the usage of keys and decides which key to evict when a BoundedStore is full.
+// An EvictionPolicy is stateful, so each BoundedStore needs its own instance.
+// BoundedStore calls the policy while holding its lock, so implementations don't need to be thread-safe.
+type EvictionPolicy interface {
+	// Touch records that the key was written or read.
+	Touch(key string)
+	// Remove forgets the key, e.g. when it was deleted from the store.
+	Remove(key string)
+	// Victim returns the key to evict next, or false if no key is tracked.
+	Victim() (string, bool)
+}
+
+// leastRecentlyUsed evicts the key that hasn't been touched for the longest time.
+type leastRecentlyUsed struct {
+	order    *list.List // front is the most recently used
+	elements map[string]*list.Element
+}
+
+func LeastRecentlyUsed() EvictionPolicy {
+	return &leastRecentlyUsed{
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (l *leastRecentlyUsed) Touch(key string) {
+	if element, isTracked := l.elements[key]; isTracked {
+		l.order.MoveToFront(element)
+
+		return
+	}
+	l.elements[key] = l.order.PushFront(key)
+}
+
+func (l *leastRecentlyUsed) Remove(key string) {
+	if element, isTracked := l.elements[key]; isTracked {
+		l.order.Remove(element)
+		delete(l.elements, key)
+	}
+}
+
+func (l *leastRecentlyUsed) Victim() (string, bool) {
+	element := l.order.Back()
+	if element == nil {
+		return "", false
+	}
+
+	return element.Value.(string), true
+}
+
+// leastFrequentlyUsed evicts the key that has been touched the fewest times,
+// and the least recently added key among the ones with the same frequency.
+type leastFrequentlyUsed struct {
+	items   lfuHeap
+	byKey   map[string]*lfuItem
+	counter uint64
+}
+
+type lfuItem struct {
+	key       string
+	frequency uint64
+	sequence  uint64 // the order of being added, to break ties between keys of the same frequency
+	index     int
+}
+
+func LeastFrequentlyUsed() EvictionPolicy {
+	return &leastFrequentlyUsed{
+		items: make(lfuHeap, 0),
+		byKey: make(map[string]*lfuItem),
+	}
+}
+
+func (l *leastFrequentlyUsed) Touch(key string) {
+	if item, isTracked := l.byKey[key]; isTracked {
+		item.frequency++
+		heap.Fix(&l.items, item.index)
+
+		return
+	}
+	l.counter++
+	item := &lfuItem{key: key, frequency: 1, sequence: l.counter}
+	l.byKey[key] = item
+	heap.Push(&l.items, item)
+}
+
+func (l *leastFrequentlyUsed) Remove(key string) {
+	if item, isTracked := l.byKey[key]; isTracked {
+		heap.Remove(&l.items, item.index)
+		delete(l.byKey, key)
+	}
+}
+
+func (l *leastFrequentlyUsed) Victim() (string, bool) {
+	if len(l.items) == 0 {
+		return "", false
+	}
+
+	return l.items[0].key, true
+}
+
+// lfuHeap implements heap.Interface, with the least frequently used item on the top.
+type lfuHeap []*lfuItem
+
+func (h lfuHeap) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].frequency != h[j].frequency {
+		return h[i].frequency < h[j].frequency
+	}
+
+	return h[i].sequence < h[j].sequence
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x any) {
+	item := x.(*lfuItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+
+	return item
+}
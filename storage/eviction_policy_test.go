@@ -0,0 +1,51 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/storage"
+)
+
+func TestLeastRecentlyUsed(t *testing.T) {
+	policy := storage.LeastRecentlyUsed()
+	_, hasVictim := policy.Victim()
+	assert.False(t, hasVictim)
+
+	policy.Touch("a")
+	policy.Touch("b")
+	policy.Touch("c")
+	policy.Touch("a")
+	victim, hasVictim := policy.Victim()
+	assert.True(t, hasVictim)
+	assert.Equal(t, "b", victim)
+
+	policy.Remove("b")
+	policy.Remove("unknown")
+	victim, _ = policy.Victim()
+	assert.Equal(t, "c", victim)
+}
+
+func TestLeastFrequentlyUsed(t *testing.T) {
+	policy := storage.LeastFrequentlyUsed()
+	_, hasVictim := policy.Victim()
+	assert.False(t, hasVictim)
+
+	policy.Touch("a")
+	policy.Touch("a")
+	policy.Touch("b")
+	policy.Touch("c")
+	victim, hasVictim := policy.Victim()
+	assert.True(t, hasVictim)
+	assert.Equal(t, "b", victim) // b & c are equally frequent, b is added earlier
+
+	policy.Touch("b")
+	victim, _ = policy.Victim()
+	assert.Equal(t, "c", victim)
+
+	policy.Remove("c")
+	policy.Remove("unknown")
+	victim, _ = policy.Victim()
+	assert.Equal(t, "a", victim) // a & b are equally frequent, a is added earlier
+}
@@ -0,0 +1,17 @@
+package storage
+
+// RecordCount returns the number of key-source records held by the store, including the expired ones
+// that haven't been removed yet.
+func (i *InMemoryStore) RecordCount() int {
+	count := 0
+	for index := range i.shards {
+		shard := &i.shards[index]
+		shard.lock.RLock()
+		for _, records := range shard.records {
+			count += len(records)
+		}
+		shard.lock.RUnlock()
+	}
+
+	return count
+}
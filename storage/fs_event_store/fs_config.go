@@ -0,0 +1,89 @@
+package fs_event_store
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/honestbank/event-driver/storage/object_event_store"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+// FSConfig is the configuration of an FSEventStore, i.e. the root directory and the configuration of
+// object_event_store.
+type FSConfig struct {
+	Root string
+	object_event_store.ObjectConfig
+}
+
+// Config creates a default configuration, that
+// - stores the files under the given root directory
+// - doesn't do compression/decompression when write & read the files
+// - takes the earliest created file if there are multiple under the same key/source/ path
+// - reads up to 8 sources concurrently in LookUpByKey
+func Config(root string) *FSConfig {
+	return &FSConfig{
+		Root:         root,
+		ObjectConfig: *object_event_store.Config(),
+	}
+}
+
+func (c *FSConfig) WithCompressor(compressor compression.Compressor) *FSConfig {
+	c.ObjectConfig.WithCompressor(compressor)
+
+	return c
+}
+
+// WithConcurrency sets the maximum number of sources read concurrently by LookUpByKey, where 1 reads them sequentially.
+func (c *FSConfig) WithConcurrency(concurrency int) *FSConfig {
+	c.ObjectConfig.WithConcurrency(concurrency)
+
+	return c
+}
+
+func (c *FSConfig) WithFolder(folder string) *FSConfig {
+	c.ObjectConfig.WithFolder(folder)
+
+	return c
+}
+
+// WithKeyLayout sets how the keys & sources are mapped to the paths of the files, see KeyLayout.
+func (c *FSConfig) WithKeyLayout(keyLayout KeyLayout) *FSConfig {
+	c.ObjectConfig.WithKeyLayout(keyLayout)
+
+	return c
+}
+
+// WithLogger sets the logger of the failures that don't fail the operations, e.g. of CollectGarbage.
+func (c *FSConfig) WithLogger(logger *slog.Logger) *FSConfig {
+	c.ObjectConfig.WithLogger(logger)
+
+	return c
+}
+
+// WithMetadata sets the metadata of each written file from its key, source and content,
+// e.g. the sequence number of the event for TakeHighestSequence.
+func (c *FSConfig) WithMetadata(metadata func(key, source, content string) map[string]string) *FSConfig {
+	c.ObjectConfig.WithMetadata(metadata)
+
+	return c
+}
+
+func (c *FSConfig) WithReadPolicy(readPolicy ReadPolicy) *FSConfig {
+	c.ObjectConfig.WithReadPolicy(readPolicy)
+
+	return c
+}
+
+// WithTTL makes the contents expire after the given TTL, by keeping the expiry time in the header of each file.
+// Expired files are skipped on read, but only deleted by Delete, DeleteByKey or a CollectGarbage read policy.
+func (c *FSConfig) WithTTL(ttl time.Duration) *FSConfig {
+	c.ObjectConfig.WithTTL(ttl)
+
+	return c
+}
+
+func (c *FSConfig) WithTimeout(timeout Timeout) *FSConfig {
+	c.ObjectConfig.WithTimeout(timeout)
+
+	return c
+}
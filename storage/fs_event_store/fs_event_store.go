@@ -0,0 +1,68 @@
+package fs_event_store
+
+import (
+	"errors"
+	"os"
+
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+// FSEventStore persists the contents as files on the local filesystem, following the same `folder/key/source/sha256`
+// layout (see KeyLayout) as GCSEventStore. The layout, the ReadPolicy, TTLs, conditional writes, revisions and key
+// listing are those of object_event_store, applied on top of a directory (see DirectoryObjectStore).
+type FSEventStore = object_event_store.ConditionalObjectEventStore
+
+// The types of object_event_store that FSConfig and FSEventStore are made of.
+type (
+	ConditionalObjectStore = object_event_store.ConditionalObjectStore
+	Conditions             = object_event_store.Conditions
+	KeyEncoding            = object_event_store.KeyEncoding
+	KeyLayout              = object_event_store.KeyLayout
+	MergingReadPolicy      = object_event_store.MergingReadPolicy
+	ObjectAttrs            = object_event_store.ObjectAttrs
+	ObjectIterator         = object_event_store.ObjectIterator
+	Operation              = object_event_store.Operation
+	PartialLookUpError     = object_event_store.PartialLookUpError
+	Query                  = object_event_store.Query
+	ReadPolicy             = object_event_store.ReadPolicy
+	Timeout                = object_event_store.Timeout
+)
+
+const (
+	ListContents  = object_event_store.ListContents
+	ReadContent   = object_event_store.ReadContent
+	WriteContent  = object_event_store.WriteContent
+	DeleteContent = object_event_store.DeleteContent
+)
+
+var (
+	ErrGarbageCollectingMerge = object_event_store.ErrGarbageCollectingMerge
+	ErrMultipleObjects        = object_event_store.ErrMultipleObjects
+)
+
+// The key encodings and read policies of object_event_store.
+var (
+	RawKeyEncoding      = object_event_store.RawKeyEncoding
+	EscapedKeyEncoding  = object_event_store.EscapedKeyEncoding
+	TakeFirstCreated    = object_event_store.TakeFirstCreated
+	TakeLastCreated     = object_event_store.TakeLastCreated
+	FailOnMultiple      = object_event_store.FailOnMultiple
+	TakeByMetadata      = object_event_store.TakeByMetadata
+	TakeHighestSequence = object_event_store.TakeHighestSequence
+	MergeConcatenated   = object_event_store.MergeConcatenated
+	MergeAsJSONArray    = object_event_store.MergeAsJSONArray
+	CollectGarbage      = object_event_store.CollectGarbage
+)
+
+// New creates an FSEventStore on the root directory of the config, creating the directory if it doesn't exist.
+func New(cfg *FSConfig) (storage.EventStore, error) {
+	if cfg == nil {
+		return nil, errors.New("fs config cannot be null")
+	}
+	if err := os.MkdirAll(cfg.Root, 0o750); err != nil {
+		return nil, err
+	}
+
+	return object_event_store.New(&cfg.ObjectConfig, DirectoryObjectStore(cfg.Root))
+}
@@ -0,0 +1,344 @@
+package fs_event_store_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/fs_event_store"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+const (
+	key     = "key"
+	source1 = "source1"
+	source2 = "source2"
+	content = "content"
+)
+
+func TestFSEventStore(t *testing.T) {
+	folderName := "folder-name"
+
+	t.Run("query empty directory", func(t *testing.T) {
+		config := fs_event_store.Config(t.TempDir()).WithFolder(folderName)
+		eventStore, err := fs_event_store.New(config)
+		assert.NoError(t, err)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, messageArray)
+	})
+
+	t.Run("with folder prefix", func(t *testing.T) {
+		root := t.TempDir()
+		config := fs_event_store.Config(root).WithFolder(folderName)
+		eventStore, err := fs_event_store.New(config)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		files, err := os.ReadDir(filepath.Join(root, folderName, key, source1))
+		assert.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, content),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+	})
+
+	t.Run("without folder prefix", func(t *testing.T) {
+		root := t.TempDir()
+		eventStore, err := fs_event_store.New(fs_event_store.Config(root))
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+
+		_, err = os.Stat(filepath.Join(root, key, source1))
+		assert.NoError(t, err)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+	})
+
+	t.Run("take last created", func(t *testing.T) {
+		config := fs_event_store.Config(t.TempDir()).
+			WithFolder(folderName).
+			WithReadPolicy(fs_event_store.TakeLastCreated())
+		eventStore, err := fs_event_store.New(config)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "something else"), message)
+
+		messageArray, err := eventStore.LookUpByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage(key, source1, "something else"),
+			event.NewMessage(key, source2, content)},
+			messageArray)
+	})
+
+	t.Run("order the files by their creation time in nanoseconds", func(t *testing.T) {
+		root := t.TempDir()
+		firstCreatedStore, err := fs_event_store.New(fs_event_store.Config(root))
+		assert.NoError(t, err)
+		lastCreatedStore, err := fs_event_store.New(fs_event_store.Config(root).
+			WithReadPolicy(fs_event_store.TakeLastCreated()))
+		assert.NoError(t, err)
+
+		for index := 0; index < 20; index++ {
+			assert.NoError(t, firstCreatedStore.Persist(context.TODO(), key, source1, fmt.Sprintf("content%d", index)))
+		}
+		files, err := os.ReadDir(filepath.Join(root, key, source1))
+		assert.NoError(t, err)
+		assert.Len(t, files, 20)
+		assert.Regexp(t, `^[\w=-]{44}$`, files[0].Name())
+
+		message, err := firstCreatedStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "content0"), message)
+		message, err = lastCreatedStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "content19"), message)
+
+		// persisting the same content again replaces its file with a newer one
+		assert.NoError(t, firstCreatedStore.Persist(context.TODO(), key, source1, "content0"))
+		files, err = os.ReadDir(filepath.Join(root, key, source1))
+		assert.NoError(t, err)
+		assert.Len(t, files, 20)
+		message, err = lastCreatedStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "content0"), message)
+		message, err = firstCreatedStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "content1"), message)
+	})
+
+	t.Run("with compression", func(t *testing.T) {
+		config := fs_event_store.Config(t.TempDir()).WithCompressor(compression.Gzip(gzip.BestSpeed))
+		eventStore, err := fs_event_store.New(config)
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+	})
+
+	t.Run("persist the same content concurrently", func(t *testing.T) {
+		eventStore, err := fs_event_store.New(fs_event_store.Config(t.TempDir()))
+		assert.NoError(t, err)
+
+		for trial := 0; trial < 50; trial++ {
+			source := fmt.Sprintf("source%d", trial)
+			var waitGroup sync.WaitGroup
+			for writer := 0; writer < 4; writer++ {
+				waitGroup.Add(1)
+				go func() {
+					defer waitGroup.Done()
+					assert.NoError(t, eventStore.Persist(context.TODO(), key, source, content))
+				}()
+			}
+			waitGroup.Wait()
+
+			message, err := eventStore.LookUp(context.TODO(), key, source)
+			assert.NoError(t, err)
+			assert.Equal(t, event.NewMessage(key, source, content), message)
+		}
+	})
+
+	t.Run("with TTL", func(t *testing.T) {
+		eventStore, err := fs_event_store.New(fs_event_store.Config(t.TempDir()).WithTTL(time.Hour))
+		assert.NoError(t, err)
+		expiringEventStore, isExpiring := eventStore.(storage.ExpiringEventStore)
+		assert.True(t, isExpiring)
+
+		assert.NoError(t, expiringEventStore.Persist(context.TODO(), key, source1, content))
+		assert.NoError(t, expiringEventStore.PersistWithTTL(context.TODO(), key, source2, content, -time.Second))
+
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{source1}, sources)
+		message, err := eventStore.LookUp(context.TODO(), key, source2)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+	})
+
+	t.Run("conditional writes", func(t *testing.T) {
+		eventStore, err := fs_event_store.New(fs_event_store.Config(t.TempDir()))
+		assert.NoError(t, err)
+		conditionalEventStore, isConditional := eventStore.(storage.ConditionalEventStore)
+		assert.True(t, isConditional)
+
+		isPersisted, err := conditionalEventStore.PersistIfAbsent(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		isPersisted, err = conditionalEventStore.PersistIfAbsent(context.TODO(), key, source1, "something else")
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		message, version, err := conditionalEventStore.LookUpVersion(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(context.TODO(), key, source1, "updated", version)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(context.TODO(), key, source1, "stale", version)
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		message, err = conditionalEventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "updated"), message)
+	})
+
+	t.Run("list the keys", func(t *testing.T) {
+		eventStore, err := fs_event_store.New(fs_event_store.Config(t.TempDir()).
+			WithFolder(folderName).
+			WithKeyLayout(fs_event_store.KeyLayout{ShardPrefixSize: 2}))
+		assert.NoError(t, err)
+		keyListingEventStore, isKeyListing := eventStore.(storage.KeyListingEventStore)
+		assert.True(t, isKeyListing)
+
+		for _, key := range []string{"key3", "key1", "other", "key2"} {
+			assert.NoError(t, eventStore.Persist(context.TODO(), key, source1, content))
+			assert.NoError(t, eventStore.Persist(context.TODO(), key, source2, content))
+		}
+		assert.NoError(t, eventStore.DeleteByKey(context.TODO(), "key2"))
+
+		keys, cursor, err := keyListingEventStore.ListKeys(context.TODO(), "key", "", 1)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+		assert.NotEmpty(t, cursor)
+		moreKeys, cursor, err := keyListingEventStore.ListKeys(context.TODO(), "key", cursor, 0)
+		assert.NoError(t, err)
+		assert.Empty(t, cursor)
+		assert.ElementsMatch(t, []string{"key1", "key3"}, append(keys, moreKeys...))
+	})
+
+	t.Run("history", func(t *testing.T) {
+		eventStore, err := fs_event_store.New(fs_event_store.Config(t.TempDir()))
+		assert.NoError(t, err)
+		historyEventStore, isHistory := eventStore.(storage.HistoryEventStore)
+		assert.True(t, isHistory)
+
+		assert.NoError(t, eventStore.Persist(context.TODO(), key, source1, content))
+		assert.NoError(t, eventStore.Persist(context.TODO(), key, source1, "something else"))
+		revisions, err := historyEventStore.ListRevisions(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		message, err := historyEventStore.LookUpRevision(context.TODO(), key, source1, revisions[1].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, "something else"), message)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		eventStore, err := fs_event_store.New(fs_event_store.Config(t.TempDir()))
+		assert.NoError(t, err)
+
+		err = eventStore.Persist(context.TODO(), key, source1, content)
+		assert.NoError(t, err)
+		err = eventStore.Persist(context.TODO(), key, source2, content)
+		assert.NoError(t, err)
+
+		err = eventStore.Delete(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		sources, err := eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source2}, sources)
+
+		err = eventStore.DeleteByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		sources, err = eventStore.ListSourcesByKey(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+	})
+
+	t.Run("ignore unfinished writes", func(t *testing.T) {
+		root := t.TempDir()
+		eventStore, err := fs_event_store.New(fs_event_store.Config(root))
+		assert.NoError(t, err)
+
+		// simulate a crash in the middle of writing
+		err = os.MkdirAll(filepath.Join(root, key, source1), 0o750)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(root, key, source1, ".tmp-123"), []byte("partial"), 0o600)
+		assert.NoError(t, err)
+
+		message, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+	})
+
+	t.Run("reject invalid path components", func(t *testing.T) {
+		eventStore, err := fs_event_store.New(fs_event_store.Config(t.TempDir()))
+		assert.NoError(t, err)
+
+		for _, invalid := range []string{"", ".", "..", `a\b`, ".tmp-1"} {
+			err = eventStore.Persist(context.TODO(), invalid, source1, content)
+			assert.Error(t, err)
+			err = eventStore.Persist(context.TODO(), key, invalid, content)
+			assert.Error(t, err)
+			_, err = eventStore.LookUp(context.TODO(), key, invalid)
+			assert.Error(t, err)
+			_, err = eventStore.ListSourcesByKey(context.TODO(), invalid)
+			assert.Error(t, err)
+			_, err = eventStore.LookUpByKey(context.TODO(), invalid)
+			assert.Error(t, err)
+			err = eventStore.Delete(context.TODO(), key, invalid)
+			assert.Error(t, err)
+			err = eventStore.DeleteByKey(context.TODO(), invalid)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		_, err := fs_event_store.New(nil)
+		assert.Error(t, err)
+	})
+}
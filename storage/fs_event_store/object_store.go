@@ -0,0 +1,394 @@
+package fs_event_store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+// tempFilePrefix starts the names of the files being written, which are renamed to the files of their objects
+// once written, and skipped until then.
+const tempFilePrefix = ".tmp-"
+
+// createTempAttempts is the number of attempts to create a temporary file in a directory that a concurrent Delete
+// may remove once it's empty.
+const createTempAttempts = 3
+
+// lastCreatedAt is the creation time of the last file written by this process, so that the files it writes have
+// strictly increasing creation times even if the clock doesn't move between two writes.
+var lastCreatedAt atomic.Int64
+
+// conditionalWrites serializes the conditional writes of this process, see DirectoryObjectStore.
+var conditionalWrites sync.Mutex
+
+// directoryObjectStore implements ObjectStore on a directory of the local filesystem, where the object `a/b/c` is
+// the file `root/a/b/c`. A file starts with a line of the attributes of its object in JSON, i.e. its creation time
+// in nanoseconds, which is also its generation, its expiry time and its metadata, followed by its content.
+// Files are written to a temporary file first and then renamed, so a crash never leaves a partially written file,
+// and a write of the same object by another writer is replaced as a whole.
+type directoryObjectStore struct {
+	root string
+}
+
+// fileHeader is the first line of a file, which holds the attributes of its object.
+type fileHeader struct {
+	Created   int64             `json:"created"`             // in nanoseconds since the Unix epoch
+	ExpiresAt int64             `json:"expiresAt,omitempty"` // in nanoseconds since the Unix epoch, never if zero
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// DirectoryObjectStore returns the ConditionalObjectStore on the root directory.
+// Conditional writes are only atomic among the writers of this process, since the files are replaced by renames
+// that can't check the generation of the replaced file, so a directory shared by several processes must be written
+// conditionally by one of them only.
+// Deleting the last file of a directory removes the directory, and its parents while they are empty,
+// so that the keys & sources without contents aren't listed.
+func DirectoryObjectStore(root string) ConditionalObjectStore {
+	return &directoryObjectStore{
+		root: filepath.Clean(root),
+	}
+}
+
+func (d *directoryObjectStore) List(_ context.Context, query *Query) ObjectIterator {
+	objects, err := d.list(query)
+
+	return &fileObjects{
+		objects: objects,
+		err:     err,
+	}
+}
+
+func (d *directoryObjectStore) Read(_ context.Context, name string) (io.ReadCloser, error) {
+	path, err := d.filePath(name)
+	if err != nil {
+		return nil, err
+	}
+	file, reader, _, err := openFile(path, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{Reader: reader, Closer: file}, nil
+}
+
+func (d *directoryObjectStore) Write(_ context.Context, attrs *ObjectAttrs, content []byte) error {
+	path, err := d.filePath(attrs.Name)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, attrs, content)
+}
+
+func (d *directoryObjectStore) WriteIf(
+	ctx context.Context,
+	attrs *ObjectAttrs,
+	content []byte,
+	conditions Conditions) error {
+	conditionalWrites.Lock()
+	defer conditionalWrites.Unlock()
+
+	object, err := d.Attrs(ctx, attrs.Name)
+	if err != nil && !errors.Is(err, object_event_store.ErrObjectNotExist) {
+		return err
+	}
+	isFound := err == nil
+	if conditions.DoesNotExist && isFound {
+		return object_event_store.ErrPreconditionFailed
+	}
+	if conditions.GenerationMatch != 0 && (!isFound || object.Generation != conditions.GenerationMatch) {
+		return object_event_store.ErrPreconditionFailed
+	}
+
+	return d.Write(ctx, attrs, content)
+}
+
+func (d *directoryObjectStore) Delete(_ context.Context, name string) error {
+	path, err := d.filePath(name)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return object_event_store.ErrObjectNotExist
+	}
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return object_event_store.ErrObjectNotExist
+	}
+	if err != nil {
+		return err
+	}
+	d.removeEmptyDirectories(filepath.Dir(path))
+
+	return nil
+}
+
+func (d *directoryObjectStore) Attrs(_ context.Context, name string) (*ObjectAttrs, error) {
+	path, err := d.filePath(name)
+	if err != nil {
+		return nil, err
+	}
+	file, _, attrs, err := openFile(path, name)
+	if err != nil {
+		return nil, err
+	}
+	_ = file.Close()
+
+	return attrs, nil
+}
+
+// list walks the directory of query.Prefix, reading the headers of the listed files only,
+// and skipping the directories whose files are all collapsed into a listed prefix or before query.StartOffset.
+func (d *directoryObjectStore) list(query *Query) ([]*ObjectAttrs, error) {
+	var prefix, delimiter, startOffset string
+	if query != nil {
+		prefix, delimiter, startOffset = query.Prefix, query.Delimiter, query.StartOffset
+	}
+	directory := d.root
+	if parent := prefix[:strings.LastIndex(prefix, "/")+1]; parent != "" {
+		var err error
+		if directory, err = d.filePath(strings.TrimSuffix(parent, "/")); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*ObjectAttrs, 0)
+	isPrefixListed := make(map[string]bool)
+	// collapse returns the prefix that the name is collapsed into by the delimiter, if any
+	collapse := func(name string) string {
+		if delimiter == "" || !strings.HasPrefix(name, prefix) {
+			return ""
+		}
+		index := strings.Index(name[len(prefix):], delimiter)
+		if index < 0 {
+			return ""
+		}
+
+		return name[:len(prefix)+index+len(delimiter)]
+	}
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) { // removed while walking
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if path == directory {
+			return nil
+		}
+		relativePath, err := filepath.Rel(d.root, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relativePath)
+		if entry.IsDir() {
+			directoryPrefix := name + "/"
+			isUnderPrefix := strings.HasPrefix(directoryPrefix, prefix) || strings.HasPrefix(prefix, directoryPrefix)
+			isBeforeStart := directoryPrefix < startOffset && !strings.HasPrefix(startOffset, directoryPrefix)
+			if !isUnderPrefix || isBeforeStart || isPrefixListed[collapse(directoryPrefix)] {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), tempFilePrefix) || !strings.HasPrefix(name, prefix) || name < startOffset {
+			return nil
+		}
+		if subPrefix := collapse(name); subPrefix != "" {
+			if !isPrefixListed[subPrefix] {
+				isPrefixListed[subPrefix] = true
+				results = append(results, &ObjectAttrs{Prefix: subPrefix})
+			}
+
+			return nil
+		}
+		file, _, attrs, err := openFile(path, name)
+		if errors.Is(err, object_event_store.ErrObjectNotExist) { // deleted after walking
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_ = file.Close()
+		results = append(results, attrs)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the directories are walked in the order of their names, which isn't the order of the paths, e.g. "a/b" > "a-c"
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name+results[i].Prefix < results[j].Name+results[j].Prefix
+	})
+
+	return results, nil
+}
+
+// filePath returns the path of the file of the object, rejecting the names that would escape or collapse
+// the `folder/key/source` layout, or collide with the temporary files.
+func (d *directoryObjectStore) filePath(name string) (string, error) {
+	components := strings.Split(name, "/")
+	for _, component := range components {
+		if component == "" || component == "." || component == ".." ||
+			strings.Contains(component, `\`) || strings.HasPrefix(component, tempFilePrefix) {
+			return "", fmt.Errorf("'%s' cannot be used as a path component of '%s'", component, name)
+		}
+	}
+
+	return filepath.Join(append([]string{d.root}, components...)...), nil
+}
+
+// removeEmptyDirectories removes the directory and its parents under the root, until one of them isn't empty.
+func (d *directoryObjectStore) removeEmptyDirectories(directory string) {
+	for directory != d.root && os.Remove(directory) == nil {
+		directory = filepath.Dir(directory)
+	}
+}
+
+// openFile opens the file of the object and reads its header, leaving the reader at the start of the content.
+func openFile(path, name string) (*os.File, *bufio.Reader, *ObjectAttrs, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil, object_event_store.ErrObjectNotExist
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = object_event_store.ErrObjectNotExist
+	}
+	if err != nil {
+		_ = file.Close()
+
+		return nil, nil, nil, err
+	}
+	reader := bufio.NewReader(file)
+	headerLine, err := reader.ReadBytes('\n')
+	header := fileHeader{}
+	if err == nil {
+		err = json.Unmarshal(headerLine, &header)
+	}
+	if err != nil {
+		_ = file.Close()
+
+		return nil, nil, nil, fmt.Errorf("failed to read the header of %s: %w", path, err)
+	}
+	attrs := &ObjectAttrs{
+		Name:       name,
+		Size:       info.Size() - int64(len(headerLine)),
+		Created:    time.Unix(0, header.Created),
+		Generation: header.Created,
+		Metadata:   header.Metadata,
+	}
+	if header.ExpiresAt != 0 {
+		attrs.ExpiresAt = time.Unix(0, header.ExpiresAt)
+	}
+
+	return file, reader, attrs, nil
+}
+
+// writeFile writes the header and the content to a temporary file in the directory of the file,
+// and renames it to the file.
+func writeFile(path string, attrs *ObjectAttrs, content []byte) error {
+	header := fileHeader{
+		Created:  nextCreatedAt(),
+		Metadata: attrs.Metadata,
+	}
+	if !attrs.ExpiresAt.IsZero() {
+		header.ExpiresAt = attrs.ExpiresAt.UnixNano()
+	}
+	headerLine, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	tempFile, err := createTemp(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name()) // no-op once renamed
+	_, err = tempFile.Write(append(headerLine, '\n'))
+	if err == nil {
+		_, err = tempFile.Write(content)
+	}
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), path)
+}
+
+// createTemp creates a temporary file in the directory, creating the directory if it doesn't exist.
+// The directory can't be removed by a concurrent Delete once the file is created in it.
+func createTemp(directory string) (*os.File, error) {
+	var err error
+	for attempt := 0; attempt < createTempAttempts; attempt++ {
+		if err = os.MkdirAll(directory, 0o750); err != nil {
+			return nil, err
+		}
+		var tempFile *os.File
+		tempFile, err = os.CreateTemp(directory, tempFilePrefix+"*")
+		if !errors.Is(err, fs.ErrNotExist) {
+			return tempFile, err
+		}
+	}
+
+	return nil, err
+}
+
+// nextCreatedAt returns the current time in nanoseconds, or the nanosecond after the last one returned.
+func nextCreatedAt() int64 {
+	for {
+		last := lastCreatedAt.Load()
+		createdAt := max(time.Now().UnixNano(), last+1)
+		if lastCreatedAt.CompareAndSwap(last, createdAt) {
+			return createdAt
+		}
+	}
+}
+
+// fileObjects implements ObjectIterator on the objects listed by a walk of the directory, or on its failure.
+type fileObjects struct {
+	objects []*ObjectAttrs
+	err     error
+}
+
+func (f *fileObjects) Next() (*ObjectAttrs, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.objects) == 0 {
+		return nil, object_event_store.ErrNoMoreObjects
+	}
+	object := f.objects[0]
+	f.objects = f.objects[1:]
+
+	return object, nil
+}
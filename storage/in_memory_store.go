@@ -2,23 +2,153 @@ package storage
 
 import (
 	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/honestbank/event-driver/event"
 )
 
-type InMemoryStore map[string]map[string]string // key -> source -> content
+const inMemoryStoreShards = 32
+
+// InMemoryStore keeps the contents in memory, which are lost at restart.
+// Contents never expire unless a TTL is configured via WithTTL or given by PersistWithTTL.
+// InMemoryStore is safe for concurrent use, the keys are spread over shards guarded by their own locks,
+// so that operations on different keys rarely contend with each other.
+// Every write is given a new version from a store-wide counter for ConditionalEventStore.
+// Only the latest content of each key-source pair is kept unless a history depth is configured via WithHistory.
+// The zero value is an empty store ready to use, like the one returned by NewInMemoryStore.
+type InMemoryStore struct {
+	shards       [inMemoryStoreShards]inMemoryShard
+	lastVersion  atomic.Int64
+	lock         sync.RWMutex // guards ttl, historyDepth & stopJanitor
+	ttl          *time.Duration
+	historyDepth int
+	stopJanitor  chan struct{}
+}
+
+type inMemoryShard struct {
+	lock    sync.RWMutex
+	records map[string]map[string]inMemoryRecord // key -> source -> record, created by the first write
+}
+
+type inMemoryRecord struct {
+	content   string
+	createdAt time.Time
+	expiresAt *time.Time
+	version   Version
+	history   []inMemoryRecord // the previous revisions from the oldest to the newest, if history is enabled
+}
+
+func (r inMemoryRecord) isExpired(now time.Time) bool {
+	return r.expiresAt != nil && !now.Before(*r.expiresAt)
+}
+
+// revisions returns the previous revisions followed by the record itself, in a new slice.
+func (r inMemoryRecord) revisions() []inMemoryRecord {
+	revisions := make([]inMemoryRecord, 0, len(r.history)+1)
+	revisions = append(revisions, r.history...)
+
+	return append(revisions, r)
+}
+
+func newInMemoryRecord(content string, ttl *time.Duration) inMemoryRecord {
+	record := inMemoryRecord{content: content, createdAt: time.Now()}
+	if ttl != nil {
+		expiresAt := time.Now().Add(*ttl)
+		record.expiresAt = &expiresAt
+	}
+
+	return record
+}
 
 func NewInMemoryStore() *InMemoryStore {
-	internalMap := make(map[string]map[string]string)
-	inMemoryStore := InMemoryStore(internalMap)
+	return &InMemoryStore{}
+}
+
+// WithTTL makes every content persisted afterwards expire after the given TTL.
+func (i *InMemoryStore) WithTTL(ttl time.Duration) *InMemoryStore {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.ttl = &ttl
+
+	return i
+}
+
+// WithHistory makes the store keep up to `depth` revisions of each key-source pair including the latest one,
+// which are exposed via HistoryEventStore. A depth less than 2 keeps the latest revision only, which is the default.
+func (i *InMemoryStore) WithHistory(depth int) *InMemoryStore {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.historyDepth = depth
+
+	return i
+}
+
+// WithJanitor starts a background goroutine that removes the expired contents every interval.
+// Expired contents are invisible to look-ups anyway, the janitor only releases the memory they hold.
+// Call Close to stop the janitor.
+func (i *InMemoryStore) WithJanitor(interval time.Duration) *InMemoryStore {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.stopJanitor != nil {
+		close(i.stopJanitor)
+	}
+	i.stopJanitor = make(chan struct{})
+	go i.runJanitor(interval, i.stopJanitor)
+
+	return i
+}
+
+// Close stops the janitor if there is one running.
+func (i *InMemoryStore) Close() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.stopJanitor != nil {
+		close(i.stopJanitor)
+		i.stopJanitor = nil
+	}
 
-	return &inMemoryStore
+	return nil
+}
+
+func (i *InMemoryStore) Delete(_ context.Context, key, source string) error {
+	shard := i.shardOf(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	delete(shard.records[key], source)
+	if len(shard.records[key]) == 0 {
+		delete(shard.records, key)
+	}
+
+	return nil
+}
+
+func (i *InMemoryStore) DeleteByKey(_ context.Context, key string) error {
+	shard := i.shardOf(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	delete(shard.records, key)
+
+	return nil
 }
 
 func (i *InMemoryStore) ListSourcesByKey(_ context.Context, key string) ([]string, error) {
-	results := (*i)[key]
+	shard := i.shardOf(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	now := time.Now()
+	results := shard.records[key]
 	sources := make([]string, 0, len(results))
-	for source := range results {
+	for source, record := range results {
+		if record.isExpired(now) {
+			continue
+		}
 		sources = append(sources, source)
 	}
 
@@ -26,29 +156,261 @@ func (i *InMemoryStore) ListSourcesByKey(_ context.Context, key string) ([]strin
 }
 
 func (i *InMemoryStore) LookUp(_ context.Context, key, source string) (*event.Message, error) {
-	content, isHit := (*i)[key][source]
-	if !isHit {
+	shard := i.shardOf(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	record, isHit := shard.records[key][source]
+	if !isHit || record.isExpired(time.Now()) {
 		return nil, nil
 	}
 
-	return event.NewMessage(key, source, content), nil
+	return event.NewMessage(key, source, record.content), nil
 }
 
 func (i *InMemoryStore) LookUpByKey(_ context.Context, key string) ([]*event.Message, error) {
-	results := (*i)[key]
-	messages := make([]*event.Message, 0, len(results))
-	for source, content := range results {
-		messages = append(messages, event.NewMessage(key, source, content))
+	shard := i.shardOf(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	return shard.lookUpByKey(key, time.Now()), nil
+}
+
+// ListKeys returns the keys with unexpired contents in lexicographic order, where the cursor is the last listed key.
+func (i *InMemoryStore) ListKeys(_ context.Context, prefix, cursor string, limit int) ([]string, string, error) {
+	now := time.Now()
+	keys := make([]string, 0)
+	for index := range i.shards {
+		shard := &i.shards[index]
+		shard.lock.RLock()
+		for key, records := range shard.records {
+			for _, record := range records {
+				if !record.isExpired(now) {
+					keys = append(keys, key)
+
+					break
+				}
+			}
+		}
+		shard.lock.RUnlock()
 	}
+	keys, nextCursor := listKeys(keys, prefix, cursor, limit)
 
-	return messages, nil
+	return keys, nextCursor, nil
 }
 
-func (i *InMemoryStore) Persist(_ context.Context, key, source, content string) error {
-	if _, isKeyExist := (*i)[key]; !isKeyExist {
-		(*i)[key] = make(map[string]string)
+// LookUpVersion returns the message with the version of its last write.
+func (i *InMemoryStore) LookUpVersion(_ context.Context, key, source string) (*event.Message, Version, error) {
+	shard := i.shardOf(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	record, isHit := shard.records[key][source]
+	if !isHit || record.isExpired(time.Now()) {
+		return nil, NoVersion, nil
 	}
-	(*i)[key][source] = content
+
+	return event.NewMessage(key, source, record.content), record.version, nil
+}
+
+func (i *InMemoryStore) Persist(_ context.Context, key, source, content string) error {
+	i.persist(key, source, content, i.getTTL(), nil)
 
 	return nil
 }
+
+// PersistWithTTL persists the content that expires after the given TTL, regardless of the store-wide TTL.
+func (i *InMemoryStore) PersistWithTTL(_ context.Context, key, source, content string, ttl time.Duration) error {
+	i.persist(key, source, content, &ttl, nil)
+
+	return nil
+}
+
+// ListRevisions returns the unexpired revisions kept in the history, whose IDs are the versions of their writes.
+func (i *InMemoryStore) ListRevisions(_ context.Context, key, source string) ([]Revision, error) {
+	shard := i.shardOf(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	revisions := make([]Revision, 0)
+	record, isHit := shard.records[key][source]
+	if !isHit {
+		return revisions, nil
+	}
+	now := time.Now()
+	for _, revision := range record.revisions() {
+		if revision.isExpired(now) {
+			continue
+		}
+		revisions = append(revisions, Revision{
+			ID:        strconv.FormatInt(int64(revision.version), 10),
+			CreatedAt: revision.createdAt,
+		})
+	}
+
+	return revisions, nil
+}
+
+// LookUpRevision returns the message of the revision kept in the history.
+func (i *InMemoryStore) LookUpRevision(_ context.Context, key, source, id string) (*event.Message, error) {
+	shard := i.shardOf(key)
+	shard.lock.RLock()
+	defer shard.lock.RUnlock()
+
+	record, isHit := shard.records[key][source]
+	if !isHit {
+		return nil, nil
+	}
+	now := time.Now()
+	for _, revision := range record.revisions() {
+		if strconv.FormatInt(int64(revision.version), 10) == id && !revision.isExpired(now) {
+			return event.NewMessage(key, source, revision.content), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// PersistAndLookUpByKey persists the content and looks up the messages of the key under the same lock.
+func (i *InMemoryStore) PersistAndLookUpByKey(_ context.Context, key, source, content string) ([]*event.Message, error) {
+	record := newInMemoryRecord(content, i.getTTL())
+	shard := i.shardOf(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	i.put(shard, key, source, record)
+
+	return shard.lookUpByKey(key, time.Now()), nil
+}
+
+// PersistIfAbsent persists the content only if there is no unexpired content of the key-source pair.
+func (i *InMemoryStore) PersistIfAbsent(ctx context.Context, key, source, content string) (bool, error) {
+	return i.CompareAndPersist(ctx, key, source, content, NoVersion)
+}
+
+// CompareAndPersist persists the content only if the version of the last write is still the given one.
+func (i *InMemoryStore) CompareAndPersist(_ context.Context, key, source, content string, version Version) (bool, error) {
+	return i.persist(key, source, content, i.getTTL(), &version), nil
+}
+
+// persist writes the content if the expected version is nil or matches the current version,
+// and reports whether it's written.
+func (i *InMemoryStore) persist(key, source, content string, ttl *time.Duration, expectedVersion *Version) bool {
+	record := newInMemoryRecord(content, ttl)
+	shard := i.shardOf(key)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+	if expectedVersion != nil {
+		currentVersion := NoVersion
+		if current, isFound := shard.records[key][source]; isFound && !current.isExpired(time.Now()) {
+			currentVersion = current.version
+		}
+		if currentVersion != *expectedVersion {
+			return false
+		}
+	}
+	i.put(shard, key, source, record)
+
+	return true
+}
+
+// put writes the record with a new version, the shard must be locked by the caller.
+// The replaced record is kept in the history of the new one if history is enabled.
+func (i *InMemoryStore) put(shard *inMemoryShard, key, source string, record inMemoryRecord) {
+	if shard.records == nil {
+		shard.records = make(map[string]map[string]inMemoryRecord)
+	}
+	if _, isKeyExist := shard.records[key]; !isKeyExist {
+		shard.records[key] = make(map[string]inMemoryRecord)
+	}
+	if historyDepth := i.getHistoryDepth(); historyDepth > 1 {
+		if previous, isFound := shard.records[key][source]; isFound {
+			history := previous.revisions()
+			history[len(history)-1].history = nil
+			if len(history) > historyDepth-1 {
+				history = history[len(history)-(historyDepth-1):]
+			}
+			record.history = history
+		}
+	}
+	record.version = Version(i.lastVersion.Add(1))
+	shard.records[key][source] = record
+}
+
+func (i *InMemoryStore) getHistoryDepth() int {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.historyDepth
+}
+
+func (i *InMemoryStore) getTTL() *time.Duration {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.ttl
+}
+
+func (i *InMemoryStore) shardOf(key string) *inMemoryShard {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+
+	return &i.shards[hash.Sum32()%inMemoryStoreShards]
+}
+
+func (i *InMemoryStore) runJanitor(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			for index := range i.shards {
+				i.shards[index].removeExpired()
+			}
+		}
+	}
+}
+
+// lookUpByKey returns the unexpired messages of the key, the shard must be locked by the caller.
+func (s *inMemoryShard) lookUpByKey(key string, now time.Time) []*event.Message {
+	results := s.records[key]
+	messages := make([]*event.Message, 0, len(results))
+	for source, record := range results {
+		if record.isExpired(now) {
+			continue
+		}
+		messages = append(messages, event.NewMessage(key, source, record.content))
+	}
+
+	return messages
+}
+
+func (s *inMemoryShard) removeExpired() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for key, records := range s.records {
+		for source, record := range records {
+			if record.isExpired(now) {
+				delete(records, source)
+
+				continue
+			}
+			if len(record.history) > 0 {
+				history := make([]inMemoryRecord, 0, len(record.history))
+				for _, revision := range record.history {
+					if !revision.isExpired(now) {
+						history = append(history, revision)
+					}
+				}
+				record.history = history
+				records[source] = record
+			}
+		}
+		if len(records) == 0 {
+			delete(s.records, key)
+		}
+	}
+}
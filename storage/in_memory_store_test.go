@@ -2,7 +2,11 @@ package storage_test
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -68,3 +72,388 @@ func TestNewInMemoryStore(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, content)
 }
+
+func TestInMemoryStoreZeroValue(t *testing.T) {
+	ctx := context.TODO()
+	inMemoryStore := &storage.InMemoryStore{}
+
+	content, err := inMemoryStore.LookUp(ctx, key1, source1)
+	assert.NoError(t, err)
+	assert.Nil(t, content)
+	assert.NoError(t, inMemoryStore.Delete(ctx, key1, source1))
+	assert.NoError(t, inMemoryStore.Persist(ctx, key1, source1, "content1-1"))
+	content, err = inMemoryStore.LookUp(ctx, key1, source1)
+	assert.NoError(t, err)
+	assert.Equal(t, event.NewMessage(key1, source1, "content1-1"), content)
+}
+
+func TestInMemoryStoreTTL(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("store-wide TTL", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore().WithTTL(10 * time.Millisecond)
+
+		err := inMemoryStore.Persist(ctx, key1, source1, "content1-1")
+		assert.NoError(t, err)
+		content, err := inMemoryStore.LookUp(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key1, source1, "content1-1"), content)
+
+		time.Sleep(20 * time.Millisecond)
+		content, err = inMemoryStore.LookUp(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, content)
+		sources, err := inMemoryStore.ListSourcesByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{}, sources)
+		contents, err := inMemoryStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, contents)
+	})
+
+	t.Run("per-persist TTL overrides store-wide TTL", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore().WithTTL(time.Hour)
+
+		err := inMemoryStore.PersistWithTTL(ctx, key1, source1, "content1-1", 10*time.Millisecond)
+		assert.NoError(t, err)
+		err = inMemoryStore.Persist(ctx, key1, source2, "content1-2")
+		assert.NoError(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		contents, err := inMemoryStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{event.NewMessage(key1, source2, "content1-2")}, contents)
+	})
+
+	t.Run("re-persisting refreshes TTL", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore().WithTTL(30 * time.Millisecond)
+
+		err := inMemoryStore.Persist(ctx, key1, source1, "content1-1")
+		assert.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		err = inMemoryStore.Persist(ctx, key1, source1, "content1-1")
+		assert.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+
+		content, err := inMemoryStore.LookUp(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key1, source1, "content1-1"), content)
+	})
+
+	t.Run("expired contents are kept without janitor", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore().WithTTL(time.Millisecond)
+
+		assert.NoError(t, inMemoryStore.Persist(ctx, key1, source1, "content1-1"))
+		time.Sleep(5 * time.Millisecond)
+		content, err := inMemoryStore.LookUp(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Nil(t, content)
+		assert.Equal(t, 1, inMemoryStore.RecordCount())
+	})
+
+	t.Run("janitor removes expired contents", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore().
+			WithTTL(50 * time.Millisecond).
+			WithJanitor(5 * time.Millisecond)
+		defer inMemoryStore.Close()
+
+		err := inMemoryStore.Persist(ctx, key1, source1, "content1-1")
+		assert.NoError(t, err)
+		err = inMemoryStore.PersistWithTTL(ctx, key2, source1, "content2-1", time.Hour)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, inMemoryStore.RecordCount())
+
+		assert.Eventually(t, func() bool { return inMemoryStore.RecordCount() == 1 }, time.Second, time.Millisecond)
+		contents, err := inMemoryStore.LookUpByKey(ctx, key1)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{}, contents)
+		contents, err = inMemoryStore.LookUpByKey(ctx, key2)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{event.NewMessage(key2, source1, "content2-1")}, contents)
+
+		assert.NoError(t, inMemoryStore.Close())
+		assert.NoError(t, inMemoryStore.Close()) // closing twice is harmless
+	})
+}
+
+func TestInMemoryStoreDelete(t *testing.T) {
+	inMemoryStore := storage.NewInMemoryStore()
+	ctx := context.TODO()
+
+	err := inMemoryStore.Persist(ctx, key1, source1, "content1-1")
+	assert.NoError(t, err)
+	err = inMemoryStore.Persist(ctx, key1, source2, "content1-2")
+	assert.NoError(t, err)
+	err = inMemoryStore.Persist(ctx, key2, source1, "content2-1")
+	assert.NoError(t, err)
+
+	// delete by key & source
+	err = inMemoryStore.Delete(ctx, key1, source1)
+	assert.NoError(t, err)
+	sources, err := inMemoryStore.ListSourcesByKey(ctx, key1)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{source2}, sources)
+
+	// delete by key
+	err = inMemoryStore.DeleteByKey(ctx, key1)
+	assert.NoError(t, err)
+	contents, err := inMemoryStore.LookUpByKey(ctx, key1)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*event.Message{}, contents)
+
+	// other keys are untouched
+	content, err := inMemoryStore.LookUp(ctx, key2, source1)
+	assert.NoError(t, err)
+	assert.Equal(t, event.NewMessage(key2, source1, "content2-1"), content)
+
+	// deleting what doesn't exist is not an error
+	assert.NoError(t, inMemoryStore.Delete(ctx, "unknown-key", source1))
+	assert.NoError(t, inMemoryStore.DeleteByKey(ctx, "unknown-key"))
+}
+
+// TestInMemoryStoreConcurrency is meant to be run with the race detector, i.e. `go test -race`.
+func TestInMemoryStoreConcurrency(t *testing.T) {
+	inMemoryStore := storage.NewInMemoryStore().WithTTL(time.Minute).WithJanitor(time.Millisecond)
+	defer inMemoryStore.Close()
+	ctx := context.TODO()
+	goroutines := 16
+	keys := 8
+
+	waitGroup := sync.WaitGroup{}
+	for routine := 0; routine < goroutines; routine++ {
+		waitGroup.Add(1)
+		go func(routine int) {
+			defer waitGroup.Done()
+			source := fmt.Sprintf("source%d", routine)
+			for index := 0; index < 100; index++ {
+				key := fmt.Sprintf("key%d", index%keys)
+				assert.NoError(t, inMemoryStore.Persist(ctx, key, source, "content"))
+				_, err := inMemoryStore.LookUp(ctx, key, source)
+				assert.NoError(t, err)
+				_, err = inMemoryStore.LookUpByKey(ctx, key)
+				assert.NoError(t, err)
+				_, err = inMemoryStore.ListSourcesByKey(ctx, key)
+				assert.NoError(t, err)
+				if index%10 == 0 {
+					assert.NoError(t, inMemoryStore.PersistWithTTL(ctx, key, source, "content", time.Millisecond))
+					assert.NoError(t, inMemoryStore.Delete(ctx, key, "unknown-source"))
+				}
+			}
+		}(routine)
+	}
+	waitGroup.Wait()
+
+	for index := 0; index < keys; index++ {
+		sources, err := inMemoryStore.ListSourcesByKey(ctx, fmt.Sprintf("key%d", index))
+		assert.NoError(t, err)
+		assert.Len(t, sources, goroutines)
+	}
+}
+
+func TestInMemoryStoreConditionalWrites(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("persist if absent", func(t *testing.T) {
+		var conditionalStore storage.ConditionalEventStore = storage.NewInMemoryStore()
+
+		isPersisted, err := conditionalStore.PersistIfAbsent(ctx, "key", "source", "content1")
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		isPersisted, err = conditionalStore.PersistIfAbsent(ctx, "key", "source", "content2")
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		message, err := conditionalStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content1"), message)
+	})
+
+	t.Run("persist if absent over expired content", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore()
+		assert.NoError(t, inMemoryStore.PersistWithTTL(ctx, "key", "source", "content1", time.Millisecond))
+		time.Sleep(5 * time.Millisecond)
+
+		isPersisted, err := inMemoryStore.PersistIfAbsent(ctx, "key", "source", "content2")
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+	})
+
+	t.Run("compare and persist", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore()
+		message, version, err := inMemoryStore.LookUpVersion(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+		assert.Equal(t, storage.NoVersion, version)
+
+		assert.NoError(t, inMemoryStore.Persist(ctx, "key", "source", "content1"))
+		message, version, err = inMemoryStore.LookUpVersion(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content1"), message)
+		assert.NotEqual(t, storage.NoVersion, version)
+
+		isPersisted, err := inMemoryStore.CompareAndPersist(ctx, "key", "source", "content2", version)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		// the version has changed with the last write
+		isPersisted, err = inMemoryStore.CompareAndPersist(ctx, "key", "source", "content3", version)
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		// deleting and re-persisting never reuses a version
+		assert.NoError(t, inMemoryStore.Delete(ctx, "key", "source"))
+		assert.NoError(t, inMemoryStore.Persist(ctx, "key", "source", "content4"))
+		isPersisted, err = inMemoryStore.CompareAndPersist(ctx, "key", "source", "content5", version)
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		message, err = inMemoryStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content4"), message)
+	})
+
+	t.Run("only one of concurrent writers wins", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore()
+		var persisted atomic.Int32
+
+		waitGroup := sync.WaitGroup{}
+		for routine := 0; routine < 16; routine++ {
+			waitGroup.Add(1)
+			go func(routine int) {
+				defer waitGroup.Done()
+				isPersisted, err := inMemoryStore.PersistIfAbsent(ctx, "key", "source", fmt.Sprint(routine))
+				assert.NoError(t, err)
+				if isPersisted {
+					persisted.Add(1)
+				}
+			}(routine)
+		}
+		waitGroup.Wait()
+
+		assert.Equal(t, int32(1), persisted.Load())
+	})
+}
+
+func TestInMemoryStorePersistAndLookUpByKey(t *testing.T) {
+	ctx := context.TODO()
+	var atomicStore storage.AtomicEventStore = storage.NewInMemoryStore()
+	goroutines := 16
+
+	// exactly one of the concurrent writers sees the contents of all the others
+	var completeViews atomic.Int32
+	waitGroup := sync.WaitGroup{}
+	for routine := 0; routine < goroutines; routine++ {
+		waitGroup.Add(1)
+		go func(routine int) {
+			defer waitGroup.Done()
+			messages, err := atomicStore.PersistAndLookUpByKey(ctx, key1, fmt.Sprintf("source%d", routine), "content")
+			assert.NoError(t, err)
+			assert.Contains(t, messages, event.NewMessage(key1, fmt.Sprintf("source%d", routine), "content"))
+			if len(messages) == goroutines {
+				completeViews.Add(1)
+			}
+		}(routine)
+	}
+	waitGroup.Wait()
+
+	assert.Equal(t, int32(1), completeViews.Load())
+}
+
+func TestInMemoryStoreHistory(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("latest revision only by default", func(t *testing.T) {
+		var historyStore storage.HistoryEventStore = storage.NewInMemoryStore()
+		assert.NoError(t, historyStore.Persist(ctx, key1, source1, "content1"))
+		assert.NoError(t, historyStore.Persist(ctx, key1, source1, "content2"))
+
+		revisions, err := historyStore.ListRevisions(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 1)
+		message, err := historyStore.LookUpRevision(ctx, key1, source1, revisions[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key1, source1, "content2"), message)
+	})
+
+	t.Run("keep revisions up to depth", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore().WithHistory(3)
+		revisions, err := inMemoryStore.ListRevisions(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Empty(t, revisions)
+
+		for index := 1; index <= 4; index++ {
+			assert.NoError(t, inMemoryStore.Persist(ctx, key1, source1, fmt.Sprintf("content%d", index)))
+		}
+		revisions, err = inMemoryStore.ListRevisions(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 3)
+		for index, revision := range revisions {
+			message, err := inMemoryStore.LookUpRevision(ctx, key1, source1, revision.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, event.NewMessage(key1, source1, fmt.Sprintf("content%d", index+2)), message)
+			if index > 0 {
+				assert.False(t, revision.CreatedAt.Before(revisions[index-1].CreatedAt))
+			}
+		}
+
+		message, err := inMemoryStore.LookUp(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key1, source1, "content4"), message)
+		message, err = inMemoryStore.LookUpRevision(ctx, key1, source1, "unknown")
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		assert.NoError(t, inMemoryStore.Delete(ctx, key1, source1))
+		revisions, err = inMemoryStore.ListRevisions(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Empty(t, revisions)
+	})
+
+	t.Run("expired revisions are skipped", func(t *testing.T) {
+		inMemoryStore := storage.NewInMemoryStore().WithHistory(3)
+		defer inMemoryStore.Close()
+		assert.NoError(t, inMemoryStore.PersistWithTTL(ctx, key1, source1, "content1", time.Millisecond))
+		assert.NoError(t, inMemoryStore.Persist(ctx, key1, source1, "content2"))
+		time.Sleep(5 * time.Millisecond)
+
+		revisions, err := inMemoryStore.ListRevisions(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 1)
+
+		// the janitor prunes the expired revisions without touching the latest one
+		inMemoryStore.WithJanitor(time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		message, err := inMemoryStore.LookUp(ctx, key1, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key1, source1, "content2"), message)
+	})
+}
+
+func TestInMemoryStoreListKeys(t *testing.T) {
+	ctx := context.TODO()
+	inMemoryStore := storage.NewInMemoryStore()
+	for _, key := range []string{"order-3", "order-1", "payment-1", "order-2", "order-10"} {
+		assert.NoError(t, inMemoryStore.Persist(ctx, key, source1, "content"))
+	}
+	assert.NoError(t, inMemoryStore.PersistWithTTL(ctx, "order-0", source1, "content", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	keys, cursor, err := inMemoryStore.ListKeys(ctx, "order-", "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-1", "order-10"}, keys)
+	assert.Equal(t, "order-10", cursor)
+	keys, cursor, err = inMemoryStore.ListKeys(ctx, "order-", cursor, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-2", "order-3"}, keys)
+	assert.Empty(t, cursor)
+
+	keys, cursor, err = inMemoryStore.ListKeys(ctx, "", "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-1", "order-10", "order-2", "order-3", "payment-1"}, keys)
+	assert.Empty(t, cursor)
+
+	assert.NoError(t, inMemoryStore.DeleteByKey(ctx, "order-1"))
+	keys, _, err = inMemoryStore.ListKeys(ctx, "order-1", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"order-10"}, keys)
+}
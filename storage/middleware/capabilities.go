@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+)
+
+//go:generate go run gen_wrappers.go
+
+// capability is the bit of an optional capability of storage.EventStore in a capability set.
+type capability uint
+
+const (
+	expiring    capability = 1 << iota // storage.ExpiringEventStore
+	conditional                        // storage.ConditionalEventStore
+	atomic                             // storage.AtomicEventStore
+	history                            // storage.HistoryEventStore
+	keyListing                         // storage.KeyListingEventStore
+
+	allCapabilities = keyListing<<1 - 1
+)
+
+// capabilityMethods holds the methods of every optional capability, where only the ones of the wrapped store
+// are exposed by the wrapper.
+type capabilityMethods struct {
+	expiring    expiringMethods
+	conditional conditionalMethods
+	atomic      atomicMethods
+	history     historyMethods
+	keyListing  keyListingMethods
+}
+
+// wrap exposes the optional capabilities of the wrapped store on top of the interceptedStore, with the wrapper of
+// their capability set in wrappers. Adding a capability takes its bit, its methods, and running go generate.
+func wrap(base *interceptedStore) storage.EventStore {
+	var capabilities capability
+	var methods capabilityMethods
+	if expiringStore, isExpiring := base.eventStore.(storage.ExpiringEventStore); isExpiring {
+		capabilities |= expiring
+		methods.expiring = expiringMethods{base: base, eventStore: expiringStore}
+	}
+	if conditionalStore, isConditional := base.eventStore.(storage.ConditionalEventStore); isConditional {
+		capabilities |= conditional
+		methods.conditional = conditionalMethods{base: base, eventStore: conditionalStore}
+	}
+	if atomicStore, isAtomic := base.eventStore.(storage.AtomicEventStore); isAtomic {
+		capabilities |= atomic
+		methods.atomic = atomicMethods{base: base, eventStore: atomicStore}
+	}
+	if historyStore, isHistory := base.eventStore.(storage.HistoryEventStore); isHistory {
+		capabilities |= history
+		methods.history = historyMethods{base: base, eventStore: historyStore}
+	}
+	if keyListingStore, isKeyListing := base.eventStore.(storage.KeyListingEventStore); isKeyListing {
+		capabilities |= keyListing
+		methods.keyListing = keyListingMethods{base: base, eventStore: keyListingStore}
+	}
+
+	return wrappers[capabilities](base, methods)
+}
+
+// expiringMethods runs the interceptor around the storage.ExpiringEventStore methods of the wrapped store.
+type expiringMethods struct {
+	base       *interceptedStore
+	eventStore storage.ExpiringEventStore
+}
+
+func (e expiringMethods) PersistWithTTL(ctx context.Context, key, source, content string, ttl time.Duration) error {
+	content, err := e.base.transformer.Encode(ctx, key, source, content)
+	if err != nil {
+		return err
+	}
+	request := Request{Operation: PersistWithTTL, Key: key, Source: source}
+
+	return e.base.interceptor(ctx, request, func(ctx context.Context) error {
+		return e.eventStore.PersistWithTTL(ctx, key, source, content, ttl)
+	})
+}
+
+// conditionalMethods runs the interceptor around the storage.ConditionalEventStore methods of the wrapped store.
+type conditionalMethods struct {
+	base       *interceptedStore
+	eventStore storage.ConditionalEventStore
+}
+
+func (c conditionalMethods) LookUpVersion(
+	ctx context.Context,
+	key, source string) (*event.Message, storage.Version, error) {
+	var message *event.Message
+	version := storage.NoVersion
+	request := Request{Operation: LookUpVersion, Key: key, Source: source}
+	err := c.base.interceptor(ctx, request, func(ctx context.Context) error {
+		var err error
+		message, version, err = c.eventStore.LookUpVersion(ctx, key, source)
+
+		return err
+	})
+	if err != nil {
+		return nil, storage.NoVersion, err
+	}
+	message, err = c.base.decode(ctx, message)
+	if err != nil {
+		return nil, storage.NoVersion, err
+	}
+
+	return message, version, nil
+}
+
+func (c conditionalMethods) PersistIfAbsent(ctx context.Context, key, source, content string) (bool, error) {
+	content, err := c.base.transformer.Encode(ctx, key, source, content)
+	if err != nil {
+		return false, err
+	}
+	var isPersisted bool
+	request := Request{Operation: PersistIfAbsent, Key: key, Source: source}
+	err = c.base.interceptor(ctx, request, func(ctx context.Context) error {
+		var err error
+		isPersisted, err = c.eventStore.PersistIfAbsent(ctx, key, source, content)
+
+		return err
+	})
+
+	return isPersisted, err
+}
+
+func (c conditionalMethods) CompareAndPersist(
+	ctx context.Context,
+	key, source, content string,
+	version storage.Version) (bool, error) {
+	content, err := c.base.transformer.Encode(ctx, key, source, content)
+	if err != nil {
+		return false, err
+	}
+	var isPersisted bool
+	request := Request{Operation: CompareAndPersist, Key: key, Source: source}
+	err = c.base.interceptor(ctx, request, func(ctx context.Context) error {
+		var err error
+		isPersisted, err = c.eventStore.CompareAndPersist(ctx, key, source, content, version)
+
+		return err
+	})
+
+	return isPersisted, err
+}
+
+// atomicMethods runs the interceptor around the storage.AtomicEventStore methods of the wrapped store.
+type atomicMethods struct {
+	base       *interceptedStore
+	eventStore storage.AtomicEventStore
+}
+
+func (a atomicMethods) PersistAndLookUpByKey(
+	ctx context.Context,
+	key, source, content string) ([]*event.Message, error) {
+	content, err := a.base.transformer.Encode(ctx, key, source, content)
+	if err != nil {
+		return nil, err
+	}
+	var messages []*event.Message
+	request := Request{Operation: PersistAndLookUpByKey, Key: key, Source: source}
+	err = a.base.interceptor(ctx, request, func(ctx context.Context) error {
+		var err error
+		messages, err = a.eventStore.PersistAndLookUpByKey(ctx, key, source, content)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.base.decodeAll(ctx, messages)
+}
+
+// historyMethods runs the interceptor around the storage.HistoryEventStore methods of the wrapped store.
+type historyMethods struct {
+	base       *interceptedStore
+	eventStore storage.HistoryEventStore
+}
+
+func (h historyMethods) ListRevisions(ctx context.Context, key, source string) ([]storage.Revision, error) {
+	var revisions []storage.Revision
+	request := Request{Operation: ListRevisions, Key: key, Source: source}
+	err := h.base.interceptor(ctx, request, func(ctx context.Context) error {
+		var err error
+		revisions, err = h.eventStore.ListRevisions(ctx, key, source)
+
+		return err
+	})
+
+	return revisions, err
+}
+
+func (h historyMethods) LookUpRevision(ctx context.Context, key, source, id string) (*event.Message, error) {
+	var message *event.Message
+	request := Request{Operation: LookUpRevision, Key: key, Source: source}
+	err := h.base.interceptor(ctx, request, func(ctx context.Context) error {
+		var err error
+		message, err = h.eventStore.LookUpRevision(ctx, key, source, id)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.base.decode(ctx, message)
+}
+
+// keyListingMethods runs the interceptor around the storage.KeyListingEventStore methods of the wrapped store.
+type keyListingMethods struct {
+	base       *interceptedStore
+	eventStore storage.KeyListingEventStore
+}
+
+func (k keyListingMethods) ListKeys(ctx context.Context, prefix, cursor string, limit int) ([]string, string, error) {
+	var keys []string
+	var nextCursor string
+	err := k.base.interceptor(ctx, Request{Operation: ListKeys, Key: prefix}, func(ctx context.Context) error {
+		var err error
+		keys, nextCursor, err = k.eventStore.ListKeys(ctx, prefix, cursor, limit)
+
+		return err
+	})
+
+	return keys, nextCursor, err
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import "github.com/honestbank/event-driver/storage"
+
+type Capability = capability
+
+const (
+	Expiring        = expiring
+	Conditional     = conditional
+	Atomic          = atomic
+	History         = history
+	KeyListing      = keyListing
+	AllCapabilities = allCapabilities
+)
+
+// WrapperOf returns the wrapper of the capability set, around nothing.
+func WrapperOf(capabilities capability) storage.EventStore {
+	return wrappers[capabilities](&interceptedStore{}, capabilityMethods{})
+}
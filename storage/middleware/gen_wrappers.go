@@ -0,0 +1,51 @@
+//go:build ignore
+
+// gen_wrappers generates wrappers_gen.go, with the wrapper of every set of optional capabilities.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+)
+
+// capabilities lists the bits of capabilities.go in order, which are also the fields of capabilityMethods.
+var capabilities = []string{"expiring", "conditional", "atomic", "history", "keyListing"}
+
+func main() {
+	var output bytes.Buffer
+	output.WriteString("// Code generated by gen_wrappers.go. DO NOT EDIT.\n\n")
+	output.WriteString("package middleware\n\n")
+	output.WriteString("import \"github.com/honestbank/event-driver/storage\"\n\n")
+	output.WriteString("// wrappers returns the wrapper that exposes exactly the capabilities of its index.\n")
+	output.WriteString("var wrappers = [allCapabilities + 1]func(*interceptedStore, capabilityMethods) storage.EventStore{\n")
+	output.WriteString("0: func(base *interceptedStore, _ capabilityMethods) storage.EventStore {\nreturn base\n},\n")
+	for set := 1; set < 1<<len(capabilities); set++ {
+		bits := make([]string, 0, len(capabilities))
+		embedded := make([]string, 0, len(capabilities))
+		values := []string{"base"}
+		for index, capability := range capabilities {
+			if set&(1<<index) == 0 {
+				continue
+			}
+			bits = append(bits, capability)
+			embedded = append(embedded, capability+"Methods")
+			values = append(values, "methods."+capability)
+		}
+		fmt.Fprintf(&output, "%s: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {\n",
+			strings.Join(bits, " | "))
+		fmt.Fprintf(&output, "return &struct {\n*interceptedStore\n%s\n}{%s}\n},\n",
+			strings.Join(embedded, "\n"), strings.Join(values, ", "))
+	}
+	output.WriteString("}\n")
+
+	source, err := format.Source(output.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile("wrappers_gen.go", source, 0o644); err != nil {
+		panic(err)
+	}
+}
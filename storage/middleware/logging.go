@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Logging returns a Middleware that logs every operation with its key, source and latency,
+// at debug level if it succeeds, or at error level along with the error if it fails.
+func Logging(logger *slog.Logger) Middleware {
+	logger = logger.With(slog.String("component", "event_store"))
+
+	return Intercept(func(ctx context.Context, request Request, invoke func(ctx context.Context) error) error {
+		startedAt := time.Now()
+		err := invoke(ctx)
+		attributes := []slog.Attr{
+			slog.String("operation", string(request.Operation)),
+			slog.String("key", request.Key),
+			slog.Duration("latency", time.Since(startedAt)),
+		}
+		if request.Source != "" {
+			attributes = append(attributes, slog.String("source", request.Source))
+		}
+		if err != nil {
+			logger.LogAttrs(ctx, slog.LevelError, "event store operation failed",
+				append(attributes, slog.Any("error", err))...)
+
+			return err
+		}
+		logger.LogAttrs(ctx, slog.LevelDebug, "event store operation succeeded", attributes...)
+
+		return nil
+	})
+}
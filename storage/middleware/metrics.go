@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"context"
+	"time"
+)
+
+// LatencyRecorder receives the latency and the result of every operation, e.g. to observe a histogram
+// labeled by operation and outcome in the metrics library of the service.
+type LatencyRecorder func(operation Operation, latency time.Duration, err error)
+
+// Metrics returns a Middleware that measures the latency of every operation and reports it to the recorder.
+func Metrics(recorder LatencyRecorder) Middleware {
+	return Intercept(func(ctx context.Context, request Request, invoke func(ctx context.Context) error) error {
+		startedAt := time.Now()
+		err := invoke(ctx)
+		recorder(request.Operation, time.Since(startedAt), err)
+
+		return err
+	})
+}
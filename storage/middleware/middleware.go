@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+)
+
+// Operation names a method of storage.EventStore or of its optional capabilities.
+type Operation string
+
+const (
+	Delete                Operation = "Delete"
+	DeleteByKey           Operation = "DeleteByKey"
+	ListSourcesByKey      Operation = "ListSourcesByKey"
+	LookUp                Operation = "LookUp"
+	LookUpByKey           Operation = "LookUpByKey"
+	Persist               Operation = "Persist"
+	PersistWithTTL        Operation = "PersistWithTTL"        // storage.ExpiringEventStore
+	LookUpVersion         Operation = "LookUpVersion"         // storage.ConditionalEventStore
+	PersistIfAbsent       Operation = "PersistIfAbsent"       // storage.ConditionalEventStore
+	CompareAndPersist     Operation = "CompareAndPersist"     // storage.ConditionalEventStore
+	PersistAndLookUpByKey Operation = "PersistAndLookUpByKey" // storage.AtomicEventStore
+	ListRevisions         Operation = "ListRevisions"         // storage.HistoryEventStore
+	LookUpRevision        Operation = "LookUpRevision"        // storage.HistoryEventStore
+	ListKeys              Operation = "ListKeys"              // storage.KeyListingEventStore
+)
+
+// Request describes an operation on the event store, as seen by an Interceptor.
+type Request struct {
+	Operation Operation
+	Key       string // the prefix for ListKeys
+	Source    string // empty for the operations by key
+}
+
+// Interceptor runs around every operation of an event store, where invoke runs the operation on the wrapped store.
+// An Interceptor may call invoke with a derived context, several times (e.g. to retry), or not at all.
+type Interceptor func(ctx context.Context, request Request, invoke func(ctx context.Context) error) error
+
+// Middleware wraps an event store with extra behavior, e.g. Retry, Timeout, Logging or Metrics.
+type Middleware func(storage.EventStore) storage.EventStore
+
+// Chain wraps the event store with the middlewares, where the first middleware is the outermost one.
+// E.g. Chain(eventStore, Logging(logger), Retry(policy), Timeout(timeouts)) logs once per operation,
+// and applies the timeouts to each attempt.
+func Chain(eventStore storage.EventStore, middlewares ...Middleware) storage.EventStore {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		eventStore = middlewares[i](eventStore)
+	}
+
+	return eventStore
+}
+
+// Intercept returns a Middleware that runs the Interceptor around every operation of the wrapped store.
+// The wrapped store keeps exposing the optional capabilities it implements, e.g. storage.ConditionalEventStore,
+// so that handlers still detect them.
+func Intercept(interceptor Interceptor) Middleware {
+	return func(eventStore storage.EventStore) storage.EventStore {
+		return wrap(&interceptedStore{
+			eventStore:  eventStore,
+			interceptor: interceptor,
+			transformer: noTransform{},
+		})
+	}
+}
+
+// ContentTransformer encodes the contents on their way into the wrapped store, and decodes them on their way out,
+// e.g. to encrypt them. Both directions get the key & source of the content.
+type ContentTransformer interface {
+	Encode(ctx context.Context, key, source, content string) (string, error)
+	Decode(ctx context.Context, key, source, content string) (string, error)
+}
+
+// TransformContent returns a Middleware that encodes every content written to the wrapped store,
+// and decodes every content read from it. Like Intercept, it keeps the optional capabilities of the wrapped store.
+func TransformContent(transformer ContentTransformer) Middleware {
+	return func(eventStore storage.EventStore) storage.EventStore {
+		return wrap(&interceptedStore{
+			eventStore:  eventStore,
+			interceptor: invoke,
+			transformer: transformer,
+		})
+	}
+}
+
+func invoke(ctx context.Context, _ Request, invoke func(ctx context.Context) error) error {
+	return invoke(ctx)
+}
+
+type noTransform struct{}
+
+func (n noTransform) Encode(_ context.Context, _, _, content string) (string, error) {
+	return content, nil
+}
+
+func (n noTransform) Decode(_ context.Context, _, _, content string) (string, error) {
+	return content, nil
+}
+
+// interceptedStore runs the interceptor around the storage.EventStore methods of the wrapped store,
+// and transforms the contents written to and read from it.
+type interceptedStore struct {
+	eventStore  storage.EventStore
+	interceptor Interceptor
+	transformer ContentTransformer
+}
+
+func (i *interceptedStore) Delete(ctx context.Context, key, source string) error {
+	return i.interceptor(ctx, Request{Operation: Delete, Key: key, Source: source}, func(ctx context.Context) error {
+		return i.eventStore.Delete(ctx, key, source)
+	})
+}
+
+func (i *interceptedStore) DeleteByKey(ctx context.Context, key string) error {
+	return i.interceptor(ctx, Request{Operation: DeleteByKey, Key: key}, func(ctx context.Context) error {
+		return i.eventStore.DeleteByKey(ctx, key)
+	})
+}
+
+func (i *interceptedStore) ListSourcesByKey(ctx context.Context, key string) ([]string, error) {
+	var sources []string
+	err := i.interceptor(ctx, Request{Operation: ListSourcesByKey, Key: key}, func(ctx context.Context) error {
+		var err error
+		sources, err = i.eventStore.ListSourcesByKey(ctx, key)
+
+		return err
+	})
+
+	return sources, err
+}
+
+func (i *interceptedStore) LookUp(ctx context.Context, key, source string) (*event.Message, error) {
+	var message *event.Message
+	err := i.interceptor(ctx, Request{Operation: LookUp, Key: key, Source: source}, func(ctx context.Context) error {
+		var err error
+		message, err = i.eventStore.LookUp(ctx, key, source)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return i.decode(ctx, message)
+}
+
+func (i *interceptedStore) LookUpByKey(ctx context.Context, key string) ([]*event.Message, error) {
+	var messages []*event.Message
+	err := i.interceptor(ctx, Request{Operation: LookUpByKey, Key: key}, func(ctx context.Context) error {
+		var err error
+		messages, err = i.eventStore.LookUpByKey(ctx, key)
+
+		return err
+	})
+	if err != nil {
+		return messages, err
+	}
+
+	return i.decodeAll(ctx, messages)
+}
+
+func (i *interceptedStore) Persist(ctx context.Context, key, source, content string) error {
+	content, err := i.transformer.Encode(ctx, key, source, content)
+	if err != nil {
+		return err
+	}
+
+	return i.interceptor(ctx, Request{Operation: Persist, Key: key, Source: source}, func(ctx context.Context) error {
+		return i.eventStore.Persist(ctx, key, source, content)
+	})
+}
+
+func (i *interceptedStore) decode(ctx context.Context, message *event.Message) (*event.Message, error) {
+	if message == nil {
+		return nil, nil
+	}
+	content, err := i.transformer.Decode(ctx, message.GetKey(), message.GetSource(), message.GetContent())
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewMessage(message.GetKey(), message.GetSource(), content), nil
+}
+
+func (i *interceptedStore) decodeAll(ctx context.Context, messages []*event.Message) ([]*event.Message, error) {
+	if messages == nil {
+		return nil, nil
+	}
+	decodedMessages := make([]*event.Message, 0, len(messages))
+	for _, message := range messages {
+		decodedMessage, err := i.decode(ctx, message)
+		if err != nil {
+			return nil, err
+		}
+		decodedMessages = append(decodedMessages, decodedMessage)
+	}
+
+	return decodedMessages, nil
+}
@@ -0,0 +1,237 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/mocks"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/middleware"
+)
+
+const (
+	key     = "key"
+	source1 = "source1"
+	source2 = "source2"
+	content = "content"
+)
+
+func TestIntercept(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("intercept every operation", func(t *testing.T) {
+		requests := make([]middleware.Request, 0)
+		recordRequests := middleware.Intercept(
+			func(ctx context.Context, request middleware.Request, invoke func(ctx context.Context) error) error {
+				requests = append(requests, request)
+
+				return invoke(ctx)
+			})
+		eventStore := recordRequests(storage.NewInMemoryStore().WithHistory(3))
+
+		assert.NoError(t, eventStore.Persist(ctx, key, source1, content))
+		assert.NoError(t, eventStore.(storage.ExpiringEventStore).PersistWithTTL(ctx, key, source2, content, time.Hour))
+		sources, err := eventStore.ListSourcesByKey(ctx, key)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{source1, source2}, sources)
+		message, err := eventStore.LookUp(ctx, key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+		messages, err := eventStore.LookUpByKey(ctx, key)
+		assert.NoError(t, err)
+		assert.Len(t, messages, 2)
+
+		conditionalEventStore := eventStore.(storage.ConditionalEventStore)
+		message, version, err := conditionalEventStore.LookUpVersion(ctx, key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+		isPersisted, err := conditionalEventStore.PersistIfAbsent(ctx, key, source1, content)
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(ctx, key, source1, content, version)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		messages, err = eventStore.(storage.AtomicEventStore).PersistAndLookUpByKey(ctx, key, source1, content)
+		assert.NoError(t, err)
+		assert.Len(t, messages, 2)
+		revisions, err := eventStore.(storage.HistoryEventStore).ListRevisions(ctx, key, source1)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 3)
+		message, err = eventStore.(storage.HistoryEventStore).LookUpRevision(ctx, key, source1, revisions[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+		keys, cursor, err := eventStore.(storage.KeyListingEventStore).ListKeys(ctx, "k", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{key}, keys)
+		assert.Empty(t, cursor)
+
+		assert.NoError(t, eventStore.Delete(ctx, key, source1))
+		assert.NoError(t, eventStore.DeleteByKey(ctx, key))
+
+		assert.Equal(t, []middleware.Request{
+			{Operation: middleware.Persist, Key: key, Source: source1},
+			{Operation: middleware.PersistWithTTL, Key: key, Source: source2},
+			{Operation: middleware.ListSourcesByKey, Key: key},
+			{Operation: middleware.LookUp, Key: key, Source: source1},
+			{Operation: middleware.LookUpByKey, Key: key},
+			{Operation: middleware.LookUpVersion, Key: key, Source: source1},
+			{Operation: middleware.PersistIfAbsent, Key: key, Source: source1},
+			{Operation: middleware.CompareAndPersist, Key: key, Source: source1},
+			{Operation: middleware.PersistAndLookUpByKey, Key: key, Source: source1},
+			{Operation: middleware.ListRevisions, Key: key, Source: source1},
+			{Operation: middleware.LookUpRevision, Key: key, Source: source1},
+			{Operation: middleware.ListKeys, Key: "k"},
+			{Operation: middleware.Delete, Key: key, Source: source1},
+			{Operation: middleware.DeleteByKey, Key: key},
+		}, requests)
+	})
+
+	t.Run("only expose the capabilities of the wrapped store", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		noop := middleware.Intercept(
+			func(ctx context.Context, _ middleware.Request, invoke func(ctx context.Context) error) error {
+				return invoke(ctx)
+			})
+
+		eventStore := noop(mocks.NewMockEventStore(ctrl))
+		assert.Implements(t, (*storage.EventStore)(nil), eventStore)
+		assert.NotImplements(t, (*storage.ExpiringEventStore)(nil), eventStore)
+		assert.NotImplements(t, (*storage.ConditionalEventStore)(nil), eventStore)
+		assert.NotImplements(t, (*storage.AtomicEventStore)(nil), eventStore)
+
+		eventStore = noop(mocks.NewMockConditionalEventStore(ctrl))
+		assert.NotImplements(t, (*storage.ExpiringEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.ConditionalEventStore)(nil), eventStore)
+		assert.NotImplements(t, (*storage.AtomicEventStore)(nil), eventStore)
+
+		eventStore = noop(mocks.NewMockAtomicEventStore(ctrl))
+		assert.NotImplements(t, (*storage.ExpiringEventStore)(nil), eventStore)
+		assert.NotImplements(t, (*storage.ConditionalEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.AtomicEventStore)(nil), eventStore)
+
+		eventStore = noop(storage.NewBoundedStore(1))
+		assert.NotImplements(t, (*storage.ExpiringEventStore)(nil), eventStore)
+		assert.NotImplements(t, (*storage.ConditionalEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.AtomicEventStore)(nil), eventStore)
+		assert.NotImplements(t, (*storage.HistoryEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.KeyListingEventStore)(nil), eventStore)
+
+		eventStore = noop(storage.NewInMemoryStore())
+		assert.Implements(t, (*storage.ExpiringEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.ConditionalEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.AtomicEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.HistoryEventStore)(nil), eventStore)
+		assert.Implements(t, (*storage.KeyListingEventStore)(nil), eventStore)
+	})
+
+	t.Run("every capability set has a wrapper that exposes exactly its capabilities", func(t *testing.T) {
+		capabilities := map[middleware.Capability]any{
+			middleware.Expiring:    (*storage.ExpiringEventStore)(nil),
+			middleware.Conditional: (*storage.ConditionalEventStore)(nil),
+			middleware.Atomic:      (*storage.AtomicEventStore)(nil),
+			middleware.History:     (*storage.HistoryEventStore)(nil),
+			middleware.KeyListing:  (*storage.KeyListingEventStore)(nil),
+		}
+		for set := middleware.Capability(0); set <= middleware.AllCapabilities; set++ {
+			wrapper := middleware.WrapperOf(set)
+			assert.Implements(t, (*storage.EventStore)(nil), wrapper)
+			for capability, capabilityInterface := range capabilities {
+				if set&capability != 0 {
+					assert.Implements(t, capabilityInterface, wrapper, set)
+				} else {
+					assert.NotImplements(t, capabilityInterface, wrapper, set)
+				}
+			}
+		}
+	})
+}
+
+func TestTransformContent(t *testing.T) {
+	ctx := context.TODO()
+	inMemoryStore := storage.NewInMemoryStore().WithHistory(2)
+	eventStore := middleware.TransformContent(prefixer{prefix: "encoded:"})(inMemoryStore)
+
+	assert.NoError(t, eventStore.Persist(ctx, key, source1, content))
+	stored, err := inMemoryStore.LookUp(ctx, key, source1)
+	assert.NoError(t, err)
+	assert.Equal(t, "encoded:"+content, stored.GetContent())
+	message, err := eventStore.LookUp(ctx, key, source1)
+	assert.NoError(t, err)
+	assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+	isPersisted, err := eventStore.(storage.ConditionalEventStore).PersistIfAbsent(ctx, key, source2, content)
+	assert.NoError(t, err)
+	assert.True(t, isPersisted)
+	message, _, err = eventStore.(storage.ConditionalEventStore).LookUpVersion(ctx, key, source2)
+	assert.NoError(t, err)
+	assert.Equal(t, event.NewMessage(key, source2, content), message)
+	messages, err := eventStore.(storage.AtomicEventStore).PersistAndLookUpByKey(ctx, key, source1, "updated")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []*event.Message{
+		event.NewMessage(key, source1, "updated"),
+		event.NewMessage(key, source2, content),
+	}, messages)
+
+	revisions, err := eventStore.(storage.HistoryEventStore).ListRevisions(ctx, key, source1)
+	assert.NoError(t, err)
+	message, err = eventStore.(storage.HistoryEventStore).LookUpRevision(ctx, key, source1, revisions[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, event.NewMessage(key, source1, content), message)
+
+	message, err = eventStore.LookUp(ctx, key, "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, message)
+
+	assert.NoError(t, inMemoryStore.Persist(ctx, key, source1, "not encoded"))
+	_, err = eventStore.LookUp(ctx, key, source1)
+	assert.Error(t, err)
+	_, err = eventStore.LookUpByKey(ctx, key)
+	assert.Error(t, err)
+}
+
+// prefixer encodes the contents by adding the prefix.
+type prefixer struct {
+	prefix string
+}
+
+func (p prefixer) Encode(_ context.Context, _, _, content string) (string, error) {
+	return p.prefix + content, nil
+}
+
+func (p prefixer) Decode(_ context.Context, _, _, content string) (string, error) {
+	if !strings.HasPrefix(content, p.prefix) {
+		return "", errors.New("content isn't encoded")
+	}
+
+	return strings.TrimPrefix(content, p.prefix), nil
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.TODO()
+	calls := make([]string, 0)
+	record := func(name string) middleware.Middleware {
+		return middleware.Intercept(
+			func(ctx context.Context, _ middleware.Request, invoke func(ctx context.Context) error) error {
+				calls = append(calls, name+" before")
+				err := invoke(ctx)
+				calls = append(calls, name+" after")
+
+				return err
+			})
+	}
+
+	eventStore := middleware.Chain(storage.NewInMemoryStore(), record("outer"), record("inner"))
+	assert.NoError(t, eventStore.Persist(ctx, key, source1, content))
+	assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+	assert.Implements(t, (*storage.ConditionalEventStore)(nil), eventStore)
+
+	inMemoryStore := storage.NewInMemoryStore()
+	assert.Same(t, inMemoryStore, middleware.Chain(inMemoryStore))
+}
@@ -0,0 +1,92 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/mocks"
+	"github.com/honestbank/event-driver/storage/middleware"
+)
+
+func TestLogging(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockEventStore := mocks.NewMockEventStore(ctrl)
+	mockEventStore.EXPECT().Persist(gomock.Any(), key, source1, content).Return(nil)
+	mockEventStore.EXPECT().LookUpByKey(gomock.Any(), key).Return(nil, errors.New("test"))
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	eventStore := middleware.Logging(logger)(mockEventStore)
+	assert.NoError(t, eventStore.Persist(context.TODO(), key, source1, content))
+	_, err := eventStore.LookUpByKey(context.TODO(), key)
+	assert.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 2)
+	logs := make([]map[string]any, 0, len(lines))
+	for _, line := range lines {
+		log := make(map[string]any)
+		assert.NoError(t, json.Unmarshal([]byte(line), &log))
+		assert.Contains(t, log, "latency")
+		delete(log, "latency")
+		delete(log, "time")
+		logs = append(logs, log)
+	}
+	assert.Equal(t, []map[string]any{
+		{
+			"level":     "DEBUG",
+			"msg":       "event store operation succeeded",
+			"component": "event_store",
+			"operation": "Persist",
+			"key":       key,
+			"source":    source1,
+		},
+		{
+			"level":     "ERROR",
+			"msg":       "event store operation failed",
+			"component": "event_store",
+			"operation": "LookUpByKey",
+			"key":       key,
+			"error":     "test",
+		},
+	}, logs)
+}
+
+func TestMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockEventStore := mocks.NewMockEventStore(ctrl)
+	mockEventStore.EXPECT().LookUp(gomock.Any(), key, source1).DoAndReturn(
+		func(_ context.Context, _, _ string) (*event.Message, error) {
+			time.Sleep(time.Millisecond)
+
+			return nil, nil
+		})
+	mockEventStore.EXPECT().Delete(gomock.Any(), key, source1).Return(errors.New("test"))
+	operations := make([]middleware.Operation, 0)
+	errs := make([]error, 0)
+
+	eventStore := middleware.Metrics(func(operation middleware.Operation, latency time.Duration, err error) {
+		operations = append(operations, operation)
+		errs = append(errs, err)
+		if operation == middleware.LookUp {
+			assert.GreaterOrEqual(t, latency, time.Millisecond)
+		}
+	})(mockEventStore)
+	_, err := eventStore.LookUp(context.TODO(), key, source1)
+	assert.NoError(t, err)
+	assert.Error(t, eventStore.Delete(context.TODO(), key, source1))
+
+	assert.Equal(t, []middleware.Operation{middleware.LookUp, middleware.Delete}, operations)
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "test")
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy decides how many times and how often a failed operation is retried.
+type RetryPolicy struct {
+	MaxAttempts    int           // the number of attempts including the first one, at least 1
+	InitialBackoff time.Duration // the wait before the first retry
+	MaxBackoff     time.Duration // the cap of the wait between attempts, no cap if zero
+	Multiplier     float64       // the growth of the wait after each retry, at least 1
+	// IsRetryable decides whether the failed operation is retried, see DefaultRetryPolicy.
+	IsRetryable func(operation Operation, err error) bool
+}
+
+// DefaultRetryPolicy makes up to 3 attempts with backoffs of 100ms and 200ms, and only retries idempotent operations.
+// Conditional writes aren't retried, since their first attempt may have succeeded even if it failed with an error,
+// and a retry would then report that the content isn't persisted. Errors of the context aren't retried either.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		IsRetryable:    IsIdempotent,
+	}
+}
+
+// IsIdempotent returns whether the operation can be safely retried after the error,
+// i.e. the operation isn't a conditional write and the error isn't from the context.
+func IsIdempotent(operation Operation, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	return operation != PersistIfAbsent && operation != CompareAndPersist
+}
+
+// Retry returns a Middleware that retries the failed operations with exponential backoff.
+// The waits are interrupted once the context is done, which returns the last error of the operation.
+func Retry(policy RetryPolicy) Middleware {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 1
+	}
+	if policy.IsRetryable == nil {
+		policy.IsRetryable = IsIdempotent
+	}
+
+	return Intercept(func(ctx context.Context, request Request, invoke func(ctx context.Context) error) error {
+		backoff := policy.InitialBackoff
+		var err error
+		for attempt := 1; ; attempt++ {
+			err = invoke(ctx)
+			if err == nil || attempt >= policy.MaxAttempts || !policy.IsRetryable(request.Operation, err) {
+				return err
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return err
+			case <-timer.C:
+			}
+			backoff = time.Duration(float64(backoff) * policy.Multiplier)
+			if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+		}
+	})
+}
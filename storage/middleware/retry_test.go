@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/mocks"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/middleware"
+)
+
+func TestRetry(t *testing.T) {
+	ctx := context.TODO()
+	policy := middleware.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	t.Run("retry until it succeeds", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockEventStore(ctrl)
+		gomock.InOrder(
+			mockEventStore.EXPECT().LookUp(gomock.Any(), key, source1).Return(nil, errors.New("test")).Times(2),
+			mockEventStore.EXPECT().LookUp(gomock.Any(), key, source1).Return(event.NewMessage(key, source1, content), nil),
+		)
+
+		eventStore := middleware.Retry(policy)(mockEventStore)
+		message, err := eventStore.LookUp(ctx, key, source1)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage(key, source1, content), message)
+	})
+
+	t.Run("give up after max attempts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().Persist(gomock.Any(), key, source1, content).Return(errors.New("test")).Times(3)
+
+		eventStore := middleware.Retry(policy)(mockEventStore)
+		assert.Error(t, eventStore.Persist(ctx, key, source1, content))
+	})
+
+	t.Run("don't retry conditional writes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockConditionalEventStore(ctrl)
+		mockEventStore.EXPECT().PersistIfAbsent(gomock.Any(), key, source1, content).Return(false, errors.New("test"))
+
+		eventStore := middleware.Retry(policy)(mockEventStore)
+		_, err := eventStore.(storage.ConditionalEventStore).PersistIfAbsent(ctx, key, source1, content)
+		assert.Error(t, err)
+	})
+
+	t.Run("don't retry errors of the context", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().DeleteByKey(gomock.Any(), key).Return(context.DeadlineExceeded)
+
+		eventStore := middleware.Retry(policy)(mockEventStore)
+		assert.ErrorIs(t, eventStore.DeleteByKey(ctx, key), context.DeadlineExceeded)
+	})
+
+	t.Run("stop waiting once the context is done", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().Delete(gomock.Any(), key, source1).Return(errors.New("test"))
+
+		eventStore := middleware.Retry(middleware.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour})(mockEventStore)
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.EqualError(t, eventStore.Delete(cancelledCtx, key, source1), "test")
+	})
+
+	t.Run("default policy", func(t *testing.T) {
+		defaultPolicy := middleware.DefaultRetryPolicy()
+		assert.Equal(t, 3, defaultPolicy.MaxAttempts)
+		assert.True(t, defaultPolicy.IsRetryable(middleware.Persist, errors.New("test")))
+		assert.False(t, defaultPolicy.IsRetryable(middleware.CompareAndPersist, errors.New("test")))
+		assert.False(t, defaultPolicy.IsRetryable(middleware.LookUp, context.Canceled))
+	})
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts configures the timeout of each operation, like the timeouts of GCSConfig but for any event store.
+type Timeouts struct {
+	Default   *time.Duration              // the default timeout for all operations
+	Operation map[Operation]time.Duration // the timeout of each operation - this overrides the default timeout
+}
+
+// Timeout returns a Middleware that runs each operation with a context that times out after the configured timeout.
+// Operations without a configured timeout run with the parent context.
+func Timeout(timeouts Timeouts) Middleware {
+	return Intercept(func(ctx context.Context, request Request, invoke func(ctx context.Context) error) error {
+		timeout, isConfigured := timeouts.Operation[request.Operation]
+		if !isConfigured {
+			if timeouts.Default == nil {
+				return invoke(ctx)
+			}
+			timeout = *timeouts.Default
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return invoke(timeoutCtx)
+	})
+}
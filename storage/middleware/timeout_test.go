@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/mocks"
+	"github.com/honestbank/event-driver/storage/middleware"
+)
+
+func TestTimeout(t *testing.T) {
+	defaultTimeout := time.Minute
+	lookUpTimeout := time.Second
+	expectDeadline := func(ctx context.Context, timeout time.Duration) error {
+		deadline, isDeadlineConfigured := ctx.Deadline()
+		assert.True(t, isDeadlineConfigured)
+		assert.True(t, time.Now().Before(deadline))
+		assert.False(t, time.Now().Add(timeout).Before(deadline))
+
+		return nil
+	}
+
+	t.Run("use timeout of specific operation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().LookUp(gomock.Any(), key, source1).DoAndReturn(
+			func(ctx context.Context, _, _ string) (*event.Message, error) {
+				return nil, expectDeadline(ctx, lookUpTimeout)
+			})
+
+		eventStore := middleware.Timeout(middleware.Timeouts{
+			Default:   &defaultTimeout,
+			Operation: map[middleware.Operation]time.Duration{middleware.LookUp: lookUpTimeout},
+		})(mockEventStore)
+		_, err := eventStore.LookUp(context.TODO(), key, source1)
+		assert.NoError(t, err)
+	})
+
+	t.Run("use default timeout if operation isn't configured", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().Persist(gomock.Any(), key, source1, content).DoAndReturn(
+			func(ctx context.Context, _, _, _ string) error {
+				return expectDeadline(ctx, defaultTimeout)
+			})
+
+		eventStore := middleware.Timeout(middleware.Timeouts{
+			Default:   &defaultTimeout,
+			Operation: map[middleware.Operation]time.Duration{middleware.LookUp: lookUpTimeout},
+		})(mockEventStore)
+		assert.NoError(t, eventStore.Persist(context.TODO(), key, source1, content))
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockEventStore := mocks.NewMockEventStore(ctrl)
+		mockEventStore.EXPECT().DeleteByKey(gomock.Any(), key).DoAndReturn(func(ctx context.Context, _ string) error {
+			_, isDeadlineConfigured := ctx.Deadline()
+			assert.False(t, isDeadlineConfigured)
+
+			return nil
+		})
+
+		eventStore := middleware.Timeout(middleware.Timeouts{})(mockEventStore)
+		assert.NoError(t, eventStore.DeleteByKey(context.TODO(), key))
+	})
+}
@@ -0,0 +1,247 @@
+// Code generated by gen_wrappers.go. DO NOT EDIT.
+
+package middleware
+
+import "github.com/honestbank/event-driver/storage"
+
+// wrappers returns the wrapper that exposes exactly the capabilities of its index.
+var wrappers = [allCapabilities + 1]func(*interceptedStore, capabilityMethods) storage.EventStore{
+	0: func(base *interceptedStore, _ capabilityMethods) storage.EventStore {
+		return base
+	},
+	expiring: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+		}{base, methods.expiring}
+	},
+	conditional: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+		}{base, methods.conditional}
+	},
+	expiring | conditional: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+		}{base, methods.expiring, methods.conditional}
+	},
+	atomic: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			atomicMethods
+		}{base, methods.atomic}
+	},
+	expiring | atomic: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			atomicMethods
+		}{base, methods.expiring, methods.atomic}
+	},
+	conditional | atomic: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+			atomicMethods
+		}{base, methods.conditional, methods.atomic}
+	},
+	expiring | conditional | atomic: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+			atomicMethods
+		}{base, methods.expiring, methods.conditional, methods.atomic}
+	},
+	history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			historyMethods
+		}{base, methods.history}
+	},
+	expiring | history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			historyMethods
+		}{base, methods.expiring, methods.history}
+	},
+	conditional | history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+			historyMethods
+		}{base, methods.conditional, methods.history}
+	},
+	expiring | conditional | history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+			historyMethods
+		}{base, methods.expiring, methods.conditional, methods.history}
+	},
+	atomic | history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			atomicMethods
+			historyMethods
+		}{base, methods.atomic, methods.history}
+	},
+	expiring | atomic | history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			atomicMethods
+			historyMethods
+		}{base, methods.expiring, methods.atomic, methods.history}
+	},
+	conditional | atomic | history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+			atomicMethods
+			historyMethods
+		}{base, methods.conditional, methods.atomic, methods.history}
+	},
+	expiring | conditional | atomic | history: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+			atomicMethods
+			historyMethods
+		}{base, methods.expiring, methods.conditional, methods.atomic, methods.history}
+	},
+	keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			keyListingMethods
+		}{base, methods.keyListing}
+	},
+	expiring | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.keyListing}
+	},
+	conditional | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+			keyListingMethods
+		}{base, methods.conditional, methods.keyListing}
+	},
+	expiring | conditional | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.conditional, methods.keyListing}
+	},
+	atomic | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			atomicMethods
+			keyListingMethods
+		}{base, methods.atomic, methods.keyListing}
+	},
+	expiring | atomic | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			atomicMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.atomic, methods.keyListing}
+	},
+	conditional | atomic | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+			atomicMethods
+			keyListingMethods
+		}{base, methods.conditional, methods.atomic, methods.keyListing}
+	},
+	expiring | conditional | atomic | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+			atomicMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.conditional, methods.atomic, methods.keyListing}
+	},
+	history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			historyMethods
+			keyListingMethods
+		}{base, methods.history, methods.keyListing}
+	},
+	expiring | history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			historyMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.history, methods.keyListing}
+	},
+	conditional | history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+			historyMethods
+			keyListingMethods
+		}{base, methods.conditional, methods.history, methods.keyListing}
+	},
+	expiring | conditional | history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+			historyMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.conditional, methods.history, methods.keyListing}
+	},
+	atomic | history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			atomicMethods
+			historyMethods
+			keyListingMethods
+		}{base, methods.atomic, methods.history, methods.keyListing}
+	},
+	expiring | atomic | history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			atomicMethods
+			historyMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.atomic, methods.history, methods.keyListing}
+	},
+	conditional | atomic | history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			conditionalMethods
+			atomicMethods
+			historyMethods
+			keyListingMethods
+		}{base, methods.conditional, methods.atomic, methods.history, methods.keyListing}
+	},
+	expiring | conditional | atomic | history | keyListing: func(base *interceptedStore, methods capabilityMethods) storage.EventStore {
+		return &struct {
+			*interceptedStore
+			expiringMethods
+			conditionalMethods
+			atomicMethods
+			historyMethods
+			keyListingMethods
+		}{base, methods.expiring, methods.conditional, methods.atomic, methods.history, methods.keyListing}
+	},
+}
@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Progress is what a Checkpoint keeps of a Migration.
+type Progress struct {
+	Cursor      string `json:"cursor"`    // where the migration resumes from, empty to start from the first key
+	IsCompleted bool   `json:"completed"` // whether every page is copied, so that running it again copies nothing
+}
+
+// Checkpoint keeps the Progress of a Migration, i.e. where it resumes from.
+type Checkpoint interface {
+	// Load returns the saved progress, or an empty Progress if the migration hasn't started yet.
+	Load(ctx context.Context) (Progress, error)
+	// Save saves the progress after a copied page.
+	Save(ctx context.Context, progress Progress) error
+}
+
+type noCheckpoint struct{}
+
+func (n noCheckpoint) Load(context.Context) (Progress, error) {
+	return Progress{}, nil
+}
+
+func (n noCheckpoint) Save(context.Context, Progress) error {
+	return nil
+}
+
+type fileCheckpoint struct {
+	path string
+}
+
+// FileCheckpoint saves the progress as JSON in the file at the path, which is replaced atomically on every save.
+// Remove the file to start the migration over.
+func FileCheckpoint(path string) Checkpoint {
+	return fileCheckpoint{path: path}
+}
+
+func (f fileCheckpoint) Load(context.Context) (Progress, error) {
+	content, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Progress{}, nil
+	}
+	if err != nil {
+		return Progress{}, err
+	}
+	var progress Progress
+	err = json.Unmarshal(content, &progress)
+
+	return progress, err
+}
+
+func (f fileCheckpoint) Save(_ context.Context, progress Progress) error {
+	content, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), f.path)
+}
@@ -0,0 +1,245 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/honestbank/event-driver/storage"
+)
+
+// ErrKeyListingUnsupported is returned by Migration.Run if the source can't list its keys and no keys are given.
+var ErrKeyListingUnsupported = storage.ErrKeyListingUnsupported
+
+// Report sums up what a Migration has copied, or would have copied in a dry run.
+type Report struct {
+	Keys     int    // the number of keys copied
+	Contents int    // the number of key-source pairs copied
+	Bytes    int64  // the size of the copied contents, before compression
+	Cursor   string // the cursor after the last copied page, which is empty once every page is copied
+	// IsAlreadyCompleted tells that the checkpoint says the migration was completed by a previous run,
+	// in which case nothing is copied.
+	IsAlreadyCompleted bool
+}
+
+// Migration copies every key & source from one event store to another, e.g. from an InMemoryStore prototype to GCS,
+// or between buckets, folders or compressors: contents are decompressed by the source store and compressed again by
+// the destination store, so the destination may use another compressor.
+//
+// The keys are copied page by page, and the Checkpoint is saved after each page, so that an interrupted migration
+// resumes from the last copied page. Copying a content twice persists it again, so the destination should tolerate
+// duplicates, like GCSEventStore does by naming the objects by the sha256 of their contents.
+// TTLs aren't copied: contents that expire in the source store don't expire in the destination store,
+// unless the destination store is configured with a TTL.
+type Migration struct {
+	source      storage.EventStore
+	destination storage.EventStore
+	keys        []string
+	prefix      string
+	pageSize    int
+	concurrency int
+	isDryRun    bool
+	checkpoint  Checkpoint
+	onProgress  func(report Report)
+}
+
+// New creates a Migration from the source to the destination, that lists the keys of the source 100 at a time,
+// and copies them one at a time. The source must implement storage.KeyListingEventStore unless WithKeys is used.
+func New(source, destination storage.EventStore) *Migration {
+	return &Migration{
+		source:      source,
+		destination: destination,
+		pageSize:    100,
+		concurrency: 1,
+		checkpoint:  noCheckpoint{},
+		onProgress:  func(Report) {},
+	}
+}
+
+// WithKeys copies the given keys instead of listing the keys of the source, e.g. for sources that can't list them.
+func (m *Migration) WithKeys(keys []string) *Migration {
+	m.keys = keys
+
+	return m
+}
+
+// WithPrefix only copies the keys that start with the prefix.
+func (m *Migration) WithPrefix(prefix string) *Migration {
+	m.prefix = prefix
+
+	return m
+}
+
+// WithPageSize sets the number of keys copied between checkpoints.
+func (m *Migration) WithPageSize(pageSize int) *Migration {
+	m.pageSize = pageSize
+
+	return m
+}
+
+// WithConcurrency sets the maximum number of keys of a page copied concurrently.
+func (m *Migration) WithConcurrency(concurrency int) *Migration {
+	m.concurrency = concurrency
+
+	return m
+}
+
+// WithDryRun reads everything the migration would copy without writing to the destination or saving checkpoints,
+// e.g. to check the access to the source store and the size of the migration.
+func (m *Migration) WithDryRun() *Migration {
+	m.isDryRun = true
+
+	return m
+}
+
+// WithCheckpoint saves the progress of the migration, so that running it again resumes from the last copied page.
+func (m *Migration) WithCheckpoint(checkpoint Checkpoint) *Migration {
+	m.checkpoint = checkpoint
+
+	return m
+}
+
+// WithProgress calls onProgress with the report so far after every copied page.
+func (m *Migration) WithProgress(onProgress func(report Report)) *Migration {
+	m.onProgress = onProgress
+
+	return m
+}
+
+// Run copies the keys from the checkpoint on, and returns what it has copied, even if it fails midway.
+// If the checkpoint says the migration is completed, Run copies nothing.
+func (m *Migration) Run(ctx context.Context) (Report, error) {
+	listKeys, err := m.keyLister()
+	if err != nil {
+		return Report{}, err
+	}
+	progress, err := m.checkpoint.Load(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to load the checkpoint: %w", err)
+	}
+	if progress.IsCompleted {
+		return Report{IsAlreadyCompleted: true}, nil
+	}
+	report := Report{Cursor: progress.Cursor}
+	for {
+		keys, nextCursor, err := listKeys(ctx, report.Cursor)
+		if err != nil {
+			return report, fmt.Errorf("failed to list the keys from cursor '%s': %w", report.Cursor, err)
+		}
+		pageReport, err := m.copyKeys(ctx, keys)
+		report.Keys += pageReport.Keys
+		report.Contents += pageReport.Contents
+		report.Bytes += pageReport.Bytes
+		if err != nil {
+			return report, err
+		}
+		report.Cursor = nextCursor
+		if !m.isDryRun {
+			if err = m.checkpoint.Save(ctx, Progress{Cursor: nextCursor, IsCompleted: nextCursor == ""}); err != nil {
+				return report, fmt.Errorf("failed to save the checkpoint: %w", err)
+			}
+		}
+		m.onProgress(report)
+		if nextCursor == "" {
+			return report, nil
+		}
+	}
+}
+
+// keyLister returns the function that lists a page of keys from a cursor.
+func (m *Migration) keyLister() (func(ctx context.Context, cursor string) ([]string, string, error), error) {
+	pageSize := max(m.pageSize, 1)
+	if m.keys != nil {
+		return func(_ context.Context, cursor string) ([]string, string, error) {
+			return m.pageOfKeys(cursor, pageSize)
+		}, nil
+	}
+	keyListingStore, isKeyListing := m.source.(storage.KeyListingEventStore)
+	if !isKeyListing {
+		return nil, ErrKeyListingUnsupported
+	}
+
+	return func(ctx context.Context, cursor string) ([]string, string, error) {
+		return keyListingStore.ListKeys(ctx, m.prefix, cursor, pageSize)
+	}, nil
+}
+
+// pageOfKeys returns a page of the given keys that start with the prefix, where the cursor is the index of the next key.
+func (m *Migration) pageOfKeys(cursor string, pageSize int) ([]string, string, error) {
+	index := 0
+	if cursor != "" {
+		var err error
+		index, err = strconv.Atoi(cursor)
+		if err != nil || index < 0 || index > len(m.keys) {
+			return nil, "", fmt.Errorf("cursor '%s' isn't an index of the keys", cursor)
+		}
+	}
+	keys := make([]string, 0, pageSize)
+	for ; index < len(m.keys) && len(keys) < pageSize; index++ {
+		if strings.HasPrefix(m.keys[index], m.prefix) {
+			keys = append(keys, m.keys[index])
+		}
+	}
+	if index == len(m.keys) {
+		return keys, "", nil
+	}
+
+	return keys, strconv.Itoa(index), nil
+}
+
+// copyKeys copies the keys with at most m.concurrency keys in flight, and returns the errors of every failed key.
+func (m *Migration) copyKeys(ctx context.Context, keys []string) (Report, error) {
+	reports := make([]Report, len(keys))
+	errs := make([]error, len(keys))
+	indices := make(chan int)
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < min(max(m.concurrency, 1), len(keys)); worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indices {
+				reports[index], errs[index] = m.copyKey(ctx, keys[index])
+			}
+		}()
+	}
+	for index := range keys {
+		indices <- index
+	}
+	close(indices)
+	waitGroup.Wait()
+
+	var report Report
+	for index := range keys {
+		report.Keys += reports[index].Keys
+		report.Contents += reports[index].Contents
+		report.Bytes += reports[index].Bytes
+	}
+
+	return report, errors.Join(errs...)
+}
+
+func (m *Migration) copyKey(ctx context.Context, key string) (Report, error) {
+	messages, err := m.source.LookUpByKey(ctx, key)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to look up key %s: %w", key, err)
+	}
+	report := Report{}
+	for _, message := range messages {
+		if !m.isDryRun {
+			err = m.destination.Persist(ctx, key, message.GetSource(), message.GetContent())
+			if err != nil {
+				return report, fmt.Errorf("failed to persist key %s & source %s: %w", key, message.GetSource(), err)
+			}
+		}
+		report.Contents++
+		report.Bytes += int64(len(message.GetContent()))
+	}
+	if len(messages) > 0 {
+		report.Keys++
+	}
+
+	return report, nil
+}
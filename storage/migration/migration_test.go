@@ -0,0 +1,151 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/migration"
+)
+
+// failingStore fails to persist the given key.
+type failingStore struct {
+	*storage.InMemoryStore
+	failingKey string
+}
+
+func (f failingStore) Persist(ctx context.Context, key, source, content string) error {
+	if key == f.failingKey {
+		return errors.New("failed to persist")
+	}
+
+	return f.InMemoryStore.Persist(ctx, key, source, content)
+}
+
+func TestMigration(t *testing.T) {
+	ctx := context.TODO()
+	source := storage.NewInMemoryStore()
+	for index := 0; index < 10; index++ {
+		key := "key" + strconv.Itoa(index)
+		assert.NoError(t, source.Persist(ctx, key, "source1", "content1-"+key))
+		assert.NoError(t, source.Persist(ctx, key, "source2", "content2-"+key))
+	}
+
+	t.Run("copies every key & source", func(t *testing.T) {
+		destination := storage.NewInMemoryStore()
+		reports := make([]migration.Report, 0)
+		report, err := migration.New(source, destination).
+			WithPageSize(3).
+			WithConcurrency(2).
+			WithProgress(func(report migration.Report) { reports = append(reports, report) }).
+			Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Report{Keys: 10, Contents: 20, Bytes: 260}, report)
+		assert.Len(t, reports, 4)
+		assert.Equal(t, migration.Report{Keys: 3, Contents: 6, Bytes: 78, Cursor: "key2"}, reports[0])
+		for index := 0; index < 10; index++ {
+			key := "key" + strconv.Itoa(index)
+			messages, err := destination.LookUpByKey(ctx, key)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, []*event.Message{
+				event.NewMessage(key, "source1", "content1-"+key),
+				event.NewMessage(key, "source2", "content2-"+key),
+			}, messages)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		destination := storage.NewInMemoryStore()
+		report, err := migration.New(source, destination).WithPrefix("key1").Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Keys)
+		keys, _, err := destination.ListKeys(ctx, "", "", 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"key1"}, keys)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		destination := storage.NewInMemoryStore()
+		checkpoint := migration.FileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+		report, err := migration.New(source, destination).WithDryRun().WithCheckpoint(checkpoint).Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Report{Keys: 10, Contents: 20, Bytes: 260}, report)
+		keys, _, err := destination.ListKeys(ctx, "", "", 100)
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+		progress, err := checkpoint.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Progress{}, progress)
+	})
+
+	t.Run("resume from the checkpoint", func(t *testing.T) {
+		checkpoint := migration.FileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+		destination := failingStore{InMemoryStore: storage.NewInMemoryStore(), failingKey: "key5"}
+		report, err := migration.New(source, destination).WithPageSize(2).WithCheckpoint(checkpoint).Run(ctx)
+		assert.ErrorContains(t, err, "failed to persist key key5 & source source")
+		assert.Equal(t, "key3", report.Cursor)
+		progress, err := checkpoint.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Progress{Cursor: "key3"}, progress)
+
+		// the page of the failed key is copied again
+		destination.failingKey = ""
+		report, err = migration.New(source, destination).WithPageSize(2).WithCheckpoint(checkpoint).Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Report{Keys: 6, Contents: 12, Bytes: 156}, report)
+		progress, err = checkpoint.Load(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Progress{IsCompleted: true}, progress)
+		for index := 0; index < 10; index++ {
+			sources, err := destination.ListSourcesByKey(ctx, fmt.Sprintf("key%d", index))
+			assert.NoError(t, err)
+			assert.Len(t, sources, 2)
+		}
+
+		// a completed migration isn't copied again
+		destination.failingKey = "key0"
+		report, err = migration.New(source, destination).WithPageSize(2).WithCheckpoint(checkpoint).Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, migration.Report{IsAlreadyCompleted: true}, report)
+	})
+
+	t.Run("given keys", func(t *testing.T) {
+		// hide ListKeys of the InMemoryStore
+		inMemoryStore := struct{ storage.EventStore }{storage.NewInMemoryStore()}
+		assert.NoError(t, inMemoryStore.Persist(ctx, "a1", "source", "content"))
+		assert.NoError(t, inMemoryStore.Persist(ctx, "a2", "source", "content"))
+		assert.NoError(t, inMemoryStore.Persist(ctx, "b1", "source", "content"))
+		_, err := migration.New(inMemoryStore, storage.NewInMemoryStore()).Run(ctx)
+		assert.ErrorIs(t, err, migration.ErrKeyListingUnsupported)
+
+		destination := storage.NewInMemoryStore()
+		cursors := make([]string, 0)
+		report, err := migration.New(inMemoryStore, destination).
+			WithKeys([]string{"a1", "b1", "unknown", "a2"}).
+			WithPrefix("a").
+			WithPageSize(1).
+			WithProgress(func(report migration.Report) { cursors = append(cursors, report.Cursor) }).
+			Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Keys)
+		assert.Equal(t, []string{"1", ""}, cursors)
+		message, err := destination.LookUp(ctx, "a2", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("a2", "source", "content"), message)
+		message, err = destination.LookUp(ctx, "b1", "source")
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		checkpoint := migration.FileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+		assert.NoError(t, checkpoint.Save(ctx, migration.Progress{Cursor: "not an index"}))
+		_, err = migration.New(inMemoryStore, destination).WithKeys([]string{"a1"}).WithCheckpoint(checkpoint).Run(ctx)
+		assert.Error(t, err)
+	})
+}
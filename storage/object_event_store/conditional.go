@@ -0,0 +1,105 @@
+package object_event_store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+)
+
+// ConditionalObjectEventStore is an ObjectEventStore on a ConditionalObjectStore,
+// which implements storage.ConditionalEventStore with the conditional writes of the ObjectStore.
+type ConditionalObjectEventStore struct {
+	*ObjectEventStore
+	objects ConditionalObjectStore
+}
+
+// NewConditional creates a ConditionalObjectEventStore on the given ConditionalObjectStore.
+func NewConditional(cfg *ObjectConfig, objects ConditionalObjectStore) (*ConditionalObjectEventStore, error) {
+	eventStore, err := newObjectEventStore(cfg, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConditionalObjectEventStore{
+		ObjectEventStore: eventStore,
+		objects:          objects,
+	}, nil
+}
+
+// LookUpVersion returns the message chosen by the ReadPolicy, and the generation of its object as the version.
+func (g *ConditionalObjectEventStore) LookUpVersion(ctx context.Context, key, source string) (*event.Message, storage.Version, error) {
+	readRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ReadContent)
+	defer cancel()
+
+	content, object, err := g.read(readRequestCtx, key, source)
+	if err != nil || object == nil {
+		return nil, storage.NoVersion, err
+	}
+
+	return event.NewMessage(key, source, string(content)), storage.Version(object.Generation), nil
+}
+
+// PersistIfAbsent uploads the message only if there is no unexpired object on the path `folder/key/source`.
+func (g *ConditionalObjectEventStore) PersistIfAbsent(ctx context.Context, key, source, content string) (bool, error) {
+	return g.CompareAndPersist(ctx, key, source, content, storage.NoVersion)
+}
+
+// CompareAndPersist uploads the message only if the object chosen by the ReadPolicy still has the given generation.
+// Conditional writes go to the fixed object `folder/key/source/head` with preconditions on its generation,
+// so concurrent conditional writers can't both succeed. The objects superseded by the head are removed afterwards,
+// otherwise the ReadPolicy may keep choosing them. Note that an unconditional Persist in between isn't detected.
+func (g *ConditionalObjectEventStore) CompareAndPersist(
+	ctx context.Context,
+	key, source, content string,
+	version storage.Version) (bool, error) {
+	writeRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, WriteContent)
+	defer cancel()
+
+	object, objects, err := g.chooseObject(writeRequestCtx, key, source)
+	if err != nil {
+		return false, err
+	}
+	currentVersion := storage.NoVersion
+	if object != nil {
+		currentVersion = storage.Version(object.Generation)
+	}
+	if currentVersion != version {
+		return false, nil
+	}
+
+	headName := g.cfg.KeyLayout.sourcePath(g.cfg.Folder, key, source) + "/" + headObjectName
+	conditions := Conditions{DoesNotExist: true}
+	for _, object := range objects {
+		if object.Name == headName { // the head may exist but be expired
+			conditions = Conditions{GenerationMatch: object.Generation}
+		}
+	}
+	compressedContent, err := g.cfg.Compressor.Compress([]byte(content))
+	if err != nil {
+		return false, err
+	}
+	attrs := g.newObjectAttrs(key, source, content, g.cfg.TTL)
+	attrs.Name = headName
+	err = g.objects.WriteIf(writeRequestCtx, attrs, compressedContent, conditions)
+	if errors.Is(err, ErrPreconditionFailed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, object := range objects {
+		if object.Name == headName {
+			continue
+		}
+		err = g.objects.Delete(writeRequestCtx, object.Name)
+		if err != nil && !errors.Is(err, ErrObjectNotExist) {
+			return true, fmt.Errorf("persisted, but failed to remove superseded object %s: %w", object.Name, err)
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,146 @@
+package object_event_store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+type Operation string
+
+const (
+	ListContents  Operation = "ListContents"  // operation that lists all content associated with a given key
+	ReadContent   Operation = "ReadContent"   // operation that reads content associated with a key-source pair
+	WriteContent  Operation = "WriteContent"  // operation that writes content associated with a key-source pair
+	DeleteContent Operation = "DeleteContent" // operation that deletes all content associated with a key or a key-source pair
+)
+
+type ObjectConfig struct {
+	Compressor  compression.Compressor
+	Concurrency int // the maximum number of sources read concurrently by LookUpByKey
+	Folder      *string
+	KeyLayout   KeyLayout                                           // the paths of the objects, see KeyLayout
+	Logger      *slog.Logger                                        // logs the failures that don't fail the operations
+	Metadata    func(key, source, content string) map[string]string // sets the metadata of the written objects
+	ReadPolicy  ReadPolicy
+	Timeout     Timeout
+	TTL         *time.Duration // contents expire after TTL if configured, see ObjectAttrs.ExpiresAt
+}
+
+type Timeout struct {
+	Default   *time.Duration              // the default timeout for all operations
+	Operation map[Operation]time.Duration // the timeout of each operation - this overrides the default timeout
+}
+
+// Config creates a default configuration, that
+// - doesn't do compression/decompression when write & read the objects
+// - takes the earliest created object if there are multiple under the same key/source/ path
+// - enforces universal 30s timeout in the requests to the ObjectStore
+// - reads up to 8 sources concurrently in LookUpByKey
+func Config() *ObjectConfig {
+	halfMinute := 30 * time.Second
+
+	return &ObjectConfig{
+		Compressor:  compression.Noop(),
+		Concurrency: 8,
+		ReadPolicy:  TakeFirstCreated(),
+		Timeout: Timeout{
+			Default:   &halfMinute,
+			Operation: make(map[Operation]time.Duration),
+		},
+	}
+}
+
+func (c *ObjectConfig) WithCompressor(compressor compression.Compressor) *ObjectConfig {
+	c.Compressor = compressor
+
+	return c
+}
+
+// WithConcurrency sets the maximum number of sources read concurrently by LookUpByKey, where 1 reads them sequentially.
+func (c *ObjectConfig) WithConcurrency(concurrency int) *ObjectConfig {
+	c.Concurrency = concurrency
+
+	return c
+}
+
+func (c *ObjectConfig) WithFolder(folder string) *ObjectConfig {
+	c.Folder = &folder
+
+	return c
+}
+
+// WithKeyLayout sets how the keys & sources are mapped to the paths of the objects, e.g.
+//
+//	WithKeyLayout(KeyLayout{Encoding: EscapedKeyEncoding(), ShardPrefixSize: 2, ReadLegacyLayout: true})
+//
+// escapes the keys & sources, shards the keys over 256 prefixes, and keeps reading the objects written before.
+func (c *ObjectConfig) WithKeyLayout(keyLayout KeyLayout) *ObjectConfig {
+	c.KeyLayout = keyLayout
+
+	return c
+}
+
+// WithLogger sets the logger of the failures that don't fail the operations, e.g. of CollectGarbage.
+func (c *ObjectConfig) WithLogger(logger *slog.Logger) *ObjectConfig {
+	c.Logger = logger
+
+	return c
+}
+
+// WithMetadata sets the metadata of each written object from its key, source and content,
+// e.g. the sequence number of the event for TakeHighestSequence.
+func (c *ObjectConfig) WithMetadata(metadata func(key, source, content string) map[string]string) *ObjectConfig {
+	c.Metadata = metadata
+
+	return c
+}
+
+func (c *ObjectConfig) WithReadPolicy(readPolicy ReadPolicy) *ObjectConfig {
+	c.ReadPolicy = readPolicy
+
+	return c
+}
+
+// WithTTL makes the contents expire after the given TTL, by setting the expiry time of each object.
+// Expired objects are skipped on read, but it's up to the ObjectStore to actually delete them.
+func (c *ObjectConfig) WithTTL(ttl time.Duration) *ObjectConfig {
+	c.TTL = &ttl
+
+	return c
+}
+
+func (c *ObjectConfig) WithTimeout(timeout Timeout) *ObjectConfig {
+	c.Timeout = timeout
+
+	return c
+}
+
+// NewContextWithTimeout generates a new context.Context with timeout from the parent context.
+// If neither operation-specific timeout nor default timeout is found, return parent context without any operation.
+func (c *ObjectConfig) NewContextWithTimeout(
+	parent context.Context,
+	operation Operation) (context.Context, context.CancelFunc) {
+	if operationTimeout, isConfigured := c.Timeout.Operation[operation]; isConfigured {
+		return context.WithTimeout(parent, operationTimeout)
+	}
+	if c.Timeout.Default != nil {
+		return context.WithTimeout(parent, *c.Timeout.Default)
+	}
+
+	return parent, noop
+}
+
+// logger returns the configured logger, or the default one.
+func (c *ObjectConfig) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+
+	return c.Logger
+}
+
+func noop() {
+}
@@ -0,0 +1,35 @@
+package object_event_store
+
+import (
+	"time"
+)
+
+// unexpiredObjects wraps an ObjectIterator to skip the objects whose expiry time has passed.
+type unexpiredObjects struct {
+	objectIterator ObjectIterator
+	now            time.Time
+}
+
+func skipExpired(objectIterator ObjectIterator) ObjectIterator {
+	return &unexpiredObjects{
+		objectIterator: objectIterator,
+		now:            time.Now(),
+	}
+}
+
+func (u *unexpiredObjects) Next() (*ObjectAttrs, error) {
+	for {
+		object, err := u.objectIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if !isExpired(object, u.now) {
+			return object, nil
+		}
+	}
+}
+
+// isExpired tells whether the expiry time of the object has passed.
+func isExpired(object *ObjectAttrs, now time.Time) bool {
+	return !object.ExpiresAt.IsZero() && !now.Before(object.ExpiresAt)
+}
@@ -0,0 +1,124 @@
+package object_event_store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// KeyEncoding maps the keys & sources to the components of the object paths, and back.
+// An encoded key or source must not contain "/".
+type KeyEncoding interface {
+	Encode(component string) string
+	Decode(encoded string) (string, error)
+}
+
+type rawKeyEncoding struct{}
+
+// RawKeyEncoding writes the keys & sources as they are, minus their leading & trailing slashes,
+// which is the original layout. Keys & sources containing "/" can't be read back.
+func RawKeyEncoding() KeyEncoding {
+	return rawKeyEncoding{}
+}
+
+func (r rawKeyEncoding) Encode(component string) string {
+	return strings.Trim(component, "/")
+}
+
+func (r rawKeyEncoding) Decode(encoded string) (string, error) {
+	return encoded, nil
+}
+
+type escapedKeyEncoding struct{}
+
+// EscapedKeyEncoding path-escapes the keys & sources, e.g. "a/b" is written as "a%2Fb",
+// so that any key or source is read back as it was written.
+func EscapedKeyEncoding() KeyEncoding {
+	return escapedKeyEncoding{}
+}
+
+func (e escapedKeyEncoding) Encode(component string) string {
+	return url.PathEscape(component)
+}
+
+func (e escapedKeyEncoding) Decode(encoded string) (string, error) {
+	return url.PathUnescape(encoded)
+}
+
+// KeyLayout configures the paths of the objects, which are `folder/key/source/` by default.
+type KeyLayout struct {
+	Encoding KeyEncoding // RawKeyEncoding if nil
+	// ShardPrefixSize is the number of hex characters of the sha256 of the key that prefix the key in the paths,
+	// i.e. `folder/shard/key/source/`, so that sequential keys are spread over the key space of the ObjectStore
+	// instead of hotspotting a single range, e.g. 2 spreads the keys over 256 prefixes. 0 disables sharding.
+	ShardPrefixSize int
+	// ReadLegacyLayout makes reads & deletes also cover the objects of the original `folder/key/source/` layout,
+	// so that a store moves to another layout without rewriting its objects. Writes only go to the new layout.
+	ReadLegacyLayout bool
+}
+
+// legacyLayout is the original layout of the objects.
+var legacyLayout = KeyLayout{Encoding: RawKeyEncoding()}
+
+// layouts returns the layout to write to, followed by the legacy layout if it's read too.
+func (k KeyLayout) layouts() []KeyLayout {
+	_, isRaw := k.encoding().(rawKeyEncoding)
+	if !k.ReadLegacyLayout || (isRaw && k.ShardPrefixSize <= 0) {
+		return []KeyLayout{k}
+	}
+
+	return []KeyLayout{k, legacyLayout}
+}
+
+// keyLayouts returns the layouts to read the key from, without the legacy layout if it maps the key to the same path,
+// e.g. an escaped key with nothing to escape, whose objects would otherwise be listed twice.
+func (k KeyLayout) keyLayouts(folder *string, key string) []KeyLayout {
+	layouts := k.layouts()
+	if len(layouts) > 1 && layouts[1].keyPath(folder, key) == k.keyPath(folder, key) {
+		return layouts[:1]
+	}
+
+	return layouts
+}
+
+func (k KeyLayout) encoding() KeyEncoding {
+	if k.Encoding == nil {
+		return RawKeyEncoding()
+	}
+
+	return k.Encoding
+}
+
+// keyPath returns the path `folder/shard/key` of the key.
+func (k KeyLayout) keyPath(folder *string, key string) string {
+	components := make([]string, 0, 2)
+	if k.ShardPrefixSize > 0 {
+		sha := sha256.Sum256([]byte(key))
+		shard := hex.EncodeToString(sha[:])
+		components = append(components, shard[:min(k.ShardPrefixSize, len(shard))])
+	}
+
+	return composePath(folder, append(components, k.encoding().Encode(key))...)
+}
+
+// sourcePath returns the path `folder/shard/key/source` of the key-source pair.
+func (k KeyLayout) sourcePath(folder *string, key, source string) string {
+	return k.keyPath(folder, key) + "/" + k.encoding().Encode(source)
+}
+
+// isShard reports whether the path component is shaped like a shard of the layout.
+func (k KeyLayout) isShard(component string) bool {
+	return k.ShardPrefixSize > 0 && len(component) == min(k.ShardPrefixSize, sha256.Size*2) &&
+		strings.Trim(component, "0123456789abcdef") == ""
+}
+
+// encodePrefix encodes a prefix of keys as the prefix of their encoded keys.
+// Escaping is done character by character, so it maps the prefix of a key to the prefix of the escaped key.
+func (k KeyLayout) encodePrefix(prefix string) string {
+	if _, isRaw := k.encoding().(rawKeyEncoding); isRaw {
+		return strings.TrimLeft(prefix, "/")
+	}
+
+	return k.encoding().Encode(prefix)
+}
@@ -0,0 +1,221 @@
+package object_event_store_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+func TestKeyEncoding(t *testing.T) {
+	for _, component := range []string{"key", "a/b", "/leading", "100%", "with space", "ключ"} {
+		escaped := object_event_store.EscapedKeyEncoding().Encode(component)
+		assert.NotContains(t, escaped, "/")
+		decoded, err := object_event_store.EscapedKeyEncoding().Decode(escaped)
+		assert.NoError(t, err)
+		assert.Equal(t, component, decoded)
+	}
+	_, err := object_event_store.EscapedKeyEncoding().Decode("%zz")
+	assert.Error(t, err)
+
+	assert.Equal(t, "a/b", object_event_store.RawKeyEncoding().Encode("/a/b/"))
+}
+
+func TestObjectEventStoreKeyLayout(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("escaped keys & sources", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		config := object_event_store.Config().WithFolder("folder").
+			WithKeyLayout(object_event_store.KeyLayout{Encoding: object_event_store.EscapedKeyEncoding()})
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+
+		assert.NoError(t, eventStore.Persist(ctx, "orders/1", "payments/settled", "content1"))
+		assert.NoError(t, eventStore.Persist(ctx, "orders/1", "refunds", "content2"))
+		assert.NoError(t, eventStore.Persist(ctx, "orders", "1/payments/settled", "not the same"))
+		names := collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder/orders%2F1/"}))
+		assert.Len(t, names, 2)
+		assert.True(t, strings.HasPrefix(names[0], "folder/orders%2F1/payments%2Fsettled/"))
+
+		sources, err := eventStore.ListSourcesByKey(ctx, "orders/1")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"payments/settled", "refunds"}, sources)
+		message, err := eventStore.LookUp(ctx, "orders/1", "payments/settled")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("orders/1", "payments/settled", "content1"), message)
+		messages, err := eventStore.LookUpByKey(ctx, "orders/1")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{
+			event.NewMessage("orders/1", "payments/settled", "content1"),
+			event.NewMessage("orders/1", "refunds", "content2")},
+			messages)
+
+		assert.NoError(t, eventStore.DeleteByKey(ctx, "orders/1"))
+		assert.Empty(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder/orders%2F1/"})))
+		message, err = eventStore.LookUp(ctx, "orders", "1/payments/settled")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("orders", "1/payments/settled", "not the same"), message)
+	})
+
+	t.Run("sharded keys", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		config := object_event_store.Config().WithFolder("folder").
+			WithKeyLayout(object_event_store.KeyLayout{ShardPrefixSize: 2})
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source", "content"))
+		assert.Len(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder/" + shardOf("key", 2) + "/key/source/"})), 1)
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content"), message)
+		sources, err := eventStore.ListSourcesByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"source"}, sources)
+	})
+
+	t.Run("read the legacy layout", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		legacyEventStore, err := object_event_store.New(object_event_store.Config(), objects)
+		assert.NoError(t, err)
+		assert.NoError(t, legacyEventStore.Persist(ctx, "key", "source1", "legacy content"))
+		assert.NoError(t, legacyEventStore.Persist(ctx, "key", "source2", "legacy content"))
+
+		keyLayout := object_event_store.KeyLayout{
+			Encoding:        object_event_store.EscapedKeyEncoding(),
+			ShardPrefixSize: 4,
+		}
+		eventStore, err := object_event_store.New(
+			object_event_store.Config().WithKeyLayout(keyLayout), objects)
+		assert.NoError(t, err)
+		message, err := eventStore.LookUp(ctx, "key", "source1")
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		keyLayout.ReadLegacyLayout = true
+		eventStore, err = object_event_store.New(
+			object_event_store.Config().WithKeyLayout(keyLayout), objects)
+		assert.NoError(t, err)
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source3", "content"))
+		assert.Len(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: shardOf("key", 4) + "/key/source3/"})), 1)
+
+		sources, err := eventStore.ListSourcesByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"source1", "source2", "source3"}, sources)
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{
+			event.NewMessage("key", "source1", "legacy content"),
+			event.NewMessage("key", "source2", "legacy content"),
+			event.NewMessage("key", "source3", "content")},
+			messages)
+		revisions, err := eventStore.(storage.HistoryEventStore).ListRevisions(ctx, "key", "source1")
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 1)
+		message, err = eventStore.(storage.HistoryEventStore).LookUpRevision(ctx, "key", "source1", revisions[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source1", "legacy content"), message)
+
+		// conditional writes move the source to the new layout
+		conditionalEventStore := eventStore.(storage.ConditionalEventStore)
+		isPersisted, err := conditionalEventStore.PersistIfAbsent(ctx, "key", "source1", "content")
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+		_, version, err := conditionalEventStore.LookUpVersion(ctx, "key", "source1")
+		assert.NoError(t, err)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(ctx, "key", "source1", "content", version)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		assert.Empty(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "key/source1/"})))
+		message, err = eventStore.LookUp(ctx, "key", "source1")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source1", "content"), message)
+
+		assert.NoError(t, eventStore.Delete(ctx, "key", "source2"))
+		assert.Empty(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "key/source2/"})))
+		assert.NoError(t, eventStore.DeleteByKey(ctx, "key"))
+		assert.Empty(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: ""})))
+	})
+
+	t.Run("read the legacy layout of plain keys once", func(t *testing.T) {
+		keyLayout := object_event_store.KeyLayout{Encoding: object_event_store.EscapedKeyEncoding(), ReadLegacyLayout: true}
+		objects := object_event_store.NewInMemoryObjectStore()
+		failingEventStore, err := object_event_store.New(object_event_store.Config().WithKeyLayout(keyLayout).
+			WithReadPolicy(object_event_store.FailOnMultiple()), objects)
+		assert.NoError(t, err)
+		mergingEventStore, err := object_event_store.New(object_event_store.Config().WithKeyLayout(keyLayout).
+			WithReadPolicy(object_event_store.MergeConcatenated(",")), objects)
+		assert.NoError(t, err)
+		assert.NoError(t, failingEventStore.Persist(ctx, "key", "source", "x"))
+
+		message, err := failingEventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "x"), message)
+		messages, err := failingEventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage("key", "source", "x")}, messages)
+		message, err = mergingEventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "x"), message)
+		messages, err = mergingEventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage("key", "source", "x")}, messages)
+		revisions, err := failingEventStore.(storage.HistoryEventStore).ListRevisions(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 1)
+
+		// the legacy objects of the sources that are escaped are still read
+		legacyEventStore, err := object_event_store.New(object_event_store.Config(), objects)
+		assert.NoError(t, err)
+		assert.NoError(t, legacyEventStore.Persist(ctx, "key", "a b", "legacy content"))
+		sources, err := failingEventStore.ListSourcesByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a b", "source"}, sources)
+		message, err = failingEventStore.LookUp(ctx, "key", "a b")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "a b", "legacy content"), message)
+	})
+
+	t.Run("legacy keys named like shards", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		legacyEventStore, err := object_event_store.New(object_event_store.Config(), objects)
+		assert.NoError(t, err)
+		shard := shardOf("key", 2)
+		assert.NoError(t, legacyEventStore.Persist(ctx, shard, "key", "legacy content"))
+
+		eventStore, err := object_event_store.New(object_event_store.Config().
+			WithKeyLayout(object_event_store.KeyLayout{ShardPrefixSize: 2, ReadLegacyLayout: true}), objects)
+		assert.NoError(t, err)
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source", "content"))
+
+		sources, err := eventStore.ListSourcesByKey(ctx, shard)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"key"}, sources)
+		sources, err = eventStore.ListSourcesByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"source"}, sources)
+		messages, err := eventStore.LookUpByKey(ctx, shard)
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage(shard, "key", "legacy content")}, messages)
+
+		assert.NoError(t, eventStore.DeleteByKey(ctx, shard))
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content"), message)
+	})
+}
+
+// shardOf returns the shard of the key, i.e. the first hex characters of its sha256.
+func shardOf(key string, size int) string {
+	sha := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sha[:])[:size]
+}
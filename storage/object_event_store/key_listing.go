@@ -0,0 +1,193 @@
+package object_event_store
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ListKeys lists the keys with delimiter listings of the folder, so that the objects of the keys aren't listed.
+// The keys come in the order of their paths, i.e. by shard first if the keys are sharded, then by encoded key,
+// and the cursor is the path of the last listed key. With KeyLayout.ReadLegacyLayout, the keys only found
+// in the legacy layout come after the others, which costs a listing per key to tell the layouts apart.
+// Keys whose objects have all expired are listed until the ObjectStore deletes the objects.
+func (g *ObjectEventStore) ListKeys(ctx context.Context, prefix, cursor string, limit int) ([]string, string, error) {
+	listRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ListContents)
+	defer cancel()
+
+	keys := make([]string, 0)
+	paths := make([]string, 0)
+	err := g.walkKeys(listRequestCtx, prefix, cursor, func(key, path string) bool {
+		keys = append(keys, key)
+		paths = append(paths, path)
+
+		return limit > 0 && len(keys) > limit
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 || len(keys) <= limit {
+		return keys, "", nil
+	}
+
+	return keys[:limit], paths[limit-1], nil
+}
+
+// walkKeys calls visit with the keys after the cursor and their paths relative to the folder,
+// until visit returns true.
+func (g *ObjectEventStore) walkKeys(
+	ctx context.Context,
+	prefix, cursor string,
+	visit func(key, path string) bool) error {
+	keyLayout := g.cfg.KeyLayout
+	isReadingLegacyLayout := len(keyLayout.layouts()) > 1
+	if keyLayout.ShardPrefixSize <= 0 {
+		// the legacy keys share the paths of the keys, and are read back as they are if they can't be decoded
+		_, err := g.walkLayoutKeys(ctx, keyLayout, "", prefix, cursor, isReadingLegacyLayout,
+			func(key, path string) (bool, error) {
+				return visit(key, path), nil
+			})
+
+		return err
+	}
+
+	// the cursor of a sharded key is `shard/key`, while the cursor of a legacy key has no shard
+	if cursor == "" || strings.Contains(cursor, "/") {
+		isDone, err := g.walkShardedKeys(ctx, prefix, cursor, isReadingLegacyLayout, visit)
+		if err != nil || isDone {
+			return err
+		}
+		cursor = ""
+	}
+	if !isReadingLegacyLayout {
+		return nil
+	}
+	_, err := g.walkLayoutKeys(ctx, legacyLayout, "", prefix, cursor, false, func(key, path string) (bool, error) {
+		// skip the shards, and the keys already listed in the sharded layout
+		if keyLayout.isShard(path) {
+			legacyObjectsBySource, err := g.listLayoutObjectsBySource(ctx, legacyLayout, key)
+			if err != nil || len(legacyObjectsBySource) == 0 {
+				return false, err
+			}
+		}
+		objectsBySource, err := g.listLayoutObjectsBySource(ctx, keyLayout, key)
+		if err != nil || len(objectsBySource) > 0 {
+			return false, err
+		}
+
+		return visit(key, path), nil
+	})
+
+	return err
+}
+
+// walkShardedKeys calls visit with the keys of the sharded layout after the cursor, shard by shard.
+func (g *ObjectEventStore) walkShardedKeys(
+	ctx context.Context,
+	prefix, cursor string,
+	isReadingLegacyLayout bool,
+	visit func(key, path string) bool) (bool, error) {
+	keyLayout := g.cfg.KeyLayout
+	cursorShard, _, _ := strings.Cut(cursor, "/")
+	shards, err := g.listPrefixes(ctx, &Query{
+		Prefix:      g.folderPrefix(),
+		Delimiter:   "/",
+		StartOffset: g.folderPrefix() + cursorShard,
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, shard := range shards {
+		if !keyLayout.isShard(shard) {
+			continue
+		}
+		shardCursor := ""
+		if shard == cursorShard {
+			shardCursor = cursor
+		}
+		isDone, err := g.walkLayoutKeys(ctx, keyLayout, shard+"/", prefix, shardCursor, false,
+			func(key, path string) (bool, error) {
+				// skip the sources of the legacy keys named like shards
+				if keyLayout.keyPath(g.cfg.Folder, key) != g.folderPrefix()+path {
+					return false, nil
+				}
+				if isReadingLegacyLayout {
+					objectsBySource, err := g.listLayoutObjectsBySource(ctx, keyLayout, key)
+					if err != nil || len(objectsBySource) == 0 {
+						return false, err
+					}
+				}
+
+				return visit(key, path), nil
+			})
+		if err != nil || isDone {
+			return isDone, err
+		}
+	}
+
+	return false, nil
+}
+
+// walkLayoutKeys calls visit with the keys right under the parent path of a layout, after the cursor.
+func (g *ObjectEventStore) walkLayoutKeys(
+	ctx context.Context,
+	layout KeyLayout,
+	parent, prefix, cursor string,
+	isRawFallback bool,
+	visit func(key, path string) (bool, error)) (bool, error) {
+	query := &Query{
+		Prefix:    g.folderPrefix() + parent + layout.encodePrefix(prefix),
+		Delimiter: "/",
+	}
+	if cursor != "" {
+		// skip the objects under `cursor/`, as "0" follows "/"
+		query.StartOffset = g.folderPrefix() + cursor + "0"
+	}
+	objectIterator := g.objects.List(ctx, query)
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, ErrNoMoreObjects) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if object.Prefix == "" { // an object right under the parent, e.g. of a legacy key named like a shard
+			continue
+		}
+		encodedKey := strings.TrimSuffix(strings.TrimPrefix(object.Prefix, g.folderPrefix()+parent), "/")
+		key, err := layout.encoding().Decode(encodedKey)
+		if err != nil && !isRawFallback {
+			return false, err
+		}
+		if err != nil {
+			key = encodedKey
+		}
+		if isDone, err := visit(key, parent+encodedKey); err != nil || isDone {
+			return isDone, err
+		}
+	}
+}
+
+// listPrefixes returns the names of the prefixes listed by the query with a delimiter, relative to query.Prefix.
+func (g *ObjectEventStore) listPrefixes(ctx context.Context, query *Query) ([]string, error) {
+	objectIterator := g.objects.List(ctx, query)
+	prefixes := make([]string, 0)
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, ErrNoMoreObjects) {
+			return prefixes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if object.Prefix != "" {
+			prefixes = append(prefixes, strings.TrimSuffix(strings.TrimPrefix(object.Prefix, query.Prefix), query.Delimiter))
+		}
+	}
+}
+
+// folderPrefix returns the path of the folder followed by "/", or "" if there is no folder.
+func (g *ObjectEventStore) folderPrefix() string {
+	return composePath(g.cfg.Folder, "")
+}
@@ -0,0 +1,129 @@
+package object_event_store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+func TestObjectEventStoreListKeys(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("keys under the folder", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		eventStore, err := object_event_store.New(object_event_store.Config().WithFolder("folder"), objects)
+		assert.NoError(t, err)
+		otherEventStore, err := object_event_store.New(object_event_store.Config().WithFolder("other"), objects)
+		assert.NoError(t, err)
+		for _, key := range []string{"order-2", "order-1", "payment-1", "order-10"} {
+			assert.NoError(t, eventStore.Persist(ctx, key, "source1", "content"))
+			assert.NoError(t, eventStore.Persist(ctx, key, "source2", "content"))
+		}
+		assert.NoError(t, otherEventStore.Persist(ctx, "order-3", "source1", "content"))
+		keyListingStore := eventStore.(storage.KeyListingEventStore)
+
+		keys, cursor, err := keyListingStore.ListKeys(ctx, "order-", "", 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order-1", "order-10"}, keys)
+		assert.NotEmpty(t, cursor)
+		keys, cursor, err = keyListingStore.ListKeys(ctx, "order-", cursor, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order-2"}, keys)
+		assert.Empty(t, cursor)
+
+		keys, cursor, err = keyListingStore.ListKeys(ctx, "", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order-1", "order-10", "order-2", "payment-1"}, keys)
+		assert.Empty(t, cursor)
+		keys, _, err = otherEventStore.(storage.KeyListingEventStore).ListKeys(ctx, "", "", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"order-3"}, keys)
+	})
+
+	t.Run("escaped keys", func(t *testing.T) {
+		config := object_event_store.Config().
+			WithKeyLayout(object_event_store.KeyLayout{Encoding: object_event_store.EscapedKeyEncoding()})
+		eventStore, err := object_event_store.New(config, object_event_store.NewInMemoryObjectStore())
+		assert.NoError(t, err)
+		for _, key := range []string{"orders/1", "orders/2", "orders", "payments/1"} {
+			assert.NoError(t, eventStore.Persist(ctx, key, "source", "content"))
+		}
+
+		keys := scan(t, eventStore, "orders/", 1)
+		assert.Equal(t, []string{"orders/1", "orders/2"}, keys)
+		keys = scan(t, eventStore, "", 3)
+		assert.ElementsMatch(t, []string{"orders", "orders/1", "orders/2", "payments/1"}, keys)
+	})
+
+	t.Run("sharded keys", func(t *testing.T) {
+		config := object_event_store.Config().WithFolder("folder").
+			WithKeyLayout(object_event_store.KeyLayout{ShardPrefixSize: 1})
+		eventStore, err := object_event_store.New(config, object_event_store.NewInMemoryObjectStore())
+		assert.NoError(t, err)
+		expectedKeys := make([]string, 0)
+		for index := 0; index < 40; index++ {
+			key := "key-" + string(rune('a'+index%26)) + string(rune('a'+index/26))
+			assert.NoError(t, eventStore.Persist(ctx, key, "source", "content"))
+			expectedKeys = append(expectedKeys, key)
+		}
+		assert.NoError(t, eventStore.Persist(ctx, "other", "source", "content"))
+
+		for _, pageSize := range []int{1, 3, 40, 100} {
+			keys := scan(t, eventStore, "key-", pageSize)
+			assert.ElementsMatch(t, expectedKeys, keys, pageSize)
+			assert.Len(t, keys, 40, pageSize)
+		}
+		assert.Equal(t, []string{"other"}, scan(t, eventStore, "o", 10))
+	})
+
+	t.Run("read the legacy layout", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		legacyEventStore, err := object_event_store.New(object_event_store.Config(), objects)
+		assert.NoError(t, err)
+		shard := shardOf("new", 2)
+		for _, key := range []string{"legacy", "both", shard} {
+			assert.NoError(t, legacyEventStore.Persist(ctx, key, "source", "legacy content"))
+		}
+
+		config := object_event_store.Config().WithKeyLayout(object_event_store.KeyLayout{
+			Encoding:         object_event_store.EscapedKeyEncoding(),
+			ShardPrefixSize:  2,
+			ReadLegacyLayout: true,
+		})
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+		for _, key := range []string{"new", "both", "a/b"} {
+			assert.NoError(t, eventStore.Persist(ctx, key, "source", "content"))
+		}
+
+		for _, pageSize := range []int{1, 2, 10} {
+			keys := scan(t, eventStore, "", pageSize)
+			assert.ElementsMatch(t, []string{"new", "both", "a/b", "legacy", shard}, keys, pageSize)
+		}
+		assert.Equal(t, []string{"legacy"}, scan(t, eventStore, "l", 1))
+
+		// the store of the legacy layout can't tell the shards from its keys
+		keys := scan(t, legacyEventStore, "", 2)
+		assert.ElementsMatch(t, []string{"legacy", "both", shard, shardOf("both", 2), shardOf("a/b", 2)}, keys)
+	})
+}
+
+// scan lists every key starting with the prefix page by page.
+func scan(t *testing.T, eventStore storage.EventStore, prefix string, pageSize int) []string {
+	t.Helper()
+
+	keys := make([]string, 0)
+	err := storage.ScanKeys(context.TODO(), eventStore.(storage.KeyListingEventStore), prefix, pageSize,
+		func(key string) error {
+			keys = append(keys, key)
+
+			return nil
+		})
+	assert.NoError(t, err)
+
+	return keys
+}
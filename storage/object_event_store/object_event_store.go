@@ -0,0 +1,689 @@
+package object_event_store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+// headObjectName is the name of the object written by conditional writes under `folder/key/source/`,
+// which never collides with the base64-encoded sha256 names of unconditional writes.
+const headObjectName = "head"
+
+// pendingObjectPrefix starts the names of the objects being uploaded to a StreamingObjectStore under
+// `folder/key/source/`, which are renamed to the sha256 of their contents once uploaded, and skipped until then.
+// The ones left by crashed writers carry the expiry time of their contents, e.g. for ExpiryLifecycleRule of GCS.
+const pendingObjectPrefix = ".pending-"
+
+// ObjectEventStore persists the contents as objects of a blob storage, e.g. GCS or S3.
+// The `folder/key/source/sha256` layout (see KeyLayout) and the ReadPolicy are applied on top of an ObjectStore,
+// so that any blob storage is plugged in underneath by implementing ObjectStore.
+type ObjectEventStore struct {
+	cfg     *ObjectConfig
+	objects ObjectStore
+}
+
+// PartialLookUpError is returned by LookUpByKey along with the messages it has read,
+// if the objects of some sources failed to be read.
+type PartialLookUpError struct {
+	Key      string
+	Failures map[string]error // the errors by source
+}
+
+func (p *PartialLookUpError) Error() string {
+	sources := make([]string, 0, len(p.Failures))
+	for source := range p.Failures {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	failures := make([]string, 0, len(sources))
+	for _, source := range sources {
+		failures = append(failures, fmt.Sprintf("%s: %v", source, p.Failures[source]))
+	}
+
+	return fmt.Sprintf("failed to look up %d source(s) of key %s: %s",
+		len(sources), p.Key, strings.Join(failures, "; "))
+}
+
+// Unwrap returns the errors of all the failed sources, so that errors.Is and errors.As match any of them.
+func (p *PartialLookUpError) Unwrap() []error {
+	errs := make([]error, 0, len(p.Failures))
+	for _, err := range p.Failures {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// New creates an ObjectEventStore on the given ObjectStore, e.g. NewInMemoryObjectStore for tests.
+// If the ObjectStore is a ConditionalObjectStore, the event store is a ConditionalObjectEventStore.
+func New(cfg *ObjectConfig, objects ObjectStore) (storage.EventStore, error) {
+	if conditionalObjects, isConditional := objects.(ConditionalObjectStore); isConditional {
+		return NewConditional(cfg, conditionalObjects)
+	}
+
+	return newObjectEventStore(cfg, objects)
+}
+
+func newObjectEventStore(cfg *ObjectConfig, objects ObjectStore) (*ObjectEventStore, error) {
+	if cfg == nil {
+		return nil, errors.New("object config cannot be null")
+	}
+	if objects == nil {
+		return nil, errors.New("object store cannot be null")
+	}
+	if garbageCollecting, isGarbageCollecting := cfg.ReadPolicy.(garbageCollecting); isGarbageCollecting {
+		if _, isMerging := garbageCollecting.ReadPolicy.(MergingReadPolicy); isMerging {
+			return nil, ErrGarbageCollectingMerge
+		}
+	}
+
+	return &ObjectEventStore{
+		cfg:     cfg,
+		objects: objects,
+	}, nil
+}
+
+// Delete removes all the objects under the path `folder/key/source/`.
+func (g *ObjectEventStore) Delete(ctx context.Context, key, source string) error {
+	deleteRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, DeleteContent)
+	defer cancel()
+
+	objects, err := g.listSourceObjects(deleteRequestCtx, key, source)
+	if err != nil {
+		return err
+	}
+
+	return g.deleteObjects(deleteRequestCtx, objects)
+}
+
+// DeleteByKey removes all the objects under the path `folder/key/`.
+func (g *ObjectEventStore) DeleteByKey(ctx context.Context, key string) error {
+	deleteRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, DeleteContent)
+	defer cancel()
+
+	objectsBySource, err := g.listObjectsBySource(deleteRequestCtx, key)
+	if err != nil {
+		return err
+	}
+	for _, objects := range objectsBySource {
+		if err = g.deleteObjects(deleteRequestCtx, objects); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *ObjectEventStore) deleteObjects(ctx context.Context, objects []*ObjectAttrs) error {
+	for _, object := range objects {
+		err := g.objects.Delete(ctx, object.Name)
+		if err != nil && !errors.Is(err, ErrObjectNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListSourcesByKey lists the sources under the path `folder/key/` that have unexpired objects, in order.
+// It lists the objects rather than the sources with a delimiter, since the objects tell whether a source has expired.
+func (g *ObjectEventStore) ListSourcesByKey(ctx context.Context, key string) ([]string, error) {
+	listRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ListContents)
+	defer cancel()
+
+	objectsBySource, err := g.listObjectsBySource(listRequestCtx, key)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sources := make([]string, 0, len(objectsBySource))
+	for _, source := range sortedSources(objectsBySource) {
+		for _, object := range objectsBySource[source] {
+			if !isExpired(object, now) {
+				sources = append(sources, source)
+
+				break
+			}
+		}
+	}
+
+	return sources, nil
+}
+
+// LookUp returns a single message by looking up the path `folder/key/source`.
+func (g *ObjectEventStore) LookUp(ctx context.Context, key, source string) (*event.Message, error) {
+	readRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ReadContent)
+	defer cancel()
+
+	content, _, err := g.read(readRequestCtx, key, source)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, nil
+	}
+
+	return event.NewMessage(key, source, string(content)), nil
+}
+
+// LookUpByKey returns a list of messages by listing the prefix `folder/key/` once, then reading the objects chosen
+// by the ReadPolicy of each source concurrently, with at most ObjectConfig.Concurrency reads in flight.
+// If some sources fail to be read, it returns the messages of the other sources along with a *PartialLookUpError.
+func (g *ObjectEventStore) LookUpByKey(ctx context.Context, key string) ([]*event.Message, error) {
+	listRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ListContents)
+	objectsBySource, err := g.listObjectsBySource(listRequestCtx, key)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	sources := sortedSources(objectsBySource)
+
+	results := make([]*event.Message, len(sources))
+	errs := make([]error, len(sources))
+	indices := make(chan int)
+	var waitGroup sync.WaitGroup
+	workers := min(max(g.cfg.Concurrency, 1), len(sources))
+	for worker := 0; worker < workers; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for index := range indices {
+				results[index], errs[index] = g.lookUpObjects(ctx, key, sources[index], objectsBySource[sources[index]])
+			}
+		}()
+	}
+	for index := range sources {
+		indices <- index
+	}
+	close(indices)
+	waitGroup.Wait()
+
+	messages := make([]*event.Message, 0, len(sources))
+	failures := make(map[string]error)
+	for index, source := range sources {
+		if errs[index] != nil {
+			failures[source] = errs[index]
+
+			continue
+		}
+		if results[index] != nil {
+			messages = append(messages, results[index])
+		}
+	}
+	if len(failures) > 0 {
+		return messages, &PartialLookUpError{Key: key, Failures: failures}
+	}
+
+	return messages, nil
+}
+
+// listObjectsBySource lists all the objects under the prefix `folder/key/` in a single pass per layout,
+// grouped by source.
+func (g *ObjectEventStore) listObjectsBySource(ctx context.Context, key string) (map[string][]*ObjectAttrs, error) {
+	objectsBySource := make(map[string][]*ObjectAttrs)
+	for _, layout := range g.cfg.KeyLayout.keyLayouts(g.cfg.Folder, key) {
+		layoutObjectsBySource, err := g.listLayoutObjectsBySource(ctx, layout, key)
+		if err != nil {
+			return nil, err
+		}
+		for source, objects := range layoutObjectsBySource {
+			objectsBySource[source] = append(objectsBySource[source], objects...)
+		}
+	}
+
+	return objectsBySource, nil
+}
+
+// listLayoutObjectsBySource lists the objects `folder/key/source/object` of a layout, grouped by source.
+// The objects at other depths are skipped, e.g. the sharded objects of another key under a legacy path.
+func (g *ObjectEventStore) listLayoutObjectsBySource(
+	ctx context.Context,
+	layout KeyLayout,
+	key string) (map[string][]*ObjectAttrs, error) {
+	prefix := layout.keyPath(g.cfg.Folder, key) + "/"
+	objects, err := g.listObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	objectsBySource := make(map[string][]*ObjectAttrs)
+	for _, object := range objects {
+		encodedSource, name, isFound := strings.Cut(strings.TrimPrefix(object.Name, prefix), "/")
+		if !isFound || strings.Contains(name, "/") {
+			continue
+		}
+		source, err := layout.encoding().Decode(encodedSource)
+		if err != nil {
+			return nil, err
+		}
+		objectsBySource[source] = append(objectsBySource[source], object)
+	}
+
+	return objectsBySource, nil
+}
+
+// listSourceObjects lists the objects right under the path `folder/key/source/` of every layout,
+// including the expired ones. A path shared by the layouts is listed once.
+func (g *ObjectEventStore) listSourceObjects(ctx context.Context, key, source string) ([]*ObjectAttrs, error) {
+	sourceObjects := make([]*ObjectAttrs, 0)
+	isListed := make(map[string]bool)
+	for _, layout := range g.cfg.KeyLayout.layouts() {
+		prefix := layout.sourcePath(g.cfg.Folder, key, source) + "/"
+		if isListed[prefix] {
+			continue
+		}
+		isListed[prefix] = true
+		objects, err := g.listObjects(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			if !strings.Contains(strings.TrimPrefix(object.Name, prefix), "/") {
+				sourceObjects = append(sourceObjects, object)
+			}
+		}
+	}
+
+	return sourceObjects, nil
+}
+
+// lookUpObjects returns the message of a key-source pair from its listed objects.
+func (g *ObjectEventStore) lookUpObjects(
+	ctx context.Context,
+	key, source string,
+	objects []*ObjectAttrs) (*event.Message, error) {
+	readRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ReadContent)
+	defer cancel()
+
+	content, _, err := g.readObjects(readRequestCtx, objects)
+	if err != nil || content == nil {
+		return nil, err
+	}
+
+	return event.NewMessage(key, source, string(content)), nil
+}
+
+// Persist uploads the message as a file on the path `folder/key/source`.
+func (g *ObjectEventStore) Persist(ctx context.Context, key, source, content string) error {
+	return g.persist(ctx, key, source, content, g.cfg.TTL)
+}
+
+// PersistWithTTL uploads the message as a file on the path `folder/key/source`, which expires after the given TTL
+// regardless of the TTL in ObjectConfig.
+func (g *ObjectEventStore) PersistWithTTL(ctx context.Context, key, source, content string, ttl time.Duration) error {
+	return g.persist(ctx, key, source, content, &ttl)
+}
+
+func (g *ObjectEventStore) persist(ctx context.Context, key, source, content string, ttl *time.Duration) error {
+	path := g.cfg.KeyLayout.sourcePath(g.cfg.Folder, key, source)
+	writeRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, WriteContent)
+	defer cancel()
+
+	attrs := g.newObjectAttrs(key, source, content, ttl)
+
+	return writeFile(writeRequestCtx, g.cfg.Compressor, g.objects, path, []byte(content), attrs)
+}
+
+// ListRevisions returns the unexpired objects on the path `folder/key/source` from the oldest to the newest,
+// identified by their object names under the path, i.e. the sha256 of their contents or "head".
+// Note that conditional writes overwrite the head object, so only its latest revision is kept.
+func (g *ObjectEventStore) ListRevisions(ctx context.Context, key, source string) ([]storage.Revision, error) {
+	listRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ListContents)
+	defer cancel()
+
+	listedObjects, err := g.listSourceObjects(listRequestCtx, key, source)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	objects := make([]*ObjectAttrs, 0, len(listedObjects))
+	for _, object := range listedObjects {
+		if !isExpired(object, now) {
+			objects = append(objects, object)
+		}
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].Created.Before(objects[j].Created)
+	})
+	revisions := make([]storage.Revision, 0, len(objects))
+	for _, object := range objects {
+		revisions = append(revisions, storage.Revision{
+			ID:        object.Name[strings.LastIndex(object.Name, "/")+1:],
+			CreatedAt: object.Created,
+		})
+	}
+
+	return revisions, nil
+}
+
+// LookUpRevision returns the message of the object `folder/key/source/id`.
+func (g *ObjectEventStore) LookUpRevision(ctx context.Context, key, source, id string) (*event.Message, error) {
+	if id == "" || strings.Contains(id, "/") {
+		return nil, fmt.Errorf("'%s' isn't a revision ID", id)
+	}
+	readRequestCtx, cancel := g.cfg.NewContextWithTimeout(ctx, ReadContent)
+	defer cancel()
+
+	for _, layout := range g.cfg.KeyLayout.layouts() {
+		name := layout.sourcePath(g.cfg.Folder, key, source) + "/" + id
+		message, err := g.lookUpRevision(readRequestCtx, key, source, name)
+		if err != nil || message != nil {
+			return message, err
+		}
+	}
+
+	return nil, nil
+}
+
+// lookUpRevision returns the message of the object, or nil if it doesn't exist or has expired.
+func (g *ObjectEventStore) lookUpRevision(ctx context.Context, key, source, name string) (*event.Message, error) {
+	object, err := g.objects.Attrs(ctx, name)
+	if errors.Is(err, ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isExpired(object, time.Now()) {
+		return nil, nil
+	}
+	content, err := readObject(ctx, g.cfg.Compressor, g.objects, name)
+	if errors.Is(err, ErrObjectNotExist) { // deleted after getting the attributes
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return event.NewMessage(key, source, string(content)), nil
+}
+
+// read returns the content of the object chosen by the ReadPolicy on the path of the key-source pair along with
+// the object, or the merged content of all the unexpired objects if the ReadPolicy is a MergingReadPolicy.
+// If the ReadPolicy is wrapped by CollectGarbage, the other objects on the path are deleted afterwards.
+func (g *ObjectEventStore) read(ctx context.Context, key, source string) ([]byte, *ObjectAttrs, error) {
+	objects, err := g.listSourceObjects(ctx, key, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return g.readObjects(ctx, objects)
+}
+
+// readObjects reads like read, but from the objects already listed on the path.
+func (g *ObjectEventStore) readObjects(ctx context.Context, objects []*ObjectAttrs) ([]byte, *ObjectAttrs, error) {
+	object, err := g.cfg.ReadPolicy.Apply(skipExpired(&sliceIterator{objects: objects}))
+	if err != nil || object == nil {
+		return nil, nil, err
+	}
+	mergingReadPolicy, isMerging := g.cfg.ReadPolicy.(MergingReadPolicy)
+	if !isMerging {
+		return g.readChosenObject(ctx, object, objects)
+	}
+
+	now := time.Now()
+	unexpiredObjects := make([]*ObjectAttrs, 0, len(objects))
+	for _, object := range objects {
+		if !isExpired(object, now) {
+			unexpiredObjects = append(unexpiredObjects, object)
+		}
+	}
+	sort.SliceStable(unexpiredObjects, func(i, j int) bool {
+		return unexpiredObjects[i].Created.Before(unexpiredObjects[j].Created)
+	})
+	contents := make([][]byte, 0, len(unexpiredObjects))
+	for _, unexpiredObject := range unexpiredObjects {
+		content, err := readObject(ctx, g.cfg.Compressor, g.objects, unexpiredObject.Name)
+		if errors.Is(err, ErrObjectNotExist) { // deleted after listing
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		contents = append(contents, content)
+	}
+	content, err := mergingReadPolicy.Merge(contents)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return content, object, nil
+}
+
+// readChosenObject reads the object chosen by the ReadPolicy. If the object is deleted after listing,
+// e.g. by a concurrent CompareAndPersist or CollectGarbage, it reads the one chosen among the others instead.
+func (g *ObjectEventStore) readChosenObject(
+	ctx context.Context,
+	object *ObjectAttrs,
+	objects []*ObjectAttrs) ([]byte, *ObjectAttrs, error) {
+	for object != nil {
+		content, err := readObject(ctx, g.cfg.Compressor, g.objects, object.Name)
+		if errors.Is(err, ErrObjectNotExist) {
+			objects = withoutObject(objects, object.Name)
+			if object, err = g.cfg.ReadPolicy.Apply(skipExpired(&sliceIterator{objects: objects})); err != nil {
+				return nil, nil, err
+			}
+
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, isGarbageCollecting := g.cfg.ReadPolicy.(garbageCollecting); isGarbageCollecting {
+			g.deleteOthers(ctx, object, objects)
+		}
+
+		return content, object, nil
+	}
+
+	return nil, nil, nil
+}
+
+// withoutObject returns the objects other than the named one.
+func withoutObject(objects []*ObjectAttrs, name string) []*ObjectAttrs {
+	others := make([]*ObjectAttrs, 0, len(objects))
+	for _, object := range objects {
+		if object.Name != name {
+			others = append(others, object)
+		}
+	}
+
+	return others
+}
+
+// deleteOthers deletes the objects other than the chosen one in the best effort, logging the failures,
+// since the objects left are deleted by a later read.
+func (g *ObjectEventStore) deleteOthers(ctx context.Context, chosen *ObjectAttrs, objects []*ObjectAttrs) {
+	for _, object := range objects {
+		if object.Name == chosen.Name {
+			continue
+		}
+		err := g.objects.Delete(ctx, object.Name)
+		if err != nil && !errors.Is(err, ErrObjectNotExist) {
+			g.cfg.logger().WarnContext(ctx, "failed to collect the garbage",
+				slog.String("object", object.Name), slog.Any("error", err))
+		}
+	}
+}
+
+// chooseObject lists all the objects on the path of the key-source pair, and returns the unexpired one chosen
+// by the ReadPolicy along with the listed objects.
+func (g *ObjectEventStore) chooseObject(
+	ctx context.Context,
+	key, source string) (*ObjectAttrs, []*ObjectAttrs, error) {
+	objects, err := g.listSourceObjects(ctx, key, source)
+	if err != nil {
+		return nil, nil, err
+	}
+	object, err := g.cfg.ReadPolicy.Apply(skipExpired(&sliceIterator{objects: objects}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return object, objects, nil
+}
+
+// listObjects lists all the objects under the prefix, including the expired ones, but not the pending ones.
+func (g *ObjectEventStore) listObjects(ctx context.Context, prefix string) ([]*ObjectAttrs, error) {
+	objectIterator := g.objects.List(ctx, &Query{Prefix: prefix})
+	objects := make([]*ObjectAttrs, 0)
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, ErrNoMoreObjects) {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(object.Name[strings.LastIndex(object.Name, "/")+1:], pendingObjectPrefix) {
+			objects = append(objects, object)
+		}
+	}
+}
+
+// sortedSources returns the sources of the objects in order.
+func sortedSources(objectsBySource map[string][]*ObjectAttrs) []string {
+	sources := make([]string, 0, len(objectsBySource))
+	for source := range objectsBySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	return sources
+}
+
+func composePath(folder *string, keys ...string) string {
+	components := make([]string, 0)
+
+	if folder != nil {
+		components = append(components, strings.Trim(*folder, "/"))
+	}
+	for _, key := range keys {
+		components = append(components, strings.Trim(key, "/"))
+	}
+
+	return strings.Join(components, "/")
+}
+
+// readObject decompresses the object while downloading it, so that the compressed content is never held whole.
+func readObject(ctx context.Context, compressor compression.Compressor, objects ObjectStore, name string) ([]byte, error) {
+	reader, err := objects.Read(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	decompressingReader, err := compression.NewReader(compressor, reader)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressingReader.Close()
+
+	return io.ReadAll(decompressingReader)
+}
+
+// writeFile uploads the content as the object `path/<sha256 of the compressed content>` with the given attributes.
+// The content is compressed while being uploaded to a StreamingObjectStore, so that it's never held compressed,
+// and only the other ObjectStores get the whole compressed content. Either way, the same content is always written
+// to the same object, since a compressor turns it into the same stream every time.
+func writeFile(
+	ctx context.Context,
+	compressor compression.Compressor,
+	objects ObjectStore,
+	path string,
+	content []byte,
+	attrs *ObjectAttrs) error {
+	if streamingObjects, isStreaming := objects.(StreamingObjectStore); isStreaming {
+		return streamFile(ctx, compressor, streamingObjects, path, content, attrs)
+	}
+	compressedContent, err := compressor.Compress(content)
+	if err != nil {
+		return err
+	}
+	sha := sha256.Sum256(compressedContent)
+	attrs.Name = fmt.Sprintf("%s/%s", path, base64.URLEncoding.EncodeToString(sha[:]))
+
+	return objects.Write(ctx, attrs, compressedContent)
+}
+
+// streamFile compresses the content into a pending object while hashing the compressed stream,
+// then renames the object to `path/<sha256 of the compressed content>`. The pending object is discarded on failure.
+func streamFile(
+	ctx context.Context,
+	compressor compression.Compressor,
+	objects StreamingObjectStore,
+	path string,
+	content []byte,
+	attrs *ObjectAttrs) error {
+	pendingName, err := newPendingName(path)
+	if err != nil {
+		return err
+	}
+	uploadCtx, cancelUpload := context.WithCancel(ctx)
+	defer cancelUpload()
+	attrs.Name = pendingName
+	writer, err := objects.NewWriter(uploadCtx, attrs)
+	if err != nil {
+		return err
+	}
+	hash := sha256.New()
+	compressingWriter := compression.NewWriter(compressor, io.MultiWriter(writer, hash))
+	_, err = compressingWriter.Write(content)
+	if err == nil {
+		err = compressingWriter.Close()
+	}
+	if err != nil {
+		cancelUpload()
+		_ = writer.Close()
+
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	attrs.Name = fmt.Sprintf("%s/%s", path, base64.URLEncoding.EncodeToString(hash.Sum(nil)))
+	if err = objects.Rename(ctx, pendingName, attrs); err != nil {
+		_ = objects.Delete(ctx, pendingName)
+
+		return err
+	}
+
+	return nil
+}
+
+// newPendingName returns a random name of a pending object under the path.
+func newPendingName(path string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return path + "/" + pendingObjectPrefix + hex.EncodeToString(random), nil
+}
+
+// newObjectAttrs returns the attributes of an object to write, without its name.
+func (g *ObjectEventStore) newObjectAttrs(key, source, content string, ttl *time.Duration) *ObjectAttrs {
+	attrs := &ObjectAttrs{}
+	if ttl != nil {
+		attrs.ExpiresAt = time.Now().Add(*ttl)
+	}
+	if g.cfg.Metadata != nil {
+		attrs.Metadata = g.cfg.Metadata(key, source, content)
+	}
+
+	return attrs
+}
@@ -0,0 +1,279 @@
+package object_event_store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ObjectStore is the blob storage underneath ObjectEventStore, which owns the `folder/key/source/sha256` layout
+// and the ReadPolicy logic. Any blob storage can be plugged in by implementing ObjectStore with these semantics:
+//   - List returns the objects under query.Prefix in lexicographic order, from query.StartOffset if set;
+//     with query.Delimiter set, the objects deeper than the delimiter are collapsed into synthetic entries
+//     with only Prefix set. The iterator ends with ErrNoMoreObjects.
+//   - Read and Attrs return ErrObjectNotExist if the object doesn't exist.
+//   - Write creates or overwrites the object named attrs.Name, with the other writable attributes of attrs,
+//     i.e. ExpiresAt and Metadata.
+//   - Delete returns ErrObjectNotExist if the object doesn't exist.
+//
+// The listed objects must have Created set, and Generation too if the ObjectStore is a ConditionalObjectStore.
+type ObjectStore interface {
+	List(ctx context.Context, query *Query) ObjectIterator
+	Read(ctx context.Context, name string) (io.ReadCloser, error)
+	Write(ctx context.Context, attrs *ObjectAttrs, content []byte) error
+	Delete(ctx context.Context, name string) error
+	Attrs(ctx context.Context, name string) (*ObjectAttrs, error)
+}
+
+// ConditionalObjectStore is an ObjectStore with conditional writes, which makes ObjectEventStore
+// a storage.ConditionalEventStore. WriteIf writes like Write only if the conditions hold,
+// otherwise it returns ErrPreconditionFailed.
+type ConditionalObjectStore interface {
+	ObjectStore
+	WriteIf(ctx context.Context, attrs *ObjectAttrs, content []byte, conditions Conditions) error
+}
+
+// StreamingObjectStore is an ObjectStore that uploads the objects as streams, so that ObjectEventStore compresses
+// the contents while uploading them rather than holding them compressed in memory. Since the name of an object
+// is the hash of its compressed content, the content is uploaded under a pending name and renamed afterwards:
+//   - NewWriter returns a writer of the object named attrs.Name, with the other writable attributes of attrs,
+//     which is written on Close, or discarded if ctx is done before.
+//   - Rename renames the object to attrs.Name with the other writable attributes of attrs, like writing its content
+//     under the new name and deleting it. It returns ErrObjectNotExist if the object doesn't exist.
+type StreamingObjectStore interface {
+	ObjectStore
+	NewWriter(ctx context.Context, attrs *ObjectAttrs) (io.WriteCloser, error)
+	Rename(ctx context.Context, name string, attrs *ObjectAttrs) error
+}
+
+type ObjectIterator interface {
+	Next() (*ObjectAttrs, error)
+}
+
+// Query selects the objects listed by ObjectStore.List.
+type Query struct {
+	Prefix      string // only the objects whose names start with Prefix
+	Delimiter   string // collapses the objects deeper than Delimiter under Prefix into their prefixes
+	StartOffset string // only the objects whose names are lexicographically equal to or after StartOffset
+}
+
+// ObjectAttrs are the attributes of an object, or of a prefix listed with a delimiter if only Prefix is set.
+type ObjectAttrs struct {
+	Name       string
+	Prefix     string
+	Size       int64
+	Created    time.Time // the creation time of the object, which orders the objects for the ReadPolicy
+	ExpiresAt  time.Time // the time after which the object is skipped on read, never if zero
+	Generation int64     // the version of the object, which changes on every write of its name
+	Metadata   map[string]string
+}
+
+// Conditions are the preconditions of ConditionalObjectStore.WriteIf.
+type Conditions struct {
+	DoesNotExist    bool  // the object doesn't exist
+	GenerationMatch int64 // the object exists with this generation, if not zero
+}
+
+var (
+	// ErrNoMoreObjects is returned by ObjectIterator.Next once all the objects are iterated.
+	ErrNoMoreObjects = errors.New("no more objects")
+	// ErrObjectNotExist is returned by the ObjectStore if the object doesn't exist.
+	ErrObjectNotExist = errors.New("object doesn't exist")
+	// ErrPreconditionFailed is returned by ConditionalObjectStore.WriteIf if the conditions don't hold.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// inMemoryObjectStore implements ObjectStore in memory, which is meant for tests and local runs.
+type inMemoryObjectStore struct {
+	lock       sync.RWMutex
+	objects    map[string]inMemoryObject
+	generation int64
+}
+
+type inMemoryObject struct {
+	attrs   ObjectAttrs
+	content []byte
+}
+
+// NewInMemoryObjectStore returns an empty ConditionalObjectStore in memory, which is a StreamingObjectStore too.
+func NewInMemoryObjectStore() ConditionalObjectStore {
+	return &inMemoryObjectStore{
+		objects: make(map[string]inMemoryObject),
+	}
+}
+
+func (i *inMemoryObjectStore) List(_ context.Context, query *Query) ObjectIterator {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	var prefix, delimiter, startOffset string
+	if query != nil {
+		prefix, delimiter, startOffset = query.Prefix, query.Delimiter, query.StartOffset
+	}
+	names := make([]string, 0)
+	for name := range i.objects {
+		if strings.HasPrefix(name, prefix) && name >= startOffset {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	results := make([]*ObjectAttrs, 0, len(names))
+	isPrefixListed := make(map[string]bool)
+	for _, name := range names {
+		if delimiter != "" {
+			if index := strings.Index(name[len(prefix):], delimiter); index >= 0 {
+				subPrefix := name[:len(prefix)+index+len(delimiter)]
+				if !isPrefixListed[subPrefix] {
+					isPrefixListed[subPrefix] = true
+					results = append(results, &ObjectAttrs{Prefix: subPrefix})
+				}
+
+				continue
+			}
+		}
+		attrs := i.objects[name].attrs
+		results = append(results, &attrs)
+	}
+
+	return &sliceIterator{objects: results}
+}
+
+func (i *inMemoryObjectStore) Read(_ context.Context, name string) (io.ReadCloser, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	object, isFound := i.objects[name]
+	if !isFound {
+		return nil, ErrObjectNotExist
+	}
+
+	return io.NopCloser(bytes.NewReader(object.content)), nil
+}
+
+func (i *inMemoryObjectStore) Write(_ context.Context, attrs *ObjectAttrs, content []byte) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.write(attrs, content)
+
+	return nil
+}
+
+func (i *inMemoryObjectStore) WriteIf(
+	_ context.Context,
+	attrs *ObjectAttrs,
+	content []byte,
+	conditions Conditions) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	object, isFound := i.objects[attrs.Name]
+	if conditions.DoesNotExist && isFound {
+		return ErrPreconditionFailed
+	}
+	if conditions.GenerationMatch != 0 && (!isFound || object.attrs.Generation != conditions.GenerationMatch) {
+		return ErrPreconditionFailed
+	}
+	i.write(attrs, content)
+
+	return nil
+}
+
+func (i *inMemoryObjectStore) write(attrs *ObjectAttrs, content []byte) {
+	i.generation++
+	objectAttrs := *attrs
+	objectAttrs.Size = int64(len(content))
+	objectAttrs.Created = time.Now()
+	objectAttrs.Generation = i.generation
+	i.objects[attrs.Name] = inMemoryObject{
+		attrs:   objectAttrs,
+		content: append([]byte{}, content...),
+	}
+}
+
+func (i *inMemoryObjectStore) NewWriter(ctx context.Context, attrs *ObjectAttrs) (io.WriteCloser, error) {
+	return &inMemoryWriter{
+		ctx:     ctx,
+		objects: i,
+		attrs:   *attrs,
+	}, nil
+}
+
+func (i *inMemoryObjectStore) Rename(_ context.Context, name string, attrs *ObjectAttrs) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	object, isFound := i.objects[name]
+	if !isFound {
+		return ErrObjectNotExist
+	}
+	delete(i.objects, name)
+	i.write(attrs, object.content)
+
+	return nil
+}
+
+func (i *inMemoryObjectStore) Delete(_ context.Context, name string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if _, isFound := i.objects[name]; !isFound {
+		return ErrObjectNotExist
+	}
+	delete(i.objects, name)
+
+	return nil
+}
+
+func (i *inMemoryObjectStore) Attrs(_ context.Context, name string) (*ObjectAttrs, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	object, isFound := i.objects[name]
+	if !isFound {
+		return nil, ErrObjectNotExist
+	}
+	attrs := object.attrs
+
+	return &attrs, nil
+}
+
+// inMemoryWriter buffers the content of an object, and writes it to the inMemoryObjectStore on Close.
+type inMemoryWriter struct {
+	ctx     context.Context
+	objects *inMemoryObjectStore
+	attrs   ObjectAttrs
+	content bytes.Buffer
+}
+
+func (i *inMemoryWriter) Write(p []byte) (int, error) {
+	return i.content.Write(p)
+}
+
+func (i *inMemoryWriter) Close() error {
+	if err := i.ctx.Err(); err != nil {
+		return err
+	}
+
+	return i.objects.Write(i.ctx, &i.attrs, i.content.Bytes())
+}
+
+// sliceIterator implements ObjectIterator on a snapshot of objects.
+type sliceIterator struct {
+	objects []*ObjectAttrs
+}
+
+func (s *sliceIterator) Next() (*ObjectAttrs, error) {
+	if len(s.objects) == 0 {
+		return nil, ErrNoMoreObjects
+	}
+	object := s.objects[0]
+	s.objects = s.objects[1:]
+
+	return object, nil
+}
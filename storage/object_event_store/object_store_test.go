@@ -0,0 +1,671 @@
+package object_event_store_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/storage/object_event_store"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+func TestInMemoryObjectStore(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("read, write, attrs & delete", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+
+		_, err := objects.Read(ctx, "a/b")
+		assert.ErrorIs(t, err, object_event_store.ErrObjectNotExist)
+		_, err = objects.Attrs(ctx, "a/b")
+		assert.ErrorIs(t, err, object_event_store.ErrObjectNotExist)
+		assert.ErrorIs(t, objects.Delete(ctx, "a/b"), object_event_store.ErrObjectNotExist)
+
+		customTime := time.Now().Add(time.Hour)
+		assert.NoError(t, objects.Write(ctx, &object_event_store.ObjectAttrs{Name: "a/b", ExpiresAt: customTime}, []byte("content")))
+		reader, err := objects.Read(ctx, "a/b")
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+		attrs, err := objects.Attrs(ctx, "a/b")
+		assert.NoError(t, err)
+		assert.Equal(t, "a/b", attrs.Name)
+		assert.Equal(t, int64(len("content")), attrs.Size)
+		assert.Equal(t, customTime, attrs.ExpiresAt)
+		assert.False(t, attrs.Created.IsZero())
+
+		assert.NoError(t, objects.Write(ctx, &object_event_store.ObjectAttrs{Name: "a/b"}, []byte("overwritten")))
+		overwrittenAttrs, err := objects.Attrs(ctx, "a/b")
+		assert.NoError(t, err)
+		assert.Greater(t, overwrittenAttrs.Generation, attrs.Generation)
+
+		assert.NoError(t, objects.Delete(ctx, "a/b"))
+		_, err = objects.Read(ctx, "a/b")
+		assert.ErrorIs(t, err, object_event_store.ErrObjectNotExist)
+	})
+
+	t.Run("stream & rename", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore().(object_event_store.StreamingObjectStore)
+		writer, err := objects.NewWriter(ctx, &object_event_store.ObjectAttrs{Name: "a/pending"})
+		assert.NoError(t, err)
+		_, err = writer.Write([]byte("content"))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+
+		customTime := time.Now().Add(time.Hour)
+		renamedObject := &object_event_store.ObjectAttrs{Name: "a/b", ExpiresAt: customTime}
+		assert.NoError(t, objects.Rename(ctx, "a/pending", renamedObject))
+		assert.ErrorIs(t, objects.Rename(ctx, "a/pending", renamedObject), object_event_store.ErrObjectNotExist)
+		assert.Equal(t, []string{"a/b"}, collect(t, objects.List(ctx, nil)))
+		attrs, err := objects.Attrs(ctx, "a/b")
+		assert.NoError(t, err)
+		assert.Equal(t, customTime, attrs.ExpiresAt)
+		reader, err := objects.Read(ctx, "a/b")
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "content", string(content))
+
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		writer, err = objects.NewWriter(cancelledCtx, &object_event_store.ObjectAttrs{Name: "a/cancelled"})
+		assert.NoError(t, err)
+		cancel()
+		assert.ErrorIs(t, writer.Close(), context.Canceled)
+		assert.Equal(t, []string{"a/b"}, collect(t, objects.List(ctx, nil)))
+	})
+
+	t.Run("list with prefix & delimiter", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		for _, name := range []string{"f/k/s1/x", "f/k/s1/y", "f/k/s2/x", "f/k2/s1/x", "f/file"} {
+			assert.NoError(t, objects.Write(ctx, &object_event_store.ObjectAttrs{Name: name}, []byte(name)))
+		}
+
+		assert.Equal(t, []string{"f/k/s1/x", "f/k/s1/y", "f/k/s2/x"},
+			collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "f/k/"})))
+		assert.Equal(t, []string{"f/k/s1/", "f/k/s2/"},
+			collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "f/k/", Delimiter: "/"})))
+		assert.Equal(t, []string{"f/file", "f/k/", "f/k2/"},
+			collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "f/", Delimiter: "/"})))
+		assert.Empty(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "nothing/"})))
+		assert.Equal(t, []string{"f/k2/"},
+			collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "f/", Delimiter: "/", StartOffset: "f/k0"})))
+	})
+}
+
+func TestObjectEventStoreWithObjectStore(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("nil object store", func(t *testing.T) {
+		_, err := object_event_store.New(object_event_store.Config(), nil)
+		assert.Error(t, err)
+		_, err = object_event_store.New(nil, object_event_store.NewInMemoryObjectStore())
+		assert.Error(t, err)
+	})
+
+	t.Run("persist, look up & delete", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		config := object_event_store.Config().
+			WithFolder("folder-name").
+			WithReadPolicy(object_event_store.TakeLastCreated())
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source1", "content"))
+		time.Sleep(time.Millisecond) // the creation times must differ for TakeLastCreated
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source1", "something else"))
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source2", "content"))
+		assert.Len(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder-name/key/source1/"})), 2)
+
+		sources, err := eventStore.ListSourcesByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"source1", "source2"}, sources)
+
+		message, err := eventStore.LookUp(ctx, "key", "source1")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source1", "something else"), message)
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*event.Message{
+			event.NewMessage("key", "source1", "something else"),
+			event.NewMessage("key", "source2", "content")},
+			messages)
+
+		assert.NoError(t, eventStore.Delete(ctx, "key", "source1"))
+		message, err = eventStore.LookUp(ctx, "key", "source1")
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+
+		assert.NoError(t, eventStore.DeleteByKey(ctx, "key"))
+		assert.Empty(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder-name/"})))
+	})
+
+	t.Run("with TTL", func(t *testing.T) {
+		eventStore, err := object_event_store.New(object_event_store.Config(),
+			object_event_store.NewInMemoryObjectStore())
+		assert.NoError(t, err)
+		expiringEventStore, isExpiring := eventStore.(storage.ExpiringEventStore)
+		assert.True(t, isExpiring)
+
+		assert.NoError(t, expiringEventStore.PersistWithTTL(ctx, "key", "source1", "content", time.Millisecond))
+		assert.NoError(t, expiringEventStore.PersistWithTTL(ctx, "key", "source2", "content", time.Hour))
+		time.Sleep(5 * time.Millisecond)
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{event.NewMessage("key", "source2", "content")}, messages)
+		sources, err := eventStore.ListSourcesByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"source2"}, sources)
+	})
+
+	t.Run("compressed contents", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		compressor := compression.Gzip(gzip.BestSpeed)
+		eventStore, err := object_event_store.New(
+			object_event_store.Config().WithFolder("folder").WithCompressor(compressor), objects)
+		assert.NoError(t, err)
+		content := strings.Repeat("content", 1000)
+
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source1", content))
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source2", ""))
+		compressedContent, err := compressor.Compress([]byte(content))
+		assert.NoError(t, err)
+		sha := sha256.Sum256(compressedContent)
+		reader, err := objects.Read(ctx, "folder/key/source1/"+base64.URLEncoding.EncodeToString(sha[:]))
+		assert.NoError(t, err)
+		storedContent, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, compressedContent, storedContent)
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{
+			event.NewMessage("key", "source1", content),
+			event.NewMessage("key", "source2", "")},
+			messages)
+
+		// moving to another compressor keeps the gzip contents readable
+		migratedEventStore, err := object_event_store.New(
+			object_event_store.Config().WithFolder("folder").WithCompressor(compression.AutoDetect(
+				compression.DeflateCodec(flate.BestSpeed), compression.GzipCodec(gzip.BestSpeed))),
+			objects)
+		assert.NoError(t, err)
+		assert.NoError(t, migratedEventStore.Persist(ctx, "key", "source3", content))
+		messages, err = migratedEventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{
+			event.NewMessage("key", "source1", content),
+			event.NewMessage("key", "source2", ""),
+			event.NewMessage("key", "source3", content)},
+			messages)
+	})
+}
+
+func TestObjectEventStoreStreamingWrites(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("stream the contents to the object named by their hash", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		assert.Implements(t, (*object_event_store.StreamingObjectStore)(nil), objects)
+		compressor := compression.Gzip(gzip.BestSpeed)
+		eventStore, err := object_event_store.New(
+			object_event_store.Config().WithFolder("folder").WithCompressor(compressor), objects)
+		assert.NoError(t, err)
+		content := strings.Repeat("content", 1000)
+
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source", content))
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source", content))
+		compressedContent, err := compressor.Compress([]byte(content))
+		assert.NoError(t, err)
+		sha := sha256.Sum256(compressedContent)
+		assert.Equal(t, []string{"folder/key/source/" + base64.URLEncoding.EncodeToString(sha[:])},
+			collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder/"})))
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", content), message)
+	})
+
+	t.Run("discard the pending object if the compression fails", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		eventStore, err := object_event_store.New(
+			object_event_store.Config().WithFolder("folder").WithCompressor(failingStreamCompressor{}), objects)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, eventStore.Persist(ctx, "key", "source", "content"), errCompress)
+		assert.Empty(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder/"})))
+	})
+
+	t.Run("skip the pending objects", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		eventStore, err := object_event_store.New(object_event_store.Config().WithFolder("folder"), objects)
+		assert.NoError(t, err)
+		pendingObject := &object_event_store.ObjectAttrs{Name: "folder/key/source/.pending-upload"}
+		assert.NoError(t, objects.Write(ctx, pendingObject, []byte("content")))
+
+		sources, err := eventStore.ListSourcesByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Empty(t, sources)
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+	})
+}
+
+var errCompress = errors.New("failed to compress")
+
+// failingStreamCompressor fails to compress the streams.
+type failingStreamCompressor struct {
+	compression.Compressor
+}
+
+func (f failingStreamCompressor) NewWriter(io.Writer) (io.WriteCloser, error) {
+	return nil, errCompress
+}
+
+func (f failingStreamCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+func TestObjectEventStoreConditionalWrites(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("persist if absent", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		eventStore, err := object_event_store.New(object_event_store.Config(), objects)
+		assert.NoError(t, err)
+		conditionalEventStore, isConditional := eventStore.(storage.ConditionalEventStore)
+		assert.True(t, isConditional)
+
+		isPersisted, err := conditionalEventStore.PersistIfAbsent(ctx, "key", "source", "content1")
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		isPersisted, err = conditionalEventStore.PersistIfAbsent(ctx, "key", "source", "content2")
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content1"), message)
+
+		// unconditionally persisted contents count as well
+		assert.NoError(t, eventStore.Persist(ctx, "key", "other-source", "content"))
+		isPersisted, err = conditionalEventStore.PersistIfAbsent(ctx, "key", "other-source", "content")
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+	})
+
+	t.Run("compare and persist replaces superseded objects", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		config := object_event_store.Config().WithFolder("folder-name")
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+		conditionalEventStore := eventStore.(storage.ConditionalEventStore)
+
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source", "content1"))
+		message, version, err := conditionalEventStore.LookUpVersion(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content1"), message)
+		assert.NotEqual(t, storage.NoVersion, version)
+
+		isPersisted, err := conditionalEventStore.CompareAndPersist(ctx, "key", "source", "content2", storage.NoVersion)
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(ctx, "key", "source", "content2", version)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		assert.Equal(t, []string{"folder-name/key/source/head"},
+			collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "folder-name/key/source/"})))
+
+		message, newVersion, err := conditionalEventStore.LookUpVersion(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content2"), message)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(ctx, "key", "source", "content3", version)
+		assert.NoError(t, err)
+		assert.False(t, isPersisted)
+		isPersisted, err = conditionalEventStore.CompareAndPersist(ctx, "key", "source", "content3", newVersion)
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+
+		message, err = eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content3"), message)
+	})
+
+	t.Run("persist if absent over expired head", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		eventStore, err := object_event_store.New(
+			object_event_store.Config().WithTTL(time.Millisecond), objects)
+		assert.NoError(t, err)
+		conditionalEventStore := eventStore.(storage.ConditionalEventStore)
+
+		isPersisted, err := conditionalEventStore.PersistIfAbsent(ctx, "key", "source", "content1")
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+		time.Sleep(5 * time.Millisecond)
+		isPersisted, err = conditionalEventStore.PersistIfAbsent(ctx, "key", "source", "content2")
+		assert.NoError(t, err)
+		assert.True(t, isPersisted)
+	})
+
+	t.Run("write if", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		attrs := &object_event_store.ObjectAttrs{Name: "a"}
+
+		assert.ErrorIs(t, objects.WriteIf(ctx, attrs, nil, object_event_store.Conditions{GenerationMatch: 1}),
+			object_event_store.ErrPreconditionFailed)
+		assert.NoError(t, objects.WriteIf(ctx, attrs, nil, object_event_store.Conditions{DoesNotExist: true}))
+		assert.ErrorIs(t, objects.WriteIf(ctx, attrs, nil, object_event_store.Conditions{DoesNotExist: true}),
+			object_event_store.ErrPreconditionFailed)
+		current, err := objects.Attrs(ctx, "a")
+		assert.NoError(t, err)
+		assert.NoError(t, objects.WriteIf(ctx, attrs, nil, object_event_store.Conditions{GenerationMatch: current.Generation}))
+		assert.ErrorIs(t, objects.WriteIf(ctx, attrs, nil, object_event_store.Conditions{GenerationMatch: current.Generation}),
+			object_event_store.ErrPreconditionFailed)
+	})
+}
+
+func TestObjectEventStoreHistory(t *testing.T) {
+	ctx := context.TODO()
+	eventStore, err := object_event_store.New(object_event_store.Config().WithFolder("folder-name"),
+		object_event_store.NewInMemoryObjectStore())
+	assert.NoError(t, err)
+	historyEventStore, isHistory := eventStore.(storage.HistoryEventStore)
+	assert.True(t, isHistory)
+
+	revisions, err := historyEventStore.ListRevisions(ctx, "key", "source")
+	assert.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	assert.NoError(t, eventStore.Persist(ctx, "key", "source", "content1"))
+	time.Sleep(time.Millisecond) // the creation times must differ for the order
+	assert.NoError(t, eventStore.Persist(ctx, "key", "source", "content2"))
+	expiringEventStore := eventStore.(storage.ExpiringEventStore)
+	assert.NoError(t, expiringEventStore.PersistWithTTL(ctx, "key", "source", "expired", -time.Second))
+
+	revisions, err = historyEventStore.ListRevisions(ctx, "key", "source")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.True(t, revisions[0].CreatedAt.Before(revisions[1].CreatedAt))
+	for index, revision := range revisions {
+		message, err := historyEventStore.LookUpRevision(ctx, "key", "source", revision.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", fmt.Sprintf("content%d", index+1)), message)
+	}
+
+	message, err := historyEventStore.LookUpRevision(ctx, "key", "source", "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, message)
+	_, err = historyEventStore.LookUpRevision(ctx, "key", "source", "../other-source/id")
+	assert.Error(t, err)
+}
+
+func TestObjectEventStoreReadPolicies(t *testing.T) {
+	ctx := context.TODO()
+
+	persistAll := func(t *testing.T, eventStore storage.EventStore, contents ...string) {
+		t.Helper()
+
+		for _, content := range contents {
+			assert.NoError(t, eventStore.Persist(ctx, "key", "source", content))
+			time.Sleep(time.Millisecond) // the creation times must differ for the order
+		}
+	}
+
+	t.Run("take highest sequence from metadata", func(t *testing.T) {
+		config := object_event_store.Config().
+			WithMetadata(func(_, _, content string) map[string]string {
+				return map[string]string{"sequence": content}
+			}).
+			WithReadPolicy(object_event_store.TakeHighestSequence("sequence"))
+		eventStore, err := object_event_store.New(config, object_event_store.NewInMemoryObjectStore())
+		assert.NoError(t, err)
+
+		persistAll(t, eventStore, "2", "10", "3")
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "10"), message)
+	})
+
+	t.Run("fail on multiple", func(t *testing.T) {
+		config := object_event_store.Config().WithReadPolicy(object_event_store.FailOnMultiple())
+		eventStore, err := object_event_store.New(config, object_event_store.NewInMemoryObjectStore())
+		assert.NoError(t, err)
+
+		persistAll(t, eventStore, "content1")
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content1"), message)
+
+		persistAll(t, eventStore, "content2")
+		_, err = eventStore.LookUp(ctx, "key", "source")
+		assert.ErrorIs(t, err, object_event_store.ErrMultipleObjects)
+	})
+
+	t.Run("merge all", func(t *testing.T) {
+		config := object_event_store.Config().WithReadPolicy(object_event_store.MergeAsJSONArray())
+		eventStore, err := object_event_store.New(config, object_event_store.NewInMemoryObjectStore())
+		assert.NoError(t, err)
+
+		persistAll(t, eventStore, `{"a":1}`, "text")
+		assert.NoError(t, eventStore.(storage.ExpiringEventStore).
+			PersistWithTTL(ctx, "key", "source", "expired", -time.Second))
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.JSONEq(t, `[{"a":1},"text"]`, message.GetContent())
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{message}, messages)
+	})
+
+	t.Run("collect garbage", func(t *testing.T) {
+		objects := object_event_store.NewInMemoryObjectStore()
+		config := object_event_store.Config().
+			WithReadPolicy(object_event_store.CollectGarbage(object_event_store.TakeLastCreated()))
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+
+		persistAll(t, eventStore, "content1", "content2", "content3")
+		assert.Len(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "key/source/"})), 3)
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content3"), message)
+		assert.Len(t, collect(t, objects.List(ctx, &object_event_store.Query{Prefix: "key/source/"})), 1)
+
+		message, err = eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content3"), message)
+	})
+
+	t.Run("log the failures to collect garbage", func(t *testing.T) {
+		var logs bytes.Buffer
+		objects := &undeletableObjectStore{ObjectStore: object_event_store.NewInMemoryObjectStore()}
+		config := object_event_store.Config().
+			WithLogger(slog.New(slog.NewTextHandler(&logs, nil))).
+			WithReadPolicy(object_event_store.CollectGarbage(object_event_store.TakeLastCreated()))
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+
+		persistAll(t, eventStore, "content1", "content2")
+		message, err := eventStore.LookUp(ctx, "key", "source")
+		assert.NoError(t, err)
+		assert.Equal(t, event.NewMessage("key", "source", "content2"), message)
+		assert.Contains(t, logs.String(), "failed to collect the garbage")
+		assert.Contains(t, logs.String(), errDelete.Error())
+	})
+
+	t.Run("reject collecting the garbage of merges", func(t *testing.T) {
+		config := object_event_store.Config().
+			WithReadPolicy(object_event_store.CollectGarbage(object_event_store.MergeConcatenated(",")))
+		_, err := object_event_store.New(config, object_event_store.NewInMemoryObjectStore())
+		assert.ErrorIs(t, err, object_event_store.ErrGarbageCollectingMerge)
+	})
+}
+
+var errDelete = errors.New("delete failed")
+
+// undeletableObjectStore fails all the deletes.
+type undeletableObjectStore struct {
+	object_event_store.ObjectStore
+}
+
+func (u *undeletableObjectStore) Delete(context.Context, string) error {
+	return errDelete
+}
+
+func TestObjectEventStoreLookUpByKey(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("list once and read concurrently", func(t *testing.T) {
+		objects := &faultyObjectStore{ObjectStore: object_event_store.NewInMemoryObjectStore()}
+		config := object_event_store.Config().WithFolder("folder-name").WithConcurrency(4)
+		eventStore, err := object_event_store.New(config, objects)
+		assert.NoError(t, err)
+
+		expectedMessages := make([]*event.Message, 0)
+		for i := 0; i < 20; i++ {
+			source := fmt.Sprintf("source%02d", i)
+			assert.NoError(t, eventStore.Persist(ctx, "key", source, "content"))
+			expectedMessages = append(expectedMessages, event.NewMessage("key", source, "content"))
+		}
+		assert.NoError(t, eventStore.(storage.ExpiringEventStore).
+			PersistWithTTL(ctx, "key", "expired", "content", -time.Second))
+		assert.NoError(t, eventStore.Persist(ctx, "key-with-same-prefix", "source", "content"))
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, expectedMessages, messages)
+		assert.Equal(t, int32(1), objects.lists.Load())
+		assert.Equal(t, int32(20), objects.reads.Load())
+	})
+
+	t.Run("report partial failures", func(t *testing.T) {
+		objects := &faultyObjectStore{ObjectStore: object_event_store.NewInMemoryObjectStore(), failingPrefix: "key/source2/"}
+		eventStore, err := object_event_store.New(object_event_store.Config(), objects)
+		assert.NoError(t, err)
+		for _, source := range []string{"source1", "source2", "source3"} {
+			assert.NoError(t, eventStore.Persist(ctx, "key", source, "content"))
+		}
+
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.Equal(t, []*event.Message{
+			event.NewMessage("key", "source1", "content"),
+			event.NewMessage("key", "source3", "content")},
+			messages)
+		var partialLookUpError *object_event_store.PartialLookUpError
+		assert.ErrorAs(t, err, &partialLookUpError)
+		assert.Equal(t, "key", partialLookUpError.Key)
+		assert.Len(t, partialLookUpError.Failures, 1)
+		assert.Contains(t, partialLookUpError.Failures, "source2")
+		assert.ErrorIs(t, err, errRead)
+	})
+
+	t.Run("fall back when the chosen object is deleted after listing", func(t *testing.T) {
+		objects := &faultyObjectStore{ObjectStore: object_event_store.NewInMemoryObjectStore()}
+		eventStore, err := object_event_store.New(
+			object_event_store.Config().WithReadPolicy(object_event_store.TakeLastCreated()), objects)
+		assert.NoError(t, err)
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source1", "content"))
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source2", "old content"))
+		assert.NoError(t, eventStore.Persist(ctx, "key", "source2", "new content"))
+		revisions, err := eventStore.(storage.HistoryEventStore).ListRevisions(ctx, "key", "source2")
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+
+		objects.vanishingName = "key/source2/" + revisions[1].ID
+		messages, err := eventStore.LookUpByKey(ctx, "key")
+		assert.NoError(t, err)
+		assert.Equal(t, []*event.Message{
+			event.NewMessage("key", "source1", "content"),
+			event.NewMessage("key", "source2", "old content")},
+			messages)
+
+		objects.vanishingName = "key/source2/" + revisions[0].ID
+		message, err := eventStore.LookUp(ctx, "key", "source2")
+		assert.NoError(t, err)
+		assert.Nil(t, message)
+	})
+
+	t.Run("fail to list", func(t *testing.T) {
+		objects := &faultyObjectStore{ObjectStore: object_event_store.NewInMemoryObjectStore(), failingPrefix: "key/"}
+		eventStore, err := object_event_store.New(object_event_store.Config(), objects)
+		assert.NoError(t, err)
+
+		_, err = eventStore.LookUpByKey(ctx, "key")
+		assert.ErrorIs(t, err, errRead)
+	})
+}
+
+var errRead = errors.New("test")
+
+// faultyObjectStore counts the list & read calls, and fails them under failingPrefix.
+// The object named vanishingName is deleted right before it's read, as if it was deleted after listing.
+type faultyObjectStore struct {
+	object_event_store.ObjectStore
+	failingPrefix string
+	vanishingName string
+	lists         atomic.Int32
+	reads         atomic.Int32
+}
+
+func (f *faultyObjectStore) List(ctx context.Context, query *object_event_store.Query) object_event_store.ObjectIterator {
+	f.lists.Add(1)
+	if f.failingPrefix != "" && query.Prefix == f.failingPrefix {
+		return &failingIterator{}
+	}
+
+	return f.ObjectStore.List(ctx, query)
+}
+
+func (f *faultyObjectStore) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	f.reads.Add(1)
+	if f.failingPrefix != "" && strings.HasPrefix(name, f.failingPrefix) {
+		return nil, errRead
+	}
+	if name == f.vanishingName {
+		_ = f.ObjectStore.Delete(ctx, name)
+	}
+
+	return f.ObjectStore.Read(ctx, name)
+}
+
+type failingIterator struct{}
+
+func (f *failingIterator) Next() (*object_event_store.ObjectAttrs, error) {
+	return nil, errRead
+}
+
+func collect(t *testing.T, objectIterator object_event_store.ObjectIterator) []string {
+	t.Helper()
+
+	names := make([]string, 0)
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, object_event_store.ErrNoMoreObjects) {
+			return names
+		}
+		assert.NoError(t, err)
+		if object.Prefix != "" {
+			names = append(names, object.Prefix)
+		} else {
+			names = append(names, object.Name)
+		}
+	}
+}
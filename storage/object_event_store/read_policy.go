@@ -0,0 +1,246 @@
+package object_event_store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ReadPolicy defines the actions that the ObjectEventStore takes when it got the object iterator via list query.
+type ReadPolicy interface {
+	Apply(ObjectIterator) (*ObjectAttrs, error)
+}
+
+// takeFirstCreated take the earliest created object under the same key/source/ path
+type takeFirstCreated struct{}
+
+func TakeFirstCreated() ReadPolicy {
+	return takeFirstCreated{}
+}
+
+func (t takeFirstCreated) Apply(objectIterator ObjectIterator) (*ObjectAttrs, error) {
+	if objectIterator == nil {
+		return nil, errors.New("objectIterator is nil")
+	}
+
+	var result *ObjectAttrs
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, ErrNoMoreObjects) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || object.Created.Before(result.Created) {
+			result = object
+		}
+	}
+
+	return result, nil
+}
+
+// takeLastCreated take the latest created object under the same key/source/ path
+type takeLastCreated struct{}
+
+func TakeLastCreated() ReadPolicy {
+	return takeLastCreated{}
+}
+
+func (t takeLastCreated) Apply(objectIterator ObjectIterator) (*ObjectAttrs, error) {
+	if objectIterator == nil {
+		return nil, errors.New("objectIterator is nil")
+	}
+
+	var result *ObjectAttrs
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, ErrNoMoreObjects) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || object.Created.After(result.Created) {
+			result = object
+		}
+	}
+
+	return result, nil
+}
+
+// ErrMultipleObjects is returned by FailOnMultiple if there are multiple objects under the same key/source/ path.
+var ErrMultipleObjects = errors.New("multiple objects under the same key/source/ path")
+
+// failOnMultiple takes the only object under the same key/source/ path, and fails if there are multiple
+type failOnMultiple struct{}
+
+// FailOnMultiple returns a ReadPolicy for contents that are never supposed to be persisted twice,
+// which fails the look-ups with ErrMultipleObjects rather than silently choosing one of the objects.
+func FailOnMultiple() ReadPolicy {
+	return failOnMultiple{}
+}
+
+func (f failOnMultiple) Apply(objectIterator ObjectIterator) (*ObjectAttrs, error) {
+	if objectIterator == nil {
+		return nil, errors.New("objectIterator is nil")
+	}
+
+	var result *ObjectAttrs
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, ErrNoMoreObjects) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			return nil, fmt.Errorf("%w: %s and %s", ErrMultipleObjects, result.Name, object.Name)
+		}
+		result = object
+	}
+
+	return result, nil
+}
+
+// takeByMetadata takes the object with the preferred value of a metadata field under the same key/source/ path
+type takeByMetadata struct {
+	field       string
+	isPreferred func(candidate, current string) bool
+}
+
+// TakeByMetadata returns a ReadPolicy that takes the object whose value of the metadata field is preferred
+// by isPreferred over the others. The metadata are set on write by ObjectConfig.WithMetadata.
+// Objects without the field are only taken if none has it, and ties are broken by taking the last created.
+func TakeByMetadata(field string, isPreferred func(candidate, current string) bool) ReadPolicy {
+	return takeByMetadata{
+		field:       field,
+		isPreferred: isPreferred,
+	}
+}
+
+// TakeHighestSequence returns a ReadPolicy that takes the object with the highest integer value of the metadata field,
+// e.g. the sequence number of the event. Values that aren't integers rank below all integers.
+func TakeHighestSequence(field string) ReadPolicy {
+	return TakeByMetadata(field, func(candidate, current string) bool {
+		candidateSequence, candidateErr := strconv.ParseInt(candidate, 10, 64)
+		currentSequence, currentErr := strconv.ParseInt(current, 10, 64)
+		if candidateErr != nil || currentErr != nil {
+			return candidateErr == nil && currentErr != nil
+		}
+
+		return candidateSequence > currentSequence
+	})
+}
+
+func (t takeByMetadata) Apply(objectIterator ObjectIterator) (*ObjectAttrs, error) {
+	if objectIterator == nil {
+		return nil, errors.New("objectIterator is nil")
+	}
+
+	var result *ObjectAttrs
+	for {
+		object, err := objectIterator.Next()
+		if errors.Is(err, ErrNoMoreObjects) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if result == nil || t.isBetter(object, result) {
+			result = object
+		}
+	}
+
+	return result, nil
+}
+
+func (t takeByMetadata) isBetter(candidate, current *ObjectAttrs) bool {
+	candidateValue, hasCandidateValue := candidate.Metadata[t.field]
+	currentValue, hasCurrentValue := current.Metadata[t.field]
+	if hasCandidateValue != hasCurrentValue {
+		return hasCandidateValue
+	}
+	if hasCandidateValue {
+		if t.isPreferred(candidateValue, currentValue) {
+			return true
+		}
+		if t.isPreferred(currentValue, candidateValue) {
+			return false
+		}
+	}
+
+	return candidate.Created.After(current.Created)
+}
+
+// MergingReadPolicy is a ReadPolicy that makes ObjectEventStore read all the unexpired objects under the same
+// key/source/ path, and merge their contents from the earliest to the latest created into one content.
+// Apply still chooses the object that stands for the merged content, e.g. whose generation is the version
+// for conditional writes.
+type MergingReadPolicy interface {
+	ReadPolicy
+	Merge(contents [][]byte) ([]byte, error)
+}
+
+// mergeConcatenated concatenates the contents of all the objects under the same key/source/ path
+type mergeConcatenated struct {
+	takeLastCreated
+	separator []byte
+}
+
+// MergeConcatenated returns a MergingReadPolicy that concatenates all the contents with the separator in between.
+func MergeConcatenated(separator string) MergingReadPolicy {
+	return mergeConcatenated{separator: []byte(separator)}
+}
+
+func (m mergeConcatenated) Merge(contents [][]byte) ([]byte, error) {
+	return bytes.Join(contents, m.separator), nil
+}
+
+// mergeAsJSONArray puts the contents of all the objects under the same key/source/ path in a JSON array
+type mergeAsJSONArray struct {
+	takeLastCreated
+}
+
+// MergeAsJSONArray returns a MergingReadPolicy that puts all the contents in a JSON array,
+// where a content is put as is if it's valid JSON, or as a string otherwise.
+func MergeAsJSONArray() MergingReadPolicy {
+	return mergeAsJSONArray{}
+}
+
+func (m mergeAsJSONArray) Merge(contents [][]byte) ([]byte, error) {
+	elements := make([]json.RawMessage, 0, len(contents))
+	for _, content := range contents {
+		if json.Valid(content) {
+			elements = append(elements, content)
+
+			continue
+		}
+		element, err := json.Marshal(string(content))
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+
+	return json.Marshal(elements)
+}
+
+// garbageCollecting makes ObjectEventStore delete the objects superseded by the one chosen by the wrapped ReadPolicy
+type garbageCollecting struct {
+	ReadPolicy
+}
+
+// ErrGarbageCollectingMerge is returned by New if the ReadPolicy is a MergingReadPolicy wrapped by CollectGarbage.
+var ErrGarbageCollectingMerge = errors.New("CollectGarbage can't wrap a MergingReadPolicy")
+
+// CollectGarbage wraps the ReadPolicy, so that ObjectEventStore deletes all the other objects under the same
+// key/source/ path after reading the chosen object, including the expired ones. The deletion is best effort,
+// failures are logged but don't fail the read, and the objects left are deleted by a later read.
+// It can't wrap a MergingReadPolicy, since a merged content is made of all the objects, see ErrGarbageCollectingMerge.
+func CollectGarbage(readPolicy ReadPolicy) ReadPolicy {
+	return garbageCollecting{ReadPolicy: readPolicy}
+}
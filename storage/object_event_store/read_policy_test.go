@@ -0,0 +1,230 @@
+package object_event_store_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/honestbank/event-driver/mocks"
+	"github.com/honestbank/event-driver/storage/object_event_store"
+)
+
+func TestTakeFirstCreatedPolicy(t *testing.T) {
+	t.Run("take the first created object", func(t *testing.T) {
+		items := makeItems(5)
+		ctrl := gomock.NewController(t)
+		mockIterator := mocks.NewMockObjectIterator(ctrl)
+		mockIterator.EXPECT().Next().DoAndReturn(func() (*object_event_store.ObjectAttrs, error) {
+			if len(items) == 0 {
+				return nil, object_event_store.ErrNoMoreObjects
+			}
+			item := items[0]
+			items = items[1:]
+
+			return item, nil
+		}).Times(len(items) + 1)
+
+		policy := object_event_store.TakeFirstCreated()
+		result, err := policy.Apply(mockIterator)
+		assert.NoError(t, err)
+		assert.Equal(t, "0", result.Name)
+	})
+
+	t.Run("fail in iteration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockIterator := mocks.NewMockObjectIterator(ctrl)
+		mockIterator.EXPECT().Next().DoAndReturn(func() (*object_event_store.ObjectAttrs, error) {
+			return nil, errors.New("test")
+		})
+
+		policy := object_event_store.TakeFirstCreated()
+		_, err := policy.Apply(mockIterator)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail if iterator is  nil", func(t *testing.T) {
+		policy := object_event_store.TakeFirstCreated()
+		_, err := policy.Apply(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestTakeLastCreatedPolicy(t *testing.T) {
+	t.Run("take the last created object", func(t *testing.T) {
+		items := makeItems(5)
+		ctrl := gomock.NewController(t)
+		mockIterator := mocks.NewMockObjectIterator(ctrl)
+		mockIterator.EXPECT().Next().DoAndReturn(func() (*object_event_store.ObjectAttrs, error) {
+			if len(items) == 0 {
+				return nil, object_event_store.ErrNoMoreObjects
+			}
+			item := items[0]
+			items = items[1:]
+
+			return item, nil
+		}).Times(len(items) + 1)
+
+		policy := object_event_store.TakeLastCreated()
+		result, err := policy.Apply(mockIterator)
+		assert.NoError(t, err)
+		assert.Equal(t, "4", result.Name)
+	})
+
+	t.Run("fail in iteration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockIterator := mocks.NewMockObjectIterator(ctrl)
+		mockIterator.EXPECT().Next().DoAndReturn(func() (*object_event_store.ObjectAttrs, error) {
+			return nil, errors.New("test")
+		})
+
+		policy := object_event_store.TakeLastCreated()
+		_, err := policy.Apply(mockIterator)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail if iterator is  nil", func(t *testing.T) {
+		policy := object_event_store.TakeLastCreated()
+		_, err := policy.Apply(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestFailOnMultiplePolicy(t *testing.T) {
+	t.Run("take the only object", func(t *testing.T) {
+		policy := object_event_store.FailOnMultiple()
+		result, err := policy.Apply(newMockIterator(t, makeItems(1)))
+		assert.NoError(t, err)
+		assert.Equal(t, "0", result.Name)
+
+		result, err = policy.Apply(newMockIterator(t, makeItems(0)))
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("fail on multiple objects", func(t *testing.T) {
+		policy := object_event_store.FailOnMultiple()
+		_, err := policy.Apply(newMockIterator(t, makeItems(2)))
+		assert.ErrorIs(t, err, object_event_store.ErrMultipleObjects)
+	})
+
+	t.Run("fail if iterator is  nil", func(t *testing.T) {
+		policy := object_event_store.FailOnMultiple()
+		_, err := policy.Apply(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestTakeHighestSequencePolicy(t *testing.T) {
+	t.Run("take the object with the highest sequence", func(t *testing.T) {
+		items := makeItems(5)
+		for i, sequence := range []string{"3", "10", "not-a-number", "2", ""} {
+			items[i].Metadata = map[string]string{"sequence": sequence}
+		}
+
+		policy := object_event_store.TakeHighestSequence("sequence")
+		result, err := policy.Apply(newMockIterator(t, items))
+		assert.NoError(t, err)
+		assert.Equal(t, "1", result.Name)
+	})
+
+	t.Run("prefer objects with the field, then the last created", func(t *testing.T) {
+		items := makeItems(4)
+		items[1].Metadata = map[string]string{"sequence": "1"}
+		items[2].Metadata = map[string]string{"sequence": "1"}
+
+		policy := object_event_store.TakeHighestSequence("sequence")
+		result, err := policy.Apply(newMockIterator(t, items))
+		assert.NoError(t, err)
+		assert.Equal(t, "2", result.Name)
+
+		result, err = policy.Apply(newMockIterator(t, makeItems(3)))
+		assert.NoError(t, err)
+		assert.Equal(t, "2", result.Name)
+	})
+
+	t.Run("fail in iteration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockIterator := mocks.NewMockObjectIterator(ctrl)
+		mockIterator.EXPECT().Next().DoAndReturn(func() (*object_event_store.ObjectAttrs, error) {
+			return nil, errors.New("test")
+		})
+
+		policy := object_event_store.TakeByMetadata("field", func(candidate, current string) bool {
+			return candidate > current
+		})
+		_, err := policy.Apply(mockIterator)
+		assert.Error(t, err)
+	})
+
+	t.Run("fail if iterator is  nil", func(t *testing.T) {
+		policy := object_event_store.TakeHighestSequence("sequence")
+		_, err := policy.Apply(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestMergingPolicies(t *testing.T) {
+	contents := [][]byte{[]byte(`{"a":1}`), []byte("plain text"), []byte("2")}
+
+	t.Run("merge concatenated", func(t *testing.T) {
+		policy := object_event_store.MergeConcatenated("\n")
+		merged, err := policy.Merge(contents)
+		assert.NoError(t, err)
+		assert.Equal(t, "{\"a\":1}\nplain text\n2", string(merged))
+
+		result, err := policy.Apply(newMockIterator(t, makeItems(3)))
+		assert.NoError(t, err)
+		assert.Equal(t, "2", result.Name)
+	})
+
+	t.Run("merge as JSON array", func(t *testing.T) {
+		policy := object_event_store.MergeAsJSONArray()
+		merged, err := policy.Merge(contents)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `[{"a":1},"plain text",2]`, string(merged))
+
+		merged, err = policy.Merge(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "[]", string(merged))
+	})
+}
+
+func TestCollectGarbagePolicy(t *testing.T) {
+	policy := object_event_store.CollectGarbage(object_event_store.TakeLastCreated())
+	result, err := policy.Apply(newMockIterator(t, makeItems(3)))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", result.Name)
+}
+
+func newMockIterator(t *testing.T, items []*object_event_store.ObjectAttrs) object_event_store.ObjectIterator {
+	ctrl := gomock.NewController(t)
+	mockIterator := mocks.NewMockObjectIterator(ctrl)
+	mockIterator.EXPECT().Next().DoAndReturn(func() (*object_event_store.ObjectAttrs, error) {
+		if len(items) == 0 {
+			return nil, object_event_store.ErrNoMoreObjects
+		}
+		item := items[0]
+		items = items[1:]
+
+		return item, nil
+	}).MaxTimes(len(items) + 1) // policies may stop iterating early
+
+	return mockIterator
+}
+
+func makeItems(number int) []*object_event_store.ObjectAttrs {
+	timeBase := time.Now()
+	objects := make([]*object_event_store.ObjectAttrs, 0, number)
+	for i := 0; i < number; i++ {
+		objects = append(objects, &object_event_store.ObjectAttrs{
+			Name:    strconv.Itoa(i),
+			Created: timeBase.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	return objects
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrKeyListingUnsupported is returned by ListKeys of the decorators, e.g. CachingStore, whose wrapped store
+// doesn't implement KeyListingEventStore.
+var ErrKeyListingUnsupported = errors.New("the event store doesn't implement storage.KeyListingEventStore")
+
+// ScanKeys calls visit with every key of the store starting with the prefix, listing pageSize keys at a time,
+// e.g. to find the keys of stale partial joins. It stops at the first error of the store or of visit.
+func ScanKeys(
+	ctx context.Context,
+	eventStore KeyListingEventStore,
+	prefix string,
+	pageSize int,
+	visit func(key string) error) error {
+	cursor := ""
+	for {
+		keys, nextCursor, err := eventStore.ListKeys(ctx, prefix, cursor, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err = visit(key); err != nil {
+				return err
+			}
+		}
+		if nextCursor == "" {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
+
+// listKeys is ListKeys of the stores that hold their keys in memory: it returns a page of the keys starting with
+// the prefix, in lexicographic order, where the cursor is the last listed key.
+// Every call sorts the matching keys of the whole store, which is fine for the sizes an in-memory store holds.
+func listKeys(keys []string, prefix, cursor string, limit int) ([]string, string) {
+	matchingKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) && (cursor == "" || key > cursor) {
+			matchingKeys = append(matchingKeys, key)
+		}
+	}
+	sort.Strings(matchingKeys)
+	if limit <= 0 || len(matchingKeys) <= limit {
+		return matchingKeys, ""
+	}
+
+	return matchingKeys[:limit], matchingKeys[limit-1]
+}
@@ -0,0 +1,41 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/storage"
+)
+
+func TestScanKeys(t *testing.T) {
+	ctx := context.TODO()
+	inMemoryStore := storage.NewInMemoryStore()
+	for _, key := range []string{"a1", "a2", "a3", "b1"} {
+		assert.NoError(t, inMemoryStore.Persist(ctx, key, source1, "content"))
+	}
+
+	keys := make([]string, 0)
+	err := storage.ScanKeys(ctx, inMemoryStore, "a", 2, func(key string) error {
+		keys = append(keys, key)
+
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "a2", "a3"}, keys)
+
+	errStop := errors.New("stop")
+	keys = make([]string, 0)
+	err = storage.ScanKeys(ctx, inMemoryStore, "", 1, func(key string) error {
+		keys = append(keys, key)
+		if key == "a2" {
+			return errStop
+		}
+
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{"a1", "a2"}, keys)
+}
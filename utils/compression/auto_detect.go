@@ -0,0 +1,191 @@
+package compression
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupportedCodec is returned when decompressing a content of a well-known format that has no registered Codec.
+var ErrUnsupportedCodec = errors.New("unsupported codec")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	// frameMagic prefixes the contents of Framed codecs. 0xff never occurs in UTF-8 text, so it can't be mistaken
+	// for an uncompressed JSON content.
+	frameMagic = []byte{0xff, 'e', 'd'}
+	// wellKnownMagics are detected even without a registered Codec, to fail instead of returning compressed bytes.
+	wellKnownMagics = []struct {
+		name  string
+		magic []byte
+	}{
+		{name: "gzip", magic: gzipMagic},
+		{name: "zstd", magic: []byte{0x28, 0xb5, 0x2f, 0xfd}},
+		{name: "lz4", magic: []byte{0x04, 0x22, 0x4d, 0x18}},
+		{name: "framed", magic: frameMagic},
+	}
+)
+
+// Codec is a Compressor whose compressed contents start with a magic prefix, so that AutoDetect recognizes them.
+type Codec struct {
+	Magic      []byte
+	Compressor Compressor
+}
+
+// GzipCodec returns the Codec of Gzip, which is recognized by the magic number of gzip.
+func GzipCodec(level int) Codec {
+	return Codec{
+		Magic:      gzipMagic,
+		Compressor: Gzip(level),
+	}
+}
+
+// DeflateCodec returns the Codec of Deflate. Raw DEFLATE streams have no magic number, so the contents are Framed.
+func DeflateCodec(level int) Codec {
+	return Framed('d', Deflate(level))
+}
+
+// NoopCodec returns the Codec of uncompressed contents. They have no magic prefix, so that they stay as they are,
+// and AutoDetect reads them with its fallback.
+func NoopCodec() Codec {
+	return Codec{
+		Compressor: Noop(),
+	}
+}
+
+// Framed returns a Codec that prefixes the contents of a compressor without a magic number of its own,
+// e.g. snappy blocks, with a 4-byte frame header: 0xff 'e' 'd' followed by the ID of the codec.
+// IDs only need to be unique among the codecs given to AutoDetect, where 'd' is taken by DeflateCodec.
+func Framed(id byte, compressor Compressor) Codec {
+	header := append(append([]byte{}, frameMagic...), id)
+
+	return Codec{
+		Magic:      header,
+		Compressor: framedCompressor{header: header, compressor: compressor},
+	}
+}
+
+type framedCompressor struct {
+	header     []byte
+	compressor Compressor
+}
+
+func (f framedCompressor) Compress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+	compressed, err := f.compressor.Compress(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(make([]byte, 0, len(f.header)+len(compressed)), f.header...), compressed...), nil
+}
+
+func (f framedCompressor) Decompress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+	if !bytes.HasPrefix(input, f.header) {
+		return nil, errMissingFrameHeader(f.header)
+	}
+
+	return f.compressor.Decompress(input[len(f.header):])
+}
+
+func errMissingFrameHeader(header []byte) error {
+	return fmt.Errorf("content doesn't start with the frame header %x", header)
+}
+
+// AutoDetector is a Compressor that compresses with one Codec, and decompresses with whichever Codec
+// the magic prefix of the content belongs to, so that the compressor of a store can be changed without rewriting
+// the contents written before.
+type AutoDetector struct {
+	writer   Codec
+	readers  []Codec
+	fallback Compressor
+}
+
+// AutoDetect returns an AutoDetector that compresses with the writer Codec, and decompresses the contents of
+// the writer and the readers. A content without a known magic prefix is decompressed by the fallback,
+// which is Noop by default, i.e. it is considered uncompressed. E.g. to move from gzip to zstd:
+//
+//	compression.AutoDetect(compressors.ZstdCodec(3), compression.GzipCodec(gzip.DefaultCompression))
+func AutoDetect(writer Codec, readers ...Codec) *AutoDetector {
+	return &AutoDetector{
+		writer:   writer,
+		readers:  append([]Codec{writer}, readers...),
+		fallback: Noop(),
+	}
+}
+
+// WithFallback sets the Compressor of the contents without a known magic prefix,
+// e.g. the headerless compressor that a store used before moving to AutoDetect.
+// Contents that start with the magic number of gzip, zstd, lz4 or a frame header never reach the fallback,
+// so the Codec of these formats must be registered to read them.
+func (a *AutoDetector) WithFallback(fallback Compressor) *AutoDetector {
+	a.fallback = fallback
+
+	return a
+}
+
+func (a *AutoDetector) Compress(input []byte) ([]byte, error) {
+	return a.writer.Compressor.Compress(input)
+}
+
+func (a *AutoDetector) Decompress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+	compressor, err := a.choose(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return compressor.Decompress(input)
+}
+
+// choose returns the Compressor of the content that starts with the prefix.
+func (a *AutoDetector) choose(prefix []byte) (Compressor, error) {
+	if codec, isFound := a.detect(prefix); isFound {
+		return codec.Compressor, nil
+	}
+	for _, wellKnown := range wellKnownMagics {
+		if bytes.HasPrefix(prefix, wellKnown.magic) {
+			return nil, fmt.Errorf("%w: content looks like %s, but no codec is registered for it",
+				ErrUnsupportedCodec, wellKnown.name)
+		}
+	}
+
+	return a.fallback, nil
+}
+
+// detect returns the reader with the longest magic prefix of the input.
+func (a *AutoDetector) detect(input []byte) (Codec, bool) {
+	var detected Codec
+	isFound := false
+	for _, reader := range a.readers {
+		if len(reader.Magic) == 0 || !bytes.HasPrefix(input, reader.Magic) {
+			continue
+		}
+		if !isFound || len(reader.Magic) > len(detected.Magic) {
+			detected = reader
+			isFound = true
+		}
+	}
+
+	return detected, isFound
+}
+
+// maxMagicSize returns the size of the longest magic prefix that choose may look at.
+func (a *AutoDetector) maxMagicSize() int {
+	maxSize := 0
+	for _, reader := range a.readers {
+		maxSize = max(maxSize, len(reader.Magic))
+	}
+	for _, wellKnown := range wellKnownMagics {
+		maxSize = max(maxSize, len(wellKnown.magic))
+	}
+
+	return maxSize
+}
@@ -2,6 +2,7 @@ package compression
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
 	"io"
 )
@@ -54,6 +55,50 @@ func (g gzipCompressor) Decompress(input []byte) ([]byte, error) {
 	return io.ReadAll(gzipReader)
 }
 
+type deflateCompressor struct {
+	level int
+}
+
+// Deflate returns a Compressor of raw DEFLATE streams, i.e. gzip without its header & checksum,
+// which saves a few bytes on small contents.
+func Deflate(level int) Compressor {
+	return deflateCompressor{
+		level: level,
+	}
+}
+
+func (d deflateCompressor) Compress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+	var buffer bytes.Buffer
+	flateWriter, err := flate.NewWriter(&buffer, d.level)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = flateWriter.Write(input)
+	if err != nil {
+		return nil, err
+	}
+	err = flateWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (d deflateCompressor) Decompress(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return input, nil
+	}
+	flateReader := flate.NewReader(bytes.NewReader(input))
+	defer flateReader.Close()
+
+	return io.ReadAll(flateReader)
+}
+
 type noop struct {
 }
 
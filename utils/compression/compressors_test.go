@@ -1,7 +1,11 @@
 package compression_test
 
 import (
+	"bytes"
+	"compress/flate"
 	"compress/gzip"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/honestbank/event-driver/utils/compression"
@@ -33,6 +37,41 @@ func TestGzip(t *testing.T) {
 	})
 }
 
+func TestDeflate(t *testing.T) {
+	input := []byte("test string")
+	compressor := compression.Deflate(flate.BestCompression)
+
+	t.Run("round trip", func(t *testing.T) {
+		compressed, err := compressor.Compress(input)
+		assert.NoError(t, err)
+		assert.NotEqual(t, input, compressed)
+
+		roundTripped, err := compressor.Decompress(compressed)
+		assert.NoError(t, err)
+		assert.Equal(t, input, roundTripped)
+	})
+
+	t.Run("empty bytes", func(t *testing.T) {
+		compressed, err := compressor.Compress([]byte{})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{}, compressed)
+
+		decompressed, err := compressor.Decompress([]byte{})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{}, decompressed)
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		_, err := compression.Deflate(100).Compress(input)
+		assert.Error(t, err)
+	})
+
+	t.Run("corrupted input", func(t *testing.T) {
+		_, err := compressor.Decompress([]byte("not deflate"))
+		assert.Error(t, err)
+	})
+}
+
 func TestNoop(t *testing.T) {
 	input := []byte("test string")
 	compressor := compression.Noop()
@@ -45,3 +84,163 @@ func TestNoop(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, input, roundTripped)
 }
+
+func TestAutoDetect(t *testing.T) {
+	input := []byte(`{"account":"123","amount":100}`)
+	gzipCompressed, err := compression.Gzip(gzip.BestSpeed).Compress(input)
+	assert.NoError(t, err)
+	deflateCompressed, err := compression.Deflate(flate.BestSpeed).Compress(input)
+	assert.NoError(t, err)
+
+	t.Run("migrate from gzip to deflate", func(t *testing.T) {
+		compressor := compression.AutoDetect(
+			compression.DeflateCodec(flate.BestSpeed),
+			compression.GzipCodec(gzip.BestSpeed))
+
+		compressed, err := compressor.Compress(input)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0xff, 'e', 'd', 'd'}, compressed[:4])
+		for _, content := range [][]byte{compressed, gzipCompressed, input} {
+			decompressed, err := compressor.Decompress(content)
+			assert.NoError(t, err)
+			assert.Equal(t, input, decompressed)
+		}
+		decompressed, err := compressor.Decompress([]byte{})
+		assert.NoError(t, err)
+		assert.Empty(t, decompressed)
+	})
+
+	t.Run("compress an uncompressed store", func(t *testing.T) {
+		compressor := compression.AutoDetect(compression.GzipCodec(gzip.BestSpeed))
+
+		compressed, err := compressor.Compress(input)
+		assert.NoError(t, err)
+		assert.Equal(t, gzipCompressed, compressed)
+		decompressed, err := compressor.Decompress(input)
+		assert.NoError(t, err)
+		assert.Equal(t, input, decompressed)
+	})
+
+	t.Run("stop compressing a store", func(t *testing.T) {
+		compressor := compression.AutoDetect(compression.NoopCodec(), compression.GzipCodec(gzip.BestSpeed))
+
+		compressed, err := compressor.Compress(input)
+		assert.NoError(t, err)
+		assert.Equal(t, input, compressed)
+		decompressed, err := compressor.Decompress(gzipCompressed)
+		assert.NoError(t, err)
+		assert.Equal(t, input, decompressed)
+	})
+
+	t.Run("fallback to a headerless compressor", func(t *testing.T) {
+		compressor := compression.AutoDetect(compression.DeflateCodec(flate.BestSpeed)).
+			WithFallback(compression.Deflate(flate.BestSpeed))
+
+		decompressed, err := compressor.Decompress(deflateCompressed)
+		assert.NoError(t, err)
+		assert.Equal(t, input, decompressed)
+	})
+
+	t.Run("unsupported codecs", func(t *testing.T) {
+		compressor := compression.AutoDetect(compression.NoopCodec())
+
+		_, err := compressor.Decompress(gzipCompressed)
+		assert.ErrorIs(t, err, compression.ErrUnsupportedCodec)
+		_, err = compressor.Decompress([]byte{0x28, 0xb5, 0x2f, 0xfd, 0})
+		assert.ErrorIs(t, err, compression.ErrUnsupportedCodec)
+		_, err = compressor.Decompress([]byte{0xff, 'e', 'd', 's', 0})
+		assert.ErrorIs(t, err, compression.ErrUnsupportedCodec)
+	})
+}
+
+func TestFramed(t *testing.T) {
+	input := []byte("test string")
+	codec := compression.Framed('n', compression.Noop())
+	assert.Equal(t, []byte{0xff, 'e', 'd', 'n'}, codec.Magic)
+
+	compressed, err := codec.Compressor.Compress(input)
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0xff, 'e', 'd', 'n'}, input...), compressed)
+	roundTripped, err := codec.Compressor.Decompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, input, roundTripped)
+
+	_, err = codec.Compressor.Decompress(input)
+	assert.Error(t, err)
+	compressed, err = codec.Compressor.Compress([]byte{})
+	assert.NoError(t, err)
+	assert.Empty(t, compressed)
+}
+
+func TestStreams(t *testing.T) {
+	input := []byte(strings.Repeat(`{"account":"123","amount":100}`, 1000))
+
+	for name, compressor := range map[string]compression.Compressor{
+		"gzip":    compression.Gzip(gzip.BestSpeed),
+		"deflate": compression.Deflate(flate.BestSpeed),
+		"noop":    compression.Noop(),
+		"framed":  compression.DeflateCodec(flate.BestSpeed).Compressor,
+		"auto detect": compression.AutoDetect(compression.GzipCodec(gzip.BestSpeed),
+			compression.DeflateCodec(flate.BestSpeed)),
+		// hides the streaming methods of gzip
+		"buffered": struct{ compression.Compressor }{compression.Gzip(gzip.BestSpeed)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			compressed, err := compressor.Compress(input)
+			assert.NoError(t, err)
+
+			var buffer bytes.Buffer
+			writer := compression.NewWriter(compressor, &buffer)
+			for i := 0; i < len(input); i += 1000 {
+				_, err = writer.Write(input[i:min(i+1000, len(input))])
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, writer.Close())
+			assert.Equal(t, compressed, buffer.Bytes())
+
+			reader, err := compression.NewReader(compressor, bytes.NewReader(compressed))
+			assert.NoError(t, err)
+			decompressed, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.NoError(t, reader.Close())
+			assert.Equal(t, input, decompressed)
+
+			buffer.Reset()
+			writer = compression.NewWriter(compressor, &buffer)
+			assert.NoError(t, writer.Close())
+			assert.Empty(t, buffer.Bytes())
+			reader, err = compression.NewReader(compressor, &buffer)
+			assert.NoError(t, err)
+			decompressed, err = io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Empty(t, decompressed)
+		})
+	}
+
+	t.Run("auto detect the codec of a stream", func(t *testing.T) {
+		compressor := compression.AutoDetect(compression.NoopCodec(), compression.DeflateCodec(flate.BestSpeed))
+		compressed, err := compression.DeflateCodec(flate.BestSpeed).Compressor.Compress(input)
+		assert.NoError(t, err)
+
+		for content, expected := range map[string][]byte{
+			string(compressed): input,
+			string(input):      input,
+			"{}":               []byte("{}"), // shorter than the magic prefixes
+		} {
+			reader, err := compression.NewReader(compressor, strings.NewReader(content))
+			assert.NoError(t, err)
+			decompressed, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, decompressed)
+		}
+		_, err = compression.NewReader(compressor, bytes.NewReader([]byte{0x1f, 0x8b}))
+		assert.ErrorIs(t, err, compression.ErrUnsupportedCodec)
+	})
+
+	t.Run("corrupted stream", func(t *testing.T) {
+		_, err := compression.NewReader(compression.Gzip(gzip.BestSpeed), strings.NewReader("not gzip"))
+		assert.Error(t, err)
+		_, err = compression.NewReader(compression.DeflateCodec(flate.BestSpeed).Compressor, strings.NewReader("ed"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,189 @@
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+)
+
+// StreamCompressor is a Compressor that also compresses & decompresses streams,
+// so that large contents don't have to be held in memory twice, compressed and decompressed.
+// Use NewWriter and NewReader rather than calling these methods directly: they handle the empty contents
+// like Compress & Decompress do, and fall back to buffering for the compressors that don't stream.
+type StreamCompressor interface {
+	Compressor
+	// NewWriter returns a writer that compresses everything written to it into w. Closing it flushes the compressed
+	// content, but doesn't close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader of the decompressed content of r, which is never empty.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// NewWriter returns a writer that compresses everything written to it into w, so that Decompress or NewReader
+// restores the content, and nothing is written for an empty content like Compress does.
+// The compressors of this package write the same output as Compress, but other StreamCompressors may not,
+// e.g. zstd encodes the streams differently from the whole contents. The same content is still streamed
+// into the same output every time, so a content addressed by the hash of its stream is always streamed.
+// The content is buffered until Close if the compressor isn't a StreamCompressor.
+func NewWriter(compressor Compressor, w io.Writer) io.WriteCloser {
+	streamCompressor, isStreaming := compressor.(StreamCompressor)
+	if !isStreaming {
+		return &bufferedWriter{
+			compressor: compressor,
+			w:          w,
+		}
+	}
+
+	return &lazyWriter{
+		newWriter: func() (io.WriteCloser, error) {
+			return streamCompressor.NewWriter(w)
+		},
+	}
+}
+
+// NewReader returns a reader of the decompressed content of r, where an empty content is decompressed as empty,
+// like compressor.Decompress does. The content is read whole if the compressor isn't a StreamCompressor.
+func NewReader(compressor Compressor, r io.Reader) (io.ReadCloser, error) {
+	bufferedReader := bufio.NewReader(r)
+	if _, err := bufferedReader.Peek(1); err == io.EOF {
+		return io.NopCloser(bufferedReader), nil
+	} else if err != nil {
+		return nil, err
+	}
+	streamCompressor, isStreaming := compressor.(StreamCompressor)
+	if !isStreaming {
+		compressedContent, err := io.ReadAll(bufferedReader)
+		if err != nil {
+			return nil, err
+		}
+		content, err := compressor.Decompress(compressedContent)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	return streamCompressor.NewReader(bufferedReader)
+}
+
+// lazyWriter creates the compressing writer at the first non-empty write, so that nothing is written for
+// an empty content.
+type lazyWriter struct {
+	newWriter func() (io.WriteCloser, error)
+	writer    io.WriteCloser
+}
+
+func (l *lazyWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if l.writer == nil {
+		writer, err := l.newWriter()
+		if err != nil {
+			return 0, err
+		}
+		l.writer = writer
+	}
+
+	return l.writer.Write(p)
+}
+
+func (l *lazyWriter) Close() error {
+	if l.writer == nil {
+		return nil
+	}
+
+	return l.writer.Close()
+}
+
+// bufferedWriter compresses the whole content at Close, for the compressors that don't stream.
+type bufferedWriter struct {
+	compressor Compressor
+	w          io.Writer
+	buffer     bytes.Buffer
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	return b.buffer.Write(p)
+}
+
+func (b *bufferedWriter) Close() error {
+	compressedContent, err := b.compressor.Compress(b.buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = b.w.Write(compressedContent)
+
+	return err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (n nopWriteCloser) Close() error {
+	return nil
+}
+
+func (g gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, g.level)
+}
+
+func (g gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func (d deflateCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, d.level)
+}
+
+func (d deflateCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+func (n noop) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return nopWriteCloser{Writer: w}, nil
+}
+
+func (n noop) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return io.NopCloser(r), nil
+}
+
+func (f framedCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if _, err := w.Write(f.header); err != nil {
+		return nil, err
+	}
+
+	return NewWriter(f.compressor, w), nil
+}
+
+func (f framedCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	header := make([]byte, len(f.header))
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header, f.header) {
+		return nil, errMissingFrameHeader(f.header)
+	}
+
+	return NewReader(f.compressor, r)
+}
+
+func (a *AutoDetector) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return NewWriter(a.writer.Compressor, w), nil
+}
+
+func (a *AutoDetector) NewReader(r io.Reader) (io.ReadCloser, error) {
+	bufferedReader := bufio.NewReader(r)
+	// a short peek is fine, the content is just shorter than the longest magic prefix
+	prefix, err := bufferedReader.Peek(a.maxMagicSize())
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	compressor, err := a.choose(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReader(compressor, bufferedReader)
+}
@@ -0,0 +1,174 @@
+package encryption_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/honestbank/event-driver/event"
+	"github.com/honestbank/event-driver/storage"
+	"github.com/honestbank/event-driver/utils/compression"
+	"github.com/honestbank/event-driver/utils/encryption"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, 32)
+	key2 = bytes.Repeat([]byte{2}, 16)
+)
+
+func TestEncryptor(t *testing.T) {
+	ctx := context.TODO()
+	plaintext := []byte(`{"account":"123","amount":100}`)
+
+	t.Run("round trip", func(t *testing.T) {
+		keyRing, err := encryption.NewKeyRing("key1", key1)
+		assert.NoError(t, err)
+		encryptor := encryption.New(keyRing)
+
+		envelope, err := encryptor.Encrypt(ctx, plaintext, []byte("associated"))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(envelope), "account")
+		keyID, err := encryption.KeyID(envelope)
+		assert.NoError(t, err)
+		assert.Equal(t, "key1", keyID)
+		anotherEnvelope, err := encryptor.Encrypt(ctx, plaintext, []byte("associated"))
+		assert.NoError(t, err)
+		assert.NotEqual(t, envelope, anotherEnvelope)
+
+		decrypted, err := encryptor.Decrypt(ctx, envelope, []byte("associated"))
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+		_, err = encryptor.Decrypt(ctx, envelope, []byte("other"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rotate keys", func(t *testing.T) {
+		keyRing, err := encryption.NewKeyRing("key1", key1)
+		assert.NoError(t, err)
+		encryptor := encryption.New(keyRing)
+		oldEnvelope, err := encryptor.Encrypt(ctx, plaintext, nil)
+		assert.NoError(t, err)
+
+		assert.NoError(t, keyRing.Rotate("key2", key2))
+		assert.Equal(t, "key2", keyRing.CurrentKeyID())
+		newEnvelope, err := encryptor.Encrypt(ctx, plaintext, nil)
+		assert.NoError(t, err)
+		keyID, err := encryption.KeyID(newEnvelope)
+		assert.NoError(t, err)
+		assert.Equal(t, "key2", keyID)
+		decrypted, err := encryptor.Decrypt(ctx, oldEnvelope, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+
+		// rewrapping moves the envelope to the current key, so the old key can be retired
+		rewrapped, err := encryptor.Rewrap(ctx, oldEnvelope)
+		assert.NoError(t, err)
+		keyID, err = encryption.KeyID(rewrapped)
+		assert.NoError(t, err)
+		assert.Equal(t, "key2", keyID)
+		retiredKeyRing, err := encryption.NewKeyRing("key2", key2)
+		assert.NoError(t, err)
+		decrypted, err = encryption.New(retiredKeyRing).Decrypt(ctx, rewrapped, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+		_, err = encryption.New(retiredKeyRing).Decrypt(ctx, oldEnvelope, nil)
+		assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, err := encryption.NewKeyRing("key1", []byte("too short"))
+		assert.Error(t, err)
+		_, err = encryption.NewKeyRing("", key1)
+		assert.Error(t, err)
+		keyRing, err := encryption.NewKeyRing("key1", key1)
+		assert.NoError(t, err)
+		assert.Error(t, keyRing.Add("key1", key2))
+		assert.NoError(t, keyRing.Add("key2", key2))
+		assert.Equal(t, "key1", keyRing.CurrentKeyID())
+	})
+
+	t.Run("malformed envelopes", func(t *testing.T) {
+		keyRing, err := encryption.NewKeyRing("key1", key1)
+		assert.NoError(t, err)
+		encryptor := encryption.New(keyRing)
+		envelope, err := encryptor.Encrypt(ctx, plaintext, nil)
+		assert.NoError(t, err)
+
+		for _, malformed := range [][]byte{nil, {2}, envelope[:3], envelope[:10]} {
+			_, err = encryptor.Decrypt(ctx, malformed, nil)
+			assert.ErrorIs(t, err, encryption.ErrMalformedEnvelope)
+		}
+		tampered := append([]byte{}, envelope...)
+		tampered[len(tampered)-1] ^= 1
+		_, err = encryptor.Decrypt(ctx, tampered, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestCompressor(t *testing.T) {
+	keyRing, err := encryption.NewKeyRing("key1", key1)
+	assert.NoError(t, err)
+	compressor := encryption.Compressor(encryption.New(keyRing), compression.Gzip(gzip.BestSpeed))
+	input := bytes.Repeat([]byte("test string"), 10)
+
+	compressed, err := compressor.Compress(input)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(compressed), "test string")
+	roundTripped, err := compressor.Decompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, input, roundTripped)
+
+	_, err = compressor.Decompress(input)
+	assert.ErrorIs(t, err, encryption.ErrMalformedEnvelope)
+
+	compressedAgain, err := compressor.Compress(input)
+	assert.NoError(t, err)
+	assert.NotEqual(t, compressed, compressedAgain, "every write has its own data key & nonce")
+
+	slowCompressor := encryption.Compressor(encryption.New(slowKeyProvider{KeyRing: keyRing}), compression.Noop()).
+		WithTimeout(time.Millisecond)
+	_, err = slowCompressor.Compress(input)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// slowKeyProvider wraps the data keys once the context is done.
+type slowKeyProvider struct {
+	*encryption.KeyRing
+}
+
+func (s slowKeyProvider) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	<-ctx.Done()
+
+	return "", nil, ctx.Err()
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.TODO()
+	keyRing, err := encryption.NewKeyRing("key1", key1)
+	assert.NoError(t, err)
+	inMemoryStore := storage.NewInMemoryStore()
+	eventStore := encryption.Middleware(encryption.New(keyRing))(inMemoryStore)
+
+	assert.NoError(t, eventStore.Persist(ctx, "key", "source", "content"))
+	stored, err := inMemoryStore.LookUp(ctx, "key", "source")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "content", stored.GetContent())
+	message, err := eventStore.LookUp(ctx, "key", "source")
+	assert.NoError(t, err)
+	assert.Equal(t, event.NewMessage("key", "source", "content"), message)
+	isPersisted, err := eventStore.(storage.ConditionalEventStore).PersistIfAbsent(ctx, "key", "source", "content")
+	assert.NoError(t, err)
+	assert.False(t, isPersisted)
+
+	// an envelope is bound to its key & source
+	assert.NoError(t, inMemoryStore.Persist(ctx, "key", "another-source", stored.GetContent()))
+	_, err = eventStore.LookUp(ctx, "key", "another-source")
+	assert.Error(t, err)
+	assert.NoError(t, inMemoryStore.Persist(ctx, "key", "plaintext", "not base64!"))
+	_, err = eventStore.LookUp(ctx, "key", "plaintext")
+	assert.ErrorIs(t, err, encryption.ErrMalformedEnvelope)
+}
@@ -0,0 +1,160 @@
+package encryption
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	envelopeVersion = 1
+	dataKeySize     = 32 // AES-256
+	maxKeyIDSize    = 255
+)
+
+// ErrMalformedEnvelope is returned when decrypting a content that isn't an envelope made by Encryptor.
+var ErrMalformedEnvelope = errors.New("malformed envelope")
+
+// Encryptor does envelope encryption: every content is encrypted with its own random AES-256-GCM data key,
+// and the data key is wrapped by the KeyProvider. The envelope holds
+//
+//	version (1 byte) | key ID size (1 byte) | key ID | wrapped key size (2 bytes) | wrapped key | nonce | ciphertext
+//
+// so that it can be decrypted after the key-encryption key is rotated.
+type Encryptor struct {
+	keyProvider KeyProvider
+}
+
+func New(keyProvider KeyProvider) *Encryptor {
+	return &Encryptor{
+		keyProvider: keyProvider,
+	}
+}
+
+// Encrypt seals the plaintext into an envelope. The associated data isn't stored,
+// but the same associated data must be given to decrypt the envelope, e.g. the key & source of the content.
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext, associatedData []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	keyID, wrappedKey, err := e.keyProvider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	envelope, err := appendHeader(make([]byte, 0, len(plaintext)+256), keyID, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	envelope = append(envelope, nonce...)
+
+	return aead.Seal(envelope, nonce, plaintext, associatedData), nil
+}
+
+// Decrypt opens the envelope with the data key unwrapped by the KeyProvider.
+func (e *Encryptor) Decrypt(ctx context.Context, envelope, associatedData []byte) ([]byte, error) {
+	parsed, err := parse(envelope)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := e.keyProvider.UnwrapKey(ctx, parsed.keyID, parsed.wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.sealed) < aead.NonceSize() {
+		return nil, ErrMalformedEnvelope
+	}
+	nonce, ciphertext := parsed.sealed[:aead.NonceSize()], parsed.sealed[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, associatedData)
+}
+
+// Rewrap re-wraps the data key of the envelope with the current key of the KeyProvider, without touching the
+// ciphertext, so that a rotated key can be retired once all the envelopes are rewrapped.
+func (e *Encryptor) Rewrap(ctx context.Context, envelope []byte) ([]byte, error) {
+	parsed, err := parse(envelope)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := e.keyProvider.UnwrapKey(ctx, parsed.keyID, parsed.wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	keyID, wrappedKey, err := e.keyProvider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	rewrapped, err := appendHeader(make([]byte, 0, len(parsed.sealed)+256), keyID, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(rewrapped, parsed.sealed...), nil
+}
+
+// appendHeader appends the version, the key ID and the wrapped key of an envelope.
+func appendHeader(envelope []byte, keyID string, wrappedKey []byte) ([]byte, error) {
+	if len(keyID) > maxKeyIDSize || len(wrappedKey) > math.MaxUint16 {
+		return nil, fmt.Errorf("key ID %s or its wrapped key is too long", keyID)
+	}
+	envelope = append(envelope, envelopeVersion, byte(len(keyID)))
+	envelope = append(envelope, keyID...)
+	envelope = binary.BigEndian.AppendUint16(envelope, uint16(len(wrappedKey)))
+
+	return append(envelope, wrappedKey...), nil
+}
+
+// KeyID returns the ID of the key-encryption key that wrapped the data key of the envelope.
+func KeyID(envelope []byte) (string, error) {
+	parsed, err := parse(envelope)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.keyID, nil
+}
+
+type parsedEnvelope struct {
+	keyID      string
+	wrappedKey []byte
+	sealed     []byte // nonce and ciphertext
+}
+
+func parse(envelope []byte) (parsedEnvelope, error) {
+	if len(envelope) < 2 || envelope[0] != envelopeVersion {
+		return parsedEnvelope{}, ErrMalformedEnvelope
+	}
+	keyIDSize := int(envelope[1])
+	rest := envelope[2:]
+	if len(rest) < keyIDSize+2 {
+		return parsedEnvelope{}, ErrMalformedEnvelope
+	}
+	keyID := string(rest[:keyIDSize])
+	wrappedKeySize := int(binary.BigEndian.Uint16(rest[keyIDSize:]))
+	rest = rest[keyIDSize+2:]
+	if len(rest) < wrappedKeySize {
+		return parsedEnvelope{}, ErrMalformedEnvelope
+	}
+
+	return parsedEnvelope{
+		keyID:      keyID,
+		wrappedKey: rest[:wrappedKeySize],
+		sealed:     rest[wrappedKeySize:],
+	}, nil
+}
@@ -0,0 +1,127 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownKey is returned when a content was encrypted with a key that the KeyProvider doesn't have.
+var ErrUnknownKey = errors.New("unknown key")
+
+// KeyProvider wraps and unwraps the data keys of the envelopes with its key-encryption keys,
+// e.g. locally with a KeyRing, or remotely with a KMS that never reveals its keys.
+type KeyProvider interface {
+	// WrapKey encrypts the data key with the current key-encryption key, and returns the ID of that key
+	// along with the wrapped data key. The ID is stored in the envelope, and must be at most 255 bytes.
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrappedKey []byte, err error)
+	// UnwrapKey decrypts the data key with the key-encryption key of the ID,
+	// or returns ErrUnknownKey if there is no such key.
+	UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error)
+}
+
+// KeyRing is a KeyProvider that holds the key-encryption keys in memory, and wraps the data keys with AES-GCM.
+// Rotating the ring makes a new key current for new envelopes, while the previous keys still unwrap the old ones.
+type KeyRing struct {
+	lock      sync.RWMutex
+	currentID string
+	keys      map[string]cipher.AEAD
+}
+
+// NewKeyRing creates a KeyRing whose current key is the AES-128, AES-192 or AES-256 key of the ID.
+func NewKeyRing(keyID string, key []byte) (*KeyRing, error) {
+	keyRing := &KeyRing{
+		keys: make(map[string]cipher.AEAD),
+	}
+	if err := keyRing.Rotate(keyID, key); err != nil {
+		return nil, err
+	}
+
+	return keyRing, nil
+}
+
+// Rotate adds the key, and makes it the current key that wraps the data keys of new envelopes.
+func (k *KeyRing) Rotate(keyID string, key []byte) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if err := k.add(keyID, key); err != nil {
+		return err
+	}
+	k.currentID = keyID
+
+	return nil
+}
+
+// Add adds a key that only unwraps existing envelopes, e.g. a retired key that is no longer current.
+func (k *KeyRing) Add(keyID string, key []byte) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	return k.add(keyID, key)
+}
+
+// CurrentKeyID returns the ID of the key that wraps the data keys of new envelopes.
+func (k *KeyRing) CurrentKeyID() string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	return k.currentID
+}
+
+func (k *KeyRing) WrapKey(_ context.Context, dataKey []byte) (string, []byte, error) {
+	k.lock.RLock()
+	keyID := k.currentID
+	aead := k.keys[keyID]
+	k.lock.RUnlock()
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+
+	return keyID, aead.Seal(nonce, nonce, dataKey, []byte(keyID)), nil
+}
+
+func (k *KeyRing) UnwrapKey(_ context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	k.lock.RLock()
+	aead, isFound := k.keys[keyID]
+	k.lock.RUnlock()
+	if !isFound {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	if len(wrappedKey) < aead.NonceSize() {
+		return nil, errors.New("wrapped key is too short")
+	}
+	nonce, sealedKey := wrappedKey[:aead.NonceSize()], wrappedKey[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, sealedKey, []byte(keyID))
+}
+
+func (k *KeyRing) add(keyID string, key []byte) error {
+	if keyID == "" || len(keyID) > maxKeyIDSize {
+		return fmt.Errorf("key ID must be 1 to %d bytes long", maxKeyIDSize)
+	}
+	if _, isFound := k.keys[keyID]; isFound {
+		return fmt.Errorf("key %s already exists", keyID)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	k.keys[keyID] = aead
+
+	return nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,107 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/honestbank/event-driver/storage/middleware"
+	"github.com/honestbank/event-driver/utils/compression"
+)
+
+const defaultCompressorTimeout = 10 * time.Second
+
+// EncryptingCompressor compresses the contents with the wrapped Compressor, then encrypts them.
+type EncryptingCompressor struct {
+	encryptor  *Encryptor
+	compressor compression.Compressor
+	timeout    time.Duration
+}
+
+// Compressor returns a compression.Compressor that compresses the contents with the given Compressor and then
+// encrypts them, so that encryption can be plugged in where a store takes a Compressor, e.g. GCSConfig.
+// Compression comes first, since ciphertexts don't compress. Compressors don't get the context of the store
+// operation, so the KeyProvider is called with a timeout of 10 seconds, which WithTimeout changes.
+//
+// Every write is encrypted with a new data key and nonce, so the same content never makes the same ciphertext.
+// Hence the stores that name their objects by the hash of the stored bytes, e.g. GCSEventStore & FSEventStore,
+// no longer deduplicate the writes of the same content: each write makes a new object, which the read policy of
+// the store chooses from like any other.
+func Compressor(encryptor *Encryptor, compressor compression.Compressor) *EncryptingCompressor {
+	return &EncryptingCompressor{
+		encryptor:  encryptor,
+		compressor: compressor,
+		timeout:    defaultCompressorTimeout,
+	}
+}
+
+// WithTimeout sets the timeout of the KeyProvider calls made by Compress & Decompress.
+func (e *EncryptingCompressor) WithTimeout(timeout time.Duration) *EncryptingCompressor {
+	e.timeout = timeout
+
+	return e
+}
+
+func (e *EncryptingCompressor) Compress(input []byte) ([]byte, error) {
+	compressed, err := e.compressor.Compress(input)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	return e.encryptor.Encrypt(ctx, compressed, nil)
+}
+
+func (e *EncryptingCompressor) Decompress(input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	compressed, err := e.encryptor.Decrypt(ctx, input, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.compressor.Decompress(compressed)
+}
+
+// contentEncryptor encrypts the contents into base64-encoded envelopes bound to their key & source.
+type contentEncryptor struct {
+	encryptor *Encryptor
+}
+
+// Middleware returns a middleware.Middleware that encrypts the contents of any event store.
+// The envelopes are base64-encoded, since event stores hold strings, and bound to the key & source of the content,
+// so that a content copied to another key or source can't be decrypted.
+// Like Compressor, it makes a different envelope on every write of the same content, which defeats the deduplication
+// of the stores that name their objects by the hash of their contents.
+func Middleware(encryptor *Encryptor) middleware.Middleware {
+	return middleware.TransformContent(contentEncryptor{encryptor: encryptor})
+}
+
+func (c contentEncryptor) Encode(ctx context.Context, key, source, content string) (string, error) {
+	envelope, err := c.encryptor.Encrypt(ctx, []byte(content), associatedData(key, source))
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+func (c contentEncryptor) Decode(ctx context.Context, key, source, content string) (string, error) {
+	envelope, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMalformedEnvelope, err)
+	}
+	plaintext, err := c.encryptor.Decrypt(ctx, envelope, associatedData(key, source))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// associatedData binds an envelope to the key & source, where the size of the key keeps the pairs unambiguous.
+func associatedData(key, source string) []byte {
+	return []byte(fmt.Sprintf("%d:%s%s", len(key), key, source))
+}